@@ -0,0 +1,333 @@
+package cmd
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"text/template"
+)
+
+// maxExtractSize limita o total extraído de um pacote de release
+const maxExtractSize = 512 << 20
+
+// defaultAssetTemplates são os nomes de asset procurados quando
+// update_assets não é definido: o binário puro e os pacotes no padrão do
+// GoReleaser (00cli_1.2.3_linux_amd64.tar.gz)
+var defaultAssetTemplates = []string{
+	"00cli-{{.OS}}-{{.Arch}}{{.Exe}}",
+	"00cli_{{.Version}}_{{.OS}}_{{.Arch}}.tar.gz",
+	"00cli_{{.Version}}_{{.OS}}_{{.Arch}}.zip",
+}
+
+// assetTemplateData são os campos disponíveis nos templates de nome de asset
+type assetTemplateData struct {
+	Tag     string // v1.2.3
+	Version string // 1.2.3
+	OS      string
+	Arch    string
+	Exe     string // ".exe" no Windows
+}
+
+// releaseBundle é o conteúdo útil de um pacote de release extraído
+type releaseBundle struct {
+	Binary      string
+	Completions []string
+	ManPages    []string
+}
+
+// getAssetTemplates obtém update_assets do settings.json ou os templates padrão
+func getAssetTemplates() []string {
+	root, _ := getProjectRoot()
+	if settings, err := loadSettings(root); err == nil && len(settings.UpdateAssets) > 0 {
+		return settings.UpdateAssets
+	}
+	return defaultAssetTemplates
+}
+
+// assetNames renderiza os templates para a release e a plataforma atual
+func assetNames(templates []string, tag string) ([]string, error) {
+	data := assetTemplateData{
+		Tag:     tag,
+		Version: strings.TrimPrefix(tag, "v"),
+		OS:      runtime.GOOS,
+		Arch:    runtime.GOARCH,
+	}
+	if runtime.GOOS == "windows" {
+		data.Exe = ".exe"
+	}
+
+	names := make([]string, 0, len(templates))
+	for _, text := range templates {
+		tmpl, err := template.New("asset").Option("missingkey=error").Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("template de asset inválido %q: %w", text, err)
+		}
+		var name bytes.Buffer
+		if err := tmpl.Execute(&name, data); err != nil {
+			return nil, fmt.Errorf("template de asset inválido %q: %w", text, err)
+		}
+		names = append(names, name.String())
+	}
+	return names, nil
+}
+
+// archiveKind identifica o formato do pacote pelo nome; vazio para binário puro
+func archiveKind(name string) string {
+	switch {
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return "tar.gz"
+	case strings.HasSuffix(name, ".zip"):
+		return "zip"
+	default:
+		return ""
+	}
+}
+
+// extractRelease extrai o pacote em destDir e localiza o binário, os
+// scripts de completion e as páginas de manual
+func extractRelease(archive, kind, destDir string) (*releaseBundle, error) {
+	var files []string
+	var err error
+	switch kind {
+	case "tar.gz":
+		files, err = extractTarGz(archive, destDir)
+	case "zip":
+		files, err = extractZip(archive, destDir)
+	default:
+		return nil, fmt.Errorf("formato de pacote não suportado: %s", kind)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao extrair pacote: %w", err)
+	}
+
+	binaryName := "00cli"
+	if runtime.GOOS == "windows" {
+		binaryName += ".exe"
+	}
+
+	bundle := &releaseBundle{}
+	binaryDepth := -1
+	for _, name := range files {
+		full := filepath.Join(destDir, filepath.FromSlash(name))
+		base := path.Base(name)
+		dirs := strings.Split(path.Dir(name), "/")
+		switch {
+		case base == binaryName:
+			// O binário mais próximo da raiz do pacote vence
+			if depth := strings.Count(name, "/"); binaryDepth < 0 || depth < binaryDepth {
+				bundle.Binary = full
+				binaryDepth = depth
+			}
+		case containsDir(dirs, "completions", "completion"):
+			bundle.Completions = append(bundle.Completions, full)
+		case containsDir(dirs, "manpages", "man") || isManPage(base):
+			bundle.ManPages = append(bundle.ManPages, full)
+		}
+	}
+
+	if bundle.Binary == "" {
+		return nil, fmt.Errorf("binário %s não encontrado no pacote", binaryName)
+	}
+	return bundle, nil
+}
+
+func containsDir(dirs []string, names ...string) bool {
+	for _, dir := range dirs {
+		for _, name := range names {
+			if dir == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isManPage reconhece páginas de manual (ex: 00cli.1, 00cli-deploy.1.gz)
+func isManPage(name string) bool {
+	name = strings.TrimSuffix(name, ".gz")
+	ext := path.Ext(name)
+	return len(ext) == 2 && ext[1] >= '1' && ext[1] <= '9'
+}
+
+// safeEntryPath valida o nome de uma entrada do pacote e retorna o caminho
+// relativo limpo; rejeita caminhos absolutos e que saiam do destino
+func safeEntryPath(name string) (string, error) {
+	clean := path.Clean(strings.ReplaceAll(name, "\\", "/"))
+	if clean == "." || path.IsAbs(clean) || filepath.IsAbs(clean) || clean == ".." ||
+		strings.HasPrefix(clean, "../") || filepath.VolumeName(clean) != "" || strings.Contains(clean, ":") {
+		return "", fmt.Errorf("caminho inseguro no pacote: %q", name)
+	}
+	return clean, nil
+}
+
+// extractLimit controla o total extraído de um pacote
+type extractLimit struct {
+	remaining int64
+}
+
+func (l *extractLimit) write(dest string, src io.Reader, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(dest, os.O_CREATE|os.O_WRONLY|os.O_EXCL, mode.Perm()|0600)
+	if err != nil {
+		return err
+	}
+
+	n, err := io.Copy(f, io.LimitReader(src, l.remaining+1))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	l.remaining -= n
+	if l.remaining < 0 {
+		return fmt.Errorf("pacote excede %d bytes extraídos", int64(maxExtractSize))
+	}
+	return nil
+}
+
+// extractTarGz extrai apenas arquivos regulares; links e dispositivos são ignorados
+func extractTarGz(archive, destDir string) ([]string, error) {
+	f, err := os.Open(archive)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	limit := &extractLimit{remaining: maxExtractSize}
+	var files []string
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		name, err := safeEntryPath(header.Name)
+		if err != nil {
+			return nil, err
+		}
+		if err := limit.write(filepath.Join(destDir, filepath.FromSlash(name)), tr, header.FileInfo().Mode()); err != nil {
+			return nil, err
+		}
+		files = append(files, name)
+	}
+	return files, nil
+}
+
+// extractZip extrai apenas arquivos regulares; links simbólicos são ignorados
+func extractZip(archive, destDir string) ([]string, error) {
+	zr, err := zip.OpenReader(archive)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	limit := &extractLimit{remaining: maxExtractSize}
+	var files []string
+	for _, entry := range zr.File {
+		if !entry.Mode().IsRegular() {
+			continue
+		}
+
+		name, err := safeEntryPath(entry.Name)
+		if err != nil {
+			return nil, err
+		}
+		rc, err := entry.Open()
+		if err != nil {
+			return nil, err
+		}
+		err = limit.write(filepath.Join(destDir, filepath.FromSlash(name)), rc, entry.Mode())
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, name)
+	}
+	return files, nil
+}
+
+// installExtras copia completions e páginas de manual do pacote para os
+// diretórios do usuário (XDG), sem exigir permissão de administrador
+func installExtras(bundle *releaseBundle) {
+	if runtime.GOOS == "windows" {
+		return
+	}
+
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return
+		}
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+
+	for _, file := range bundle.Completions {
+		dest := completionPath(dataHome, filepath.Base(file))
+		if dest == "" {
+			continue
+		}
+		installExtra(file, dest, "completion")
+	}
+
+	for _, file := range bundle.ManPages {
+		name := filepath.Base(file)
+		section := path.Ext(strings.TrimSuffix(name, ".gz"))
+		if section == "" {
+			continue
+		}
+		installExtra(file, filepath.Join(dataHome, "man", "man"+section[1:], name), "manual")
+	}
+}
+
+// completionPath retorna o destino do script de completion conforme o shell
+func completionPath(dataHome, name string) string {
+	switch {
+	case strings.Contains(name, "bash"):
+		return filepath.Join(dataHome, "bash-completion", "completions", "00cli")
+	case strings.Contains(name, "zsh") || strings.HasPrefix(name, "_"):
+		return filepath.Join(dataHome, "zsh", "site-functions", "_00cli")
+	case strings.Contains(name, "fish"):
+		return filepath.Join(dataHome, "fish", "vendor_completions.d", "00cli.fish")
+	default:
+		return ""
+	}
+}
+
+func installExtra(src, dest, kind string) {
+	data, err := os.ReadFile(src)
+	if err == nil {
+		if err = os.MkdirAll(filepath.Dir(dest), 0755); err == nil {
+			err = writeFileAtomic(dest, data, 0644)
+		}
+	}
+	if err != nil {
+		fmt.Printf("   ⚠️  Não foi possível instalar %s de %s: %v\n", kind, filepath.Base(src), err)
+		return
+	}
+	fmt.Printf("   📚 %s instalado: %s\n", strings.ToUpper(kind[:1])+kind[1:], dest)
+}
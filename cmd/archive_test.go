@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+type archiveEntry struct {
+	Name     string
+	Body     string
+	Symlink  string
+	Typeflag byte
+}
+
+func writeTarGz(t *testing.T, entries []archiveEntry) string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "release.tar.gz")
+	f, err := os.Create(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	for _, e := range entries {
+		header := &tar.Header{Name: e.Name, Mode: 0755, Size: int64(len(e.Body)), Typeflag: tar.TypeReg}
+		if e.Symlink != "" {
+			header = &tar.Header{Name: e.Name, Linkname: e.Symlink, Typeflag: tar.TypeSymlink}
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write([]byte(e.Body))
+	}
+	tw.Close()
+	gz.Close()
+	return file
+}
+
+func writeZip(t *testing.T, entries []archiveEntry) string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "release.zip")
+	f, err := os.Create(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	for _, e := range entries {
+		w, err := zw.Create(e.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(e.Body))
+	}
+	zw.Close()
+	return file
+}
+
+func TestExtractRelease(t *testing.T) {
+	binary := "00cli"
+	if runtime.GOOS == "windows" {
+		binary += ".exe"
+	}
+	entries := []archiveEntry{
+		{Name: "README.md", Body: "leia-me"},
+		{Name: "docs/" + binary, Body: "não é o binário principal"},
+		{Name: binary, Body: "binário"},
+		{Name: "completions/00cli.bash", Body: "complete -F _00cli 00cli"},
+		{Name: "completions/_00cli", Body: "#compdef 00cli"},
+		{Name: "manpages/00cli.1.gz", Body: "man"},
+		{Name: "link", Symlink: "/etc/passwd"},
+	}
+
+	for kind, archive := range map[string]string{"tar.gz": writeTarGz(t, entries), "zip": writeZip(t, entries[:6])} {
+		dest := t.TempDir()
+		bundle, err := extractRelease(archive, kind, dest)
+		if err != nil {
+			t.Fatalf("%s: erro ao extrair: %v", kind, err)
+		}
+		if data, _ := os.ReadFile(bundle.Binary); string(data) != "binário" {
+			t.Errorf("%s: binário errado escolhido: %s", kind, bundle.Binary)
+		}
+		if len(bundle.Completions) != 2 || len(bundle.ManPages) != 1 {
+			t.Errorf("%s: extras inesperados: %+v", kind, bundle)
+		}
+		if _, err := os.Lstat(filepath.Join(dest, "link")); !os.IsNotExist(err) {
+			t.Errorf("%s: links simbólicos não deveriam ser extraídos", kind)
+		}
+	}
+}
+
+func TestExtractRejectsUnsafePaths(t *testing.T) {
+	for _, name := range []string{"../00cli", "bin/../../00cli", "/tmp/00cli", "C:/00cli"} {
+		dest := t.TempDir()
+		entries := []archiveEntry{{Name: name, Body: "malicioso"}}
+
+		if _, err := extractRelease(writeTarGz(t, entries), "tar.gz", dest); err == nil || !strings.Contains(err.Error(), "inseguro") {
+			t.Errorf("tar.gz com %q deveria ser rejeitado, obtido %v", name, err)
+		}
+		if _, err := extractRelease(writeZip(t, entries), "zip", dest); err == nil || !strings.Contains(err.Error(), "inseguro") {
+			t.Errorf("zip com %q deveria ser rejeitado, obtido %v", name, err)
+		}
+	}
+
+	if _, err := extractRelease(writeTarGz(t, []archiveEntry{{Name: "LICENSE", Body: "MIT"}}), "tar.gz", t.TempDir()); err == nil {
+		t.Error("esperado erro para pacote sem binário")
+	}
+}
+
+func TestFindBinaryAssetTemplates(t *testing.T) {
+	archive := fmt.Sprintf("00cli_1.2.3_%s_%s.tar.gz", runtime.GOOS, runtime.GOARCH)
+	release := &Release{
+		TagName: "v1.2.3",
+		Assets: []ReleaseAsset{
+			{Name: "checksums.txt"},
+			{Name: "00cli_1.2.3_plan9_386.tar.gz"},
+			{Name: archive},
+		},
+	}
+
+	t.Setenv("00CLI_UPDATE_SERVER", "")
+	asset := findBinaryAsset(release)
+	if asset == nil || asset.Name != archive {
+		t.Fatalf("esperado %s, obtido %+v", archive, asset)
+	}
+	if archiveKind(asset.Name) != "tar.gz" || archiveKind(getBinaryName()) != "" {
+		t.Error("formato de pacote identificado incorretamente")
+	}
+
+	names, err := assetNames([]string{"cli-{{.Tag}}-{{.OS}}.zip"}, "v1.2.3")
+	if err != nil || names[0] != "cli-v1.2.3-"+runtime.GOOS+".zip" {
+		t.Errorf("template renderizado incorretamente: %v (%v)", names, err)
+	}
+	if _, err := assetNames([]string{"{{.Inexistente}}"}, "v1.2.3"); err == nil {
+		t.Error("esperado erro para campo inexistente no template")
+	}
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"os"
+	"runtime"
+	"runtime/debug"
+)
+
+const defaultVersion = "v0.0.0"
+
+// BuildInfo descreve o binário em execução
+type BuildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	Date      string `json:"date,omitempty"`
+	Dirty     bool   `json:"dirty,omitempty"`
+	GoVersion string `json:"go_version"`
+	Platform  string `json:"platform"`
+}
+
+var buildInfo = resolveBuildInfo("", "", "", nil)
+
+// SetBuildInfo registra os dados de build recebidos por main (-ldflags).
+// Valores vazios são completados com runtime/debug.ReadBuildInfo, o que
+// cobre binários instalados com "go install".
+func SetBuildInfo(version, commit, date string) {
+	info, _ := debug.ReadBuildInfo()
+	buildInfo = resolveBuildInfo(version, commit, date, info)
+}
+
+// resolveBuildInfo combina os valores do -ldflags com os metadados do módulo
+func resolveBuildInfo(version, commit, date string, info *debug.BuildInfo) BuildInfo {
+	b := BuildInfo{
+		Version:   version,
+		Commit:    commit,
+		Date:      date,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+
+	if info != nil {
+		if b.Version == "" && info.Main.Version != "" && info.Main.Version != "(devel)" {
+			b.Version = info.Main.Version
+		}
+		if info.GoVersion != "" {
+			b.GoVersion = info.GoVersion
+		}
+		// Os dados do VCS só valem se o commit não veio do -ldflags
+		if b.Commit == "" {
+			for _, setting := range info.Settings {
+				switch setting.Key {
+				case "vcs.revision":
+					b.Commit = setting.Value
+				case "vcs.time":
+					if b.Date == "" {
+						b.Date = setting.Value
+					}
+				case "vcs.modified":
+					b.Dirty = setting.Value == "true"
+				}
+			}
+		}
+	}
+
+	if b.Version == "" {
+		b.Version = defaultVersion
+	}
+	return b
+}
+
+// currentBuildInfo retorna os dados de build do binário em execução. A
+// variável 00CLI_VERSION, se definida, substitui a versão.
+func currentBuildInfo() BuildInfo {
+	b := buildInfo
+	if v := os.Getenv("00CLI_VERSION"); v != "" {
+		b.Version = v
+	}
+	return b
+}
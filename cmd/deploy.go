@@ -2,8 +2,12 @@ package cmd
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/tstest3213/00cli/internal/deploy"
@@ -17,7 +21,16 @@ e ./.00cli/deploy.json. O deploy utiliza o diretório /provision/ se disponível
 	RunE: runDeploy,
 }
 
+var (
+	releaseName  string
+	deployRoles  []string
+	forceRebuild bool
+)
+
 func init() {
+	deployCmd.Flags().StringVar(&releaseName, "release", "", "Nome da release criada em releases/ (padrão: timestamp)")
+	deployCmd.Flags().StringSliceVar(&deployRoles, "roles", nil, "Executa apenas nos hosts com estes papéis (ex: web,worker)")
+	deployCmd.Flags().BoolVar(&forceRebuild, "rebuild", false, "Refaz o build local mesmo com artefato em cache para o commit")
 	rootCmd.AddCommand(deployCmd)
 }
 
@@ -43,14 +56,37 @@ func runDeploy(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("erro ao carregar deploy.json: %w", err)
 	}
 
+	env, err := applyEnvironment(settings, deployConfig, envName)
+	if err != nil {
+		return err
+	}
+	if len(deployRoles) > 0 {
+		deployConfig.Parallel.Roles = deployRoles
+	}
+
 	if verbose {
 		fmt.Printf("📦 Projeto: %s\n", root)
-		fmt.Printf("🖥️  Servidor: %s@%s:%d\n", settings.Server.User, settings.Server.Host, settings.Server.Port)
+		if env != "" {
+			fmt.Printf("🌎 Ambiente: %s\n", env)
+		}
+		if len(settings.Hosts) > 0 {
+			for _, host := range settings.Hosts {
+				fmt.Printf("🖥️  Host: %s\n", describeHost(settings, host))
+			}
+		} else {
+			fmt.Printf("🖥️  Servidor: %s\n", serverAddress(settings.Server.User, settings.Server.Host, settings.Server.Port))
+		}
+		for _, jump := range settings.Server.Jump {
+			fmt.Printf("🔀 Bastion: %s\n", jump.Host)
+		}
 		fmt.Printf("📋 Versão atual no servidor: %s\n", settings.CurrentVersion)
+		if len(deployConfig.Environment) > 0 {
+			fmt.Printf("🔧 Variáveis: %s\n", deploy.MaskEnv(deployConfig.Environment))
+		}
 	}
 
 	// Verificar se existe diretório provision
-	provisionPath := filepath.Join(root, "provision")
+	provisionPath := provisionDir(root, deployConfig)
 	if _, err := os.Stat(provisionPath); os.IsNotExist(err) {
 		if verbose {
 			fmt.Printf("⚠️  Diretório /provision/ não encontrado\n")
@@ -63,23 +99,28 @@ func runDeploy(cmd *cobra.Command, args []string) error {
 
 	fmt.Println("\n🚀 Iniciando deploy...")
 	fmt.Printf("   Tipo: %s\n", deployConfig.Type)
+	if env != "" {
+		fmt.Printf("   Ambiente: %s\n", env)
+	}
 
 	// Criar configuração para o deployer
 	config := deploy.ConfigMap{
 		"project_path": root,
+		"environment":  deployConfig.Environment,
+		"verbose":      verbose,
 	}
 
 	// Configurar baseado no tipo de deploy
 	switch deployConfig.Type {
 	case "ssh":
-		config["host"] = settings.Server.Host
-		config["port"] = settings.Server.Port
-		config["user"] = settings.Server.User
-		if settings.Server.SSHKey != "" {
-			config["ssh_key"] = settings.Server.SSHKey
+		for k, v := range sshDeployConfig(root, settings, deployConfig) {
+			config[k] = v
+		}
+		if releaseName != "" {
+			config["release_name"] = releaseName
 		}
-		if settings.Server.Password != "" {
-			config["password"] = settings.Server.Password
+		if forceRebuild {
+			config["build_force"] = true
 		}
 
 	case "git":
@@ -88,8 +129,33 @@ func runDeploy(cmd *cobra.Command, args []string) error {
 		config["branch"] = "main"
 		config["commands"] = deployConfig.Commands
 
+	case "docker":
+		if err := checkDockerConfig(deployConfig); err != nil {
+			return err
+		}
+		docker := deployConfig.Docker
+		if docker.Remote {
+			for k, v := range sshDeployConfig(root, settings, deployConfig) {
+				config[k] = v
+			}
+			config["remote"] = true
+			config["remote_dir"] = docker.RemoteDir
+		}
+		config["compose_file"] = docker.ComposeFile
+		config["project_name"] = docker.ProjectName
+		config["profiles"] = docker.Profiles
+		config["env_file"] = docker.EnvFile
+
 	default:
-		return fmt.Errorf("tipo de deploy não suportado: %s. Tipos suportados: ssh, git", deployConfig.Type)
+		return fmt.Errorf("tipo de deploy não suportado: %s. Tipos suportados: ssh, git, docker", deployConfig.Type)
+	}
+
+	stages, err := deployStages(root, deployConfig)
+	if err != nil {
+		return err
+	}
+	if len(stages) > 0 {
+		config["stages"] = stages
 	}
 
 	// Criar deployer
@@ -97,6 +163,9 @@ func runDeploy(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("erro ao criar deployer: %w", err)
 	}
+	if closer, ok := deployer.(io.Closer); ok {
+		defer closer.Close()
+	}
 
 	// Executar deploy
 	if err := deployer.Execute(deployConfig.Commands); err != nil {
@@ -107,3 +176,194 @@ func runDeploy(cmd *cobra.Command, args []string) error {
 	fmt.Println("\n✅ Deploy concluído com sucesso!")
 	return nil
 }
+
+// checkDockerConfig recusa as seções do deploy.json exclusivas do tipo ssh,
+// que o deploy docker ignoraria
+func checkDockerConfig(deployConfig *DeployConfig) error {
+	var sections []string
+	if deployConfig.Sync.Path != "" {
+		sections = append(sections, "sync")
+	}
+	if len(deployConfig.Build.Commands) > 0 {
+		sections = append(sections, "build")
+	}
+	if deployConfig.Releases.Path != "" {
+		sections = append(sections, "releases")
+	}
+	if len(sections) > 0 {
+		return fmt.Errorf("deploy do tipo docker não suporta %s (exclusivo do tipo ssh; use stages)", strings.Join(sections, ", "))
+	}
+	return nil
+}
+
+// sshDeployConfig monta a configuração do SSHDeployer a partir dos arquivos do projeto
+func sshDeployConfig(root string, settings *Settings, deployConfig *DeployConfig) deploy.ConfigMap {
+	config := deploy.ConfigMap{
+		"host":             settings.Server.Host,
+		"port":             settings.Server.Port,
+		"user":             settings.Server.User,
+		"known_hosts":      knownHostsFiles(root),
+		"provision_path":   provisionDir(root, deployConfig),
+		"provision_files":  deployConfig.Provision.Files,
+		"provision_target": deployConfig.Provision.Target,
+		"environment":      deployConfig.Environment,
+		"verbose":          verbose,
+	}
+
+	if settings.Server.SSHKey != "" {
+		config["ssh_key"] = settings.Server.SSHKey
+	}
+	if settings.Server.Password != "" {
+		config["password"] = settings.Server.Password
+	}
+	if settings.Server.HostKey != "" {
+		config["host_key"] = settings.Server.HostKey
+	}
+	if len(settings.Server.IdentityFiles) > 0 {
+		config["identity_files"] = settings.Server.IdentityFiles
+	}
+	if settings.Server.SSHConfig != "" {
+		config["ssh_config"] = settings.Server.SSHConfig
+	}
+	if settings.Server.KeepAlive != "" {
+		config["keepalive"] = settings.Server.KeepAlive
+	}
+	if len(settings.Server.Jump) > 0 {
+		jumps := make([]deploy.JumpHost, len(settings.Server.Jump))
+		for i, jump := range settings.Server.Jump {
+			jumps[i] = deploy.JumpHost{
+				Host:     jump.Host,
+				Port:     jump.Port,
+				User:     jump.User,
+				SSHKey:   jump.SSHKey,
+				Password: jump.Password,
+				HostKey:  jump.HostKey,
+			}
+		}
+		config["jump"] = jumps
+	}
+	if deployConfig.SessionMode != "" {
+		config["session_mode"] = deployConfig.SessionMode
+	}
+	if deployConfig.Shell != "" {
+		config["shell"] = deployConfig.Shell
+	}
+	if len(settings.Hosts) > 0 {
+		hosts := make([]deploy.HostTarget, len(settings.Hosts))
+		for i, host := range settings.Hosts {
+			hosts[i] = deploy.HostTarget{
+				Name:    host.Name,
+				Host:    host.Host,
+				Port:    host.Port,
+				User:    host.User,
+				HostKey: host.HostKey,
+				Roles:   host.Roles,
+			}
+		}
+		config["hosts"] = hosts
+		config["roles"] = deployConfig.Parallel.Roles
+		config["concurrency"] = deployConfig.Parallel.Concurrency
+		config["rolling"] = deployConfig.Parallel.Rolling
+		config["batch_size"] = deployConfig.Parallel.BatchSize
+	}
+	if len(deployConfig.Build.Commands) > 0 {
+		config["project_path"] = root
+		config["build_commands"] = deployConfig.Build.Commands
+		config["build_output"] = deployConfig.Build.Output
+		config["build_target"] = deployConfig.Build.Target
+		config["build_cache_dir"] = filepath.Join(root, ".00cli", "cache", "builds")
+	}
+	if deployConfig.Sync.Path != "" {
+		config["sync_path"] = resolveProjectPath(root, deployConfig.Sync.Path)
+		config["sync_target"] = deployConfig.Sync.Target
+		config["sync_delete"] = deployConfig.Sync.Delete
+		config["sync_exclude"] = deployConfig.Sync.Exclude
+		config["sync_ignore_file"] = filepath.Join(root, deploy.IgnoreFile)
+	}
+	if deployConfig.Releases.Path != "" {
+		config["releases_path"] = deployConfig.Releases.Path
+		config["releases_keep"] = deployConfig.Releases.Keep
+		config["releases_shared"] = deployConfig.Releases.Shared
+		config["releases_restart"] = deployConfig.Releases.Restart
+	}
+
+	return config
+}
+
+// deployStages converte os estágios do deploy.json para o pipeline do
+// deployer; os scripts de nível superior entram no estágio build
+func deployStages(root string, deployConfig *DeployConfig) (deploy.Pipeline, error) {
+	pipeline := make(deploy.Pipeline, len(deployConfig.Stages)+1)
+	for name, stage := range deployConfig.Stages {
+		var timeout time.Duration
+		if stage.Timeout != "" {
+			var err error
+			if timeout, err = time.ParseDuration(stage.Timeout); err != nil || timeout <= 0 {
+				return nil, fmt.Errorf("stages.%s.timeout inválido: %s (ex: \"10m\")", name, stage.Timeout)
+			}
+		}
+		pipeline[name] = deploy.Stage{
+			Commands:        stage.Commands,
+			Scripts:         deployScripts(root, stage.Scripts, stage.Interpreter),
+			Dir:             stage.Dir,
+			Target:          stage.Target,
+			Timeout:         timeout,
+			ContinueOnError: stage.ContinueOnError,
+		}
+	}
+
+	if len(deployConfig.Scripts) > 0 {
+		build := pipeline[deploy.StageBuild]
+		if len(build.Scripts) > 0 {
+			return nil, fmt.Errorf("use scripts ou stages.build.scripts, não ambos")
+		}
+		build.Scripts = deployScripts(root, deployConfig.Scripts, deployConfig.Interpreter)
+		pipeline[deploy.StageBuild] = build
+	}
+	return pipeline, pipeline.Validate()
+}
+
+// deployScripts resolve os scripts a partir da raiz do projeto
+func deployScripts(root string, files []string, interpreter string) []deploy.Script {
+	if len(files) == 0 {
+		return nil
+	}
+	scripts := make([]deploy.Script, len(files))
+	for i, file := range files {
+		scripts[i] = deploy.Script{Path: resolveProjectPath(root, file), Interpreter: interpreter}
+	}
+	return scripts
+}
+
+// serverAddress formata o endereço como no ssh ([usuário@]host[:porta]),
+// omitindo o que fica a cargo do ssh_config
+func serverAddress(user, host string, port int) string {
+	addr := host
+	if user != "" {
+		addr = user + "@" + addr
+	}
+	if port != 0 {
+		addr += ":" + strconv.Itoa(port)
+	}
+	return addr
+}
+
+// describeHost formata um host da lista com os valores herdados de server
+func describeHost(settings *Settings, host HostConfig) string {
+	user, port := host.User, host.Port
+	if user == "" {
+		user = settings.Server.User
+	}
+	if port == 0 {
+		port = settings.Server.Port
+	}
+
+	desc := serverAddress(user, host.Host, port)
+	if host.Name != "" {
+		desc = host.Name + " (" + desc + ")"
+	}
+	if len(host.Roles) > 0 {
+		desc += " [" + strings.Join(host.Roles, ", ") + "]"
+	}
+	return desc
+}
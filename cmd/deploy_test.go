@@ -4,7 +4,9 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestLoadSettings(t *testing.T) {
@@ -142,3 +144,164 @@ func TestCheckProjectStructure(t *testing.T) {
 		t.Errorf("não esperado erro quando ambos arquivos existem: %v", err)
 	}
 }
+
+func TestApplyEnvironment(t *testing.T) {
+	newConfig := func() (*Settings, *DeployConfig) {
+		settings := &Settings{DefaultEnvironment: "staging"}
+		settings.Server = ServerConfig{Host: "base.com", Port: 22, User: "deploy"}
+		settings.Environments = map[string]SettingsEnvironment{
+			"staging": {Server: ServerConfig{Host: "staging.com"}},
+			"production": {
+				Server: ServerConfig{Host: "prod.com", Port: 2222},
+				Hosts:  []HostConfig{{Name: "web1", Host: "10.0.0.11"}, {Name: "web2", Host: "10.0.0.12"}},
+			},
+		}
+
+		config := &DeployConfig{
+			Type:        "ssh",
+			Commands:    []string{"git pull"},
+			Environment: map[string]string{"NODE_ENV": "production", "LOG_LEVEL": "info"},
+		}
+		config.Provision.Target = "/etc/app"
+		config.Sync.Path = "dist"
+		config.Sync.Target = "/srv/app/public"
+		config.Stages = map[string]StageConfig{
+			"migrate":    {Commands: []string{"php artisan migrate"}},
+			"on_failure": {Commands: []string{"notify falhou"}, Target: "local"},
+		}
+		prod := DeployEnvironment{
+			Commands:    []string{"git pull", "pm2 reload app"},
+			Environment: map[string]string{"LOG_LEVEL": "warn"},
+		}
+		prod.Provision.Target = "/etc/app-prod"
+		prod.Sync.Target = "/srv/app-prod/public"
+		prod.Stages = map[string]StageConfig{"migrate": {Commands: []string{"php artisan migrate --force"}, Timeout: "5m"}}
+		config.Environments = map[string]DeployEnvironment{"production": prod}
+
+		return settings, config
+	}
+
+	// Ambiente padrão herda tudo exceto o host
+	settings, config := newConfig()
+	env, err := applyEnvironment(settings, config, "")
+	if err != nil {
+		t.Fatalf("erro ao aplicar ambiente padrão: %v", err)
+	}
+	if env != "staging" || settings.Server.Host != "staging.com" || settings.Server.User != "deploy" {
+		t.Errorf("ambiente padrão aplicado incorretamente: %s %+v", env, settings.Server)
+	}
+	if len(config.Commands) != 1 || config.Provision.Target != "/etc/app" || config.Sync.Target != "/srv/app/public" {
+		t.Errorf("staging deveria herdar comandos e provision: %+v", config)
+	}
+
+	// Flag --env tem precedência e mescla variáveis
+	settings, config = newConfig()
+	env, err = applyEnvironment(settings, config, "production")
+	if err != nil {
+		t.Fatalf("erro ao aplicar production: %v", err)
+	}
+	if env != "production" || settings.Server.Host != "prod.com" || settings.Server.Port != 2222 || settings.Server.User != "deploy" {
+		t.Errorf("servidor de production incorreto: %+v", settings.Server)
+	}
+	if len(settings.Hosts) != 2 || settings.Hosts[1].Name != "web2" {
+		t.Errorf("hosts de production incorretos: %+v", settings.Hosts)
+	}
+	if len(config.Commands) != 2 || config.Provision.Target != "/etc/app-prod" || config.Sync.Target != "/srv/app-prod/public" {
+		t.Errorf("configuração de production incorreta: %+v", config)
+	}
+	if config.Environment["NODE_ENV"] != "production" || config.Environment["LOG_LEVEL"] != "warn" {
+		t.Errorf("variáveis mescladas incorretamente: %v", config.Environment)
+	}
+	if config.Stages["migrate"].Timeout != "5m" || len(config.Stages["on_failure"].Commands) != 1 {
+		t.Errorf("estágios mesclados incorretamente: %+v", config.Stages)
+	}
+
+	// Ambiente inexistente
+	settings, config = newConfig()
+	if _, err := applyEnvironment(settings, config, "qa"); err == nil {
+		t.Error("esperado erro para ambiente inexistente")
+	}
+
+	// Sem ambientes nem padrão, nada muda
+	settings, config = newConfig()
+	settings.DefaultEnvironment = ""
+	env, err = applyEnvironment(settings, config, "")
+	if err != nil || env != "" || settings.Server.Host != "base.com" {
+		t.Errorf("configuração base não deveria mudar: %s %v %+v", env, err, settings.Server)
+	}
+}
+
+func TestDeployStages(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "provision"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "provision", "migrate.sh"), []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	config := &DeployConfig{
+		Scripts:     []string{"provision/migrate.sh"},
+		Interpreter: "bash",
+		Stages: map[string]StageConfig{
+			"migrate": {Commands: []string{"migrate"}, Target: "remote", Timeout: "90s", ContinueOnError: true},
+		},
+	}
+	stages, err := deployStages(root, config)
+	if err != nil {
+		t.Fatalf("erro ao converter estágios: %v", err)
+	}
+	if migrate := stages["migrate"]; migrate.Timeout != 90*time.Second || !migrate.ContinueOnError || migrate.Target != "remote" {
+		t.Errorf("estágio convertido incorretamente: %+v", migrate)
+	}
+	scripts := stages["build"].Scripts
+	if len(scripts) != 1 || scripts[0].Path != filepath.Join(root, "provision", "migrate.sh") || scripts[0].Interpreter != "bash" {
+		t.Errorf("scripts deveriam entrar no estágio build: %+v", scripts)
+	}
+
+	for _, invalid := range []*DeployConfig{
+		{Stages: map[string]StageConfig{"migrate": {Timeout: "cinco minutos"}}},
+		{Stages: map[string]StageConfig{"migrate": {Timeout: "-1m"}}},
+		{Stages: map[string]StageConfig{"deploy": {Commands: []string{"true"}}}},
+		{Stages: map[string]StageConfig{"build": {Target: "cluster"}}},
+		{Scripts: []string{"provision/inexistente.sh"}},
+		{Scripts: []string{"provision/migrate.sh"}, Stages: map[string]StageConfig{"build": {Scripts: []string{"provision/migrate.sh"}}}},
+	} {
+		if _, err := deployStages(root, invalid); err == nil {
+			t.Errorf("esperado erro para %+v", invalid)
+		}
+	}
+}
+
+func TestCheckDockerConfig(t *testing.T) {
+	config := &DeployConfig{Type: "docker"}
+	config.Docker.Remote = true
+	if err := checkDockerConfig(config); err != nil {
+		t.Fatalf("erro inesperado: %v", err)
+	}
+
+	// Seções do tipo ssh não são ignoradas em silêncio
+	config.Sync.Path = "public"
+	config.Releases.Path = "/srv/app"
+	err := checkDockerConfig(config)
+	if err == nil || !strings.Contains(err.Error(), "sync, releases") {
+		t.Errorf("esperado erro citando sync e releases, obtido %v", err)
+	}
+}
+
+func TestServerAddress(t *testing.T) {
+	// Porta e usuário vazios ficam a cargo do ssh_config
+	for _, tt := range []struct {
+		user, host string
+		port       int
+		want       string
+	}{
+		{"deploy", "prod.com", 2222, "deploy@prod.com:2222"},
+		{"", "producao", 0, "producao"},
+		{"ops", "producao", 0, "ops@producao"},
+	} {
+		if got := serverAddress(tt.user, tt.host, tt.port); got != tt.want {
+			t.Errorf("esperado %q, obtido %q", tt.want, got)
+		}
+	}
+}
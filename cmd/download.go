@@ -0,0 +1,292 @@
+package cmd
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tstest3213/00cli/internal/tty"
+)
+
+const (
+	downloadAttempts = 5
+
+	// downloadStallTimeout cancela uma transferência que não recebe dados;
+	// não há limite para a duração total, para não falhar em links lentos
+	downloadStallTimeout = 60 * time.Second
+)
+
+// downloadBackoff é a espera antes da primeira nova tentativa (dobra a cada
+// falha, até 30s); variável para os testes
+var downloadBackoff = time.Second
+
+// newHTTPClient retorna um cliente que respeita HTTPS_PROXY/NO_PROXY e o
+// bundle de CAs de 00CLI_CA_BUNDLE (ou SSL_CERT_FILE)
+func newHTTPClient(timeout time.Duration) (*http.Client, error) {
+	transport, err := newTransport()
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{Transport: transport, Timeout: timeout}, nil
+}
+
+func newTransport() (http.RoundTripper, error) {
+	base := http.DefaultTransport.(*http.Transport).Clone()
+	base.Proxy = http.ProxyFromEnvironment
+	base.ResponseHeaderTimeout = 30 * time.Second
+
+	bundle := os.Getenv("00CLI_CA_BUNDLE")
+	if bundle == "" {
+		bundle = os.Getenv("SSL_CERT_FILE")
+	}
+	if bundle == "" {
+		return base, nil
+	}
+
+	pem, err := os.ReadFile(bundle)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler bundle de CAs %s: %w", bundle, err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("bundle de CAs %s não contém certificados PEM válidos", bundle)
+	}
+	base.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	return base, nil
+}
+
+// downloadFile baixa url em dest, retomando com Range a partir do que já foi
+// recebido quando a conexão cai e tentando de novo em erros transitórios
+func downloadFile(url, dest string) error {
+	client, err := newHTTPClient(0)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(dest, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	d := &download{client: client, url: url, file: file}
+	if tty.IsTerminal(os.Stderr) {
+		d.progress = newProgressBar(os.Stderr)
+		defer d.progress.Finish()
+	}
+
+	backoff := downloadBackoff
+	for attempt := 1; ; attempt++ {
+		err = d.attempt()
+		if err == nil {
+			return nil
+		}
+		if attempt == downloadAttempts || !isTransient(err) {
+			return err
+		}
+
+		if d.progress != nil {
+			d.progress.Clear()
+		}
+		fmt.Fprintf(os.Stderr, "⚠️  Falha no download (%v), nova tentativa em %s...\n", err, backoff)
+		time.Sleep(backoff)
+		backoff = min(backoff*2, 30*time.Second)
+	}
+}
+
+// download guarda o estado entre tentativas de um mesmo arquivo
+type download struct {
+	client    *http.Client
+	url       string
+	file      *os.File
+	written   int64
+	total     int64  // tamanho total, se conhecido
+	validator string // ETag ou Last-Modified, para If-Range
+	progress  *progressBar
+}
+
+// httpStatusError é uma resposta HTTP inesperada
+type httpStatusError struct {
+	Code int
+}
+
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("status code: %d", e.Code)
+}
+
+func (d *download) attempt() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", "00cli-updater")
+	if d.written > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", d.written))
+		if d.validator != "" {
+			req.Header.Set("If-Range", d.validator)
+		}
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && d.written > 0:
+		start, total, ok := parseContentRange(resp.Header.Get("Content-Range"))
+		if !ok || start != d.written {
+			return d.restart()
+		}
+		if total > 0 {
+			d.total = total
+		}
+	case resp.StatusCode == http.StatusOK:
+		// Servidor sem suporte a Range (ou arquivo alterado): recomeçar
+		if err := d.reset(); err != nil {
+			return err
+		}
+		d.total = resp.ContentLength
+		if etag := resp.Header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			d.validator = etag
+		} else {
+			d.validator = resp.Header.Get("Last-Modified")
+		}
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && d.written > 0:
+		if d.total > 0 && d.written == d.total {
+			return nil
+		}
+		return d.restart()
+	default:
+		return &httpStatusError{Code: resp.StatusCode}
+	}
+
+	if d.progress != nil {
+		d.progress.Start(d.written, d.total)
+	}
+
+	// Cancelar a requisição se nenhum dado chegar dentro de downloadStallTimeout
+	watchdog := time.AfterFunc(downloadStallTimeout, cancel)
+	defer watchdog.Stop()
+
+	buf := make([]byte, 32*1024)
+	for {
+		n, readErr := resp.Body.Read(buf)
+		if n > 0 {
+			watchdog.Reset(downloadStallTimeout)
+			if _, err := d.file.Write(buf[:n]); err != nil {
+				return err
+			}
+			d.written += int64(n)
+			if d.progress != nil {
+				d.progress.Set(d.written)
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			if ctx.Err() != nil {
+				return fmt.Errorf("download parado por mais de %s: %w", downloadStallTimeout, readErr)
+			}
+			return readErr
+		}
+	}
+
+	if d.total > 0 && d.written < d.total {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+
+// restart descarta o que foi baixado e pede uma nova tentativa desde o início
+func (d *download) restart() error {
+	if err := d.reset(); err != nil {
+		return err
+	}
+	return errRestartDownload
+}
+
+func (d *download) reset() error {
+	if err := d.file.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := d.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	d.written = 0
+	d.total = 0
+	return nil
+}
+
+// errRestartDownload indica que o servidor não retomou do ponto esperado
+var errRestartDownload = errors.New("servidor não retomou o download, recomeçando")
+
+// isTransient indica se vale tentar de novo: erros de rede, transferência
+// interrompida, 408, 429 e 5xx
+func isTransient(err error) bool {
+	var status *httpStatusError
+	if errors.As(err, &status) {
+		return status.Code == http.StatusRequestTimeout || status.Code == http.StatusTooManyRequests || status.Code >= 500
+	}
+
+	// Certificado inválido não se resolve tentando de novo
+	var certErr *tls.CertificateVerificationError
+	var authorityErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	if errors.As(err, &certErr) || errors.As(err, &authorityErr) || errors.As(err, &hostnameErr) {
+		return false
+	}
+
+	var netErr net.Error
+	return errors.Is(err, errRestartDownload) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, context.Canceled) ||
+		errors.As(err, &netErr) ||
+		strings.Contains(err.Error(), "connection reset")
+}
+
+// parseContentRange interpreta "bytes <início>-<fim>/<total>"; total é -1
+// quando desconhecido ("*")
+func parseContentRange(value string) (start, total int64, ok bool) {
+	value, found := strings.CutPrefix(value, "bytes ")
+	if !found {
+		return 0, 0, false
+	}
+	span, size, found := strings.Cut(value, "/")
+	if !found {
+		return 0, 0, false
+	}
+	first, _, found := strings.Cut(span, "-")
+	if !found {
+		return 0, 0, false
+	}
+
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	total = -1
+	if size != "*" {
+		if total, err = strconv.ParseInt(size, 10, 64); err != nil {
+			return 0, 0, false
+		}
+	}
+	return start, total, true
+}
@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDownloadResumesWithRange(t *testing.T) {
+	downloadBackoff = time.Millisecond
+	content := bytes.Repeat([]byte("00cli"), 20000)
+
+	var requests atomic.Int32
+	var resumedFrom string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			// Primeira tentativa: metade do arquivo e a conexão cai
+			w.Header().Set("Content-Length", "100000")
+			w.Header().Set("ETag", `"v1"`)
+			w.Write(content[:len(content)/2])
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			conn.Close()
+			return
+		}
+		resumedFrom = r.Header.Get("Range")
+		w.Header().Set("ETag", `"v1"`)
+		http.ServeContent(w, r, "00cli", time.Time{}, bytes.NewReader(content))
+	}))
+	defer ts.Close()
+
+	dest := filepath.Join(t.TempDir(), "00cli-update")
+	if err := downloadFile(ts.URL, dest); err != nil {
+		t.Fatalf("erro no download: %v", err)
+	}
+	data, _ := os.ReadFile(dest)
+	if !bytes.Equal(data, content) {
+		t.Errorf("conteúdo retomado difere do original (%d de %d bytes)", len(data), len(content))
+	}
+	if !strings.HasPrefix(resumedFrom, "bytes=") || resumedFrom == "bytes=0-" {
+		t.Errorf("segunda tentativa deveria retomar com Range, obtido %q", resumedFrom)
+	}
+}
+
+func TestDownloadRetries(t *testing.T) {
+	downloadBackoff = time.Millisecond
+
+	var requests atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/ausente":
+			http.NotFound(w, r)
+		case requests.Add(1) < 3:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.Write([]byte("binário"))
+		}
+	}))
+	defer ts.Close()
+
+	dest := filepath.Join(t.TempDir(), "00cli-update")
+	if err := downloadFile(ts.URL, dest); err != nil {
+		t.Fatalf("esperado sucesso após erros 503: %v", err)
+	}
+	if requests.Load() != 3 {
+		t.Errorf("esperadas 3 tentativas, obtidas %d", requests.Load())
+	}
+
+	requests.Store(0)
+	if err := downloadFile(ts.URL+"/ausente", dest); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("esperado erro 404 sem novas tentativas, obtido %v", err)
+	}
+}
+
+func TestDownloadWithCABundle(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("binário"))
+	}))
+	defer ts.Close()
+
+	dest := filepath.Join(t.TempDir(), "00cli-update")
+	t.Setenv("00CLI_CA_BUNDLE", "")
+	t.Setenv("SSL_CERT_FILE", "")
+	downloadBackoff = time.Millisecond
+	err := downloadFile(ts.URL, dest)
+	if err == nil {
+		t.Fatal("certificado autoassinado não deveria ser aceito sem o bundle")
+	}
+	if isTransient(err) {
+		t.Errorf("erro de certificado não deveria gerar novas tentativas: %v", err)
+	}
+
+	bundle := filepath.Join(t.TempDir(), "ca.pem")
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw})
+	if err := os.WriteFile(bundle, cert, 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("00CLI_CA_BUNDLE", bundle)
+	if err := downloadFile(ts.URL, dest); err != nil {
+		t.Errorf("download com bundle de CAs falhou: %v", err)
+	}
+}
+
+func TestParseContentRange(t *testing.T) {
+	tests := []struct {
+		value        string
+		start, total int64
+		ok           bool
+	}{
+		{"bytes 100-199/200", 100, 200, true},
+		{"bytes 0-9/*", 0, -1, true},
+		{"bytes */200", 0, 0, false},
+		{"items 0-9/10", 0, 0, false},
+	}
+	for _, tt := range tests {
+		start, total, ok := parseContentRange(tt.value)
+		if ok != tt.ok || (ok && (start != tt.start || total != tt.total)) {
+			t.Errorf("parseContentRange(%q) = %d, %d, %v", tt.value, start, total, ok)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// selectEnvironment retorna o ambiente escolhido: a flag --env ou, na falta
+// dela, default_environment do settings.json. Vazio usa a configuração de
+// nível superior.
+func selectEnvironment(settings *Settings, flag string) string {
+	if flag != "" {
+		return flag
+	}
+	return settings.DefaultEnvironment
+}
+
+// environmentNames retorna os ambientes definidos em settings.json e deploy.json
+func environmentNames(settings *Settings, config *DeployConfig) []string {
+	seen := make(map[string]bool)
+	for name := range settings.Environments {
+		seen[name] = true
+	}
+	for name := range config.Environments {
+		seen[name] = true
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// applyEnvironment sobrepõe a configuração do ambiente sobre os valores de
+// nível superior de settings e deploy config. Retorna o nome do ambiente
+// aplicado (vazio quando nenhum foi selecionado).
+func applyEnvironment(settings *Settings, config *DeployConfig, flag string) (string, error) {
+	name := selectEnvironment(settings, flag)
+	if name == "" {
+		return "", nil
+	}
+
+	settingsEnv, inSettings := settings.Environments[name]
+	deployEnv, inDeploy := config.Environments[name]
+	if !inSettings && !inDeploy {
+		available := environmentNames(settings, config)
+		if len(available) == 0 {
+			return "", fmt.Errorf("ambiente '%s' não encontrado: nenhum ambiente definido em environments", name)
+		}
+		return "", fmt.Errorf("ambiente '%s' não encontrado. Disponíveis: %s", name, strings.Join(available, ", "))
+	}
+
+	if inSettings {
+		mergeServer(&settings.Server, settingsEnv.Server)
+		if len(settingsEnv.Hosts) > 0 {
+			settings.Hosts = settingsEnv.Hosts
+		}
+	}
+
+	if inDeploy {
+		if len(deployEnv.Commands) > 0 {
+			config.Commands = deployEnv.Commands
+		}
+		if len(deployEnv.Environment) > 0 {
+			merged := make(map[string]string, len(config.Environment)+len(deployEnv.Environment))
+			for k, v := range config.Environment {
+				merged[k] = v
+			}
+			for k, v := range deployEnv.Environment {
+				merged[k] = v
+			}
+			config.Environment = merged
+		}
+		if len(deployEnv.Provision.Files) > 0 {
+			config.Provision.Files = deployEnv.Provision.Files
+		}
+		if deployEnv.Provision.Target != "" {
+			config.Provision.Target = deployEnv.Provision.Target
+		}
+		if deployEnv.Releases.Path != "" {
+			config.Releases.Path = deployEnv.Releases.Path
+		}
+		if deployEnv.Sync.Target != "" {
+			config.Sync.Target = deployEnv.Sync.Target
+		}
+		if len(deployEnv.Stages) > 0 {
+			stages := make(map[string]StageConfig, len(config.Stages)+len(deployEnv.Stages))
+			for name, stage := range config.Stages {
+				stages[name] = stage
+			}
+			for name, stage := range deployEnv.Stages {
+				stages[name] = stage
+			}
+			config.Stages = stages
+		}
+	}
+
+	return name, nil
+}
+
+// mergeServer copia para base os campos preenchidos de override
+func mergeServer(base *ServerConfig, override ServerConfig) {
+	if override.Host != "" {
+		base.Host = override.Host
+	}
+	if override.Port != 0 {
+		base.Port = override.Port
+	}
+	if override.User != "" {
+		base.User = override.User
+	}
+	if override.SSHKey != "" {
+		base.SSHKey = override.SSHKey
+	}
+	if override.Password != "" {
+		base.Password = override.Password
+	}
+	if override.HostKey != "" {
+		base.HostKey = override.HostKey
+	}
+	if len(override.IdentityFiles) > 0 {
+		base.IdentityFiles = override.IdentityFiles
+	}
+	if override.SSHConfig != "" {
+		base.SSHConfig = override.SSHConfig
+	}
+	if len(override.Jump) > 0 {
+		base.Jump = override.Jump
+	}
+	if override.KeepAlive != "" {
+		base.KeepAlive = override.KeepAlive
+	}
+}
@@ -42,8 +42,8 @@ func runInit(cmd *cobra.Command, args []string) error {
 		}
 		// Configurações padrão do servidor
 		settings.Server.Host = "example.com"
-		settings.Server.Port = 22
-		settings.Server.User = "deploy"
+		// Port e User ficam vazios: quando definidos, têm precedência sobre o
+		// ~/.ssh/config do host
 		// Deixar SSHKey e Password vazios para o usuário preencher
 		// UpdateServer pode ser configurado para usar servidor customizado (ex: "http://192.168.1.100:8080/updates")
 
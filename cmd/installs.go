@@ -0,0 +1,453 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultUpdateKeep é a quantidade de binários guardados para rollback
+	defaultUpdateKeep = 3
+	installsFile      = "installs.json"
+	validateTimeout   = 15 * time.Second
+)
+
+// installRecord registra uma instalação feita por 00cli update
+type installRecord struct {
+	Version     string    `json:"version"`
+	InstalledAt time.Time `json:"installed_at"`
+	Previous    string    `json:"previous,omitempty"`
+	Binary      string    `json:"binary,omitempty"` // cópia guardada em <estado>/binaries
+	Rollback    bool      `json:"rollback,omitempty"`
+}
+
+// installStore guarda os últimos binários instalados e o histórico de
+// instalações no diretório de estado do usuário
+type installStore struct {
+	Dir  string
+	Keep int
+}
+
+// userStateDir retorna o diretório de estado do 00cli: 00CLI_STATE_DIR,
+// $XDG_STATE_HOME/00cli ou o padrão do sistema operacional
+func userStateDir() (string, error) {
+	if dir := os.Getenv("00CLI_STATE_DIR"); dir != "" {
+		return dir, nil
+	}
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, "00cli"), nil
+	}
+
+	switch runtime.GOOS {
+	case "windows", "darwin":
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			return "", fmt.Errorf("erro ao obter diretório de estado: %w", err)
+		}
+		return filepath.Join(dir, "00cli"), nil
+	default:
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("erro ao obter diretório de estado: %w", err)
+		}
+		return filepath.Join(home, ".local", "state", "00cli"), nil
+	}
+}
+
+// newInstallStore abre o histórico de instalações do usuário
+func newInstallStore() (*installStore, error) {
+	dir, err := userStateDir()
+	if err != nil {
+		return nil, err
+	}
+	return &installStore{Dir: dir, Keep: getUpdateKeep()}, nil
+}
+
+// getUpdateKeep obtém update_keep do settings.json (padrão: 3)
+func getUpdateKeep() int {
+	root, _ := getProjectRoot()
+	if settings, err := loadSettings(root); err == nil && settings.UpdateKeep > 0 {
+		return settings.UpdateKeep
+	}
+	return defaultUpdateKeep
+}
+
+func (s *installStore) binariesDir() string {
+	return filepath.Join(s.Dir, "binaries")
+}
+
+// history retorna as instalações, da mais antiga para a mais recente
+func (s *installStore) history() ([]installRecord, error) {
+	data, err := os.ReadFile(filepath.Join(s.Dir, installsFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler histórico de instalações: %w", err)
+	}
+
+	var history struct {
+		Installs []installRecord `json:"installs"`
+	}
+	if err := json.Unmarshal(data, &history); err != nil {
+		return nil, fmt.Errorf("erro ao ler histórico de instalações: %w", err)
+	}
+	return history.Installs, nil
+}
+
+// record adiciona uma instalação ao histórico e remove binários além de Keep
+func (s *installStore) record(rec installRecord) error {
+	records, err := s.history()
+	if err != nil {
+		return err
+	}
+	records = append(records, rec)
+
+	keep := s.Keep
+	if keep <= 0 {
+		keep = defaultUpdateKeep
+	}
+
+	// Manter os binários das últimas versões distintas instaladas
+	kept := make(map[string]bool)
+	for i := len(records) - 1; i >= 0 && len(kept) < keep; i-- {
+		if records[i].Binary != "" {
+			kept[records[i].Binary] = true
+		}
+	}
+	for i := range records {
+		if records[i].Binary != "" && !kept[records[i].Binary] {
+			os.Remove(records[i].Binary)
+			records[i].Binary = ""
+		}
+	}
+
+	data, err := json.MarshalIndent(map[string]interface{}{"installs": records}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.Dir, 0755); err != nil {
+		return fmt.Errorf("erro ao criar diretório de estado: %w", err)
+	}
+	if err := writeFileAtomic(filepath.Join(s.Dir, installsFile), data, 0644); err != nil {
+		return fmt.Errorf("erro ao salvar histórico de instalações: %w", err)
+	}
+	return nil
+}
+
+// keepBinary guarda uma cópia do binário da versão informada e retorna o caminho
+func (s *installStore) keepBinary(src, version string) (string, error) {
+	name := "00cli-" + strings.NewReplacer("/", "_", "\\", "_").Replace(version)
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	dest := filepath.Join(s.binariesDir(), name)
+	if src == dest {
+		return dest, nil
+	}
+
+	if err := os.MkdirAll(s.binariesDir(), 0755); err != nil {
+		return "", fmt.Errorf("erro ao criar diretório de binários: %w", err)
+	}
+	if err := installBinary(src, dest); err != nil {
+		return "", fmt.Errorf("erro ao guardar binário %s: %w", version, err)
+	}
+	return dest, nil
+}
+
+// previous retorna a instalação mais recente, com binário guardado, de uma
+// versão diferente da atual; version seleciona uma versão específica
+func (s *installStore) previous(current, version string) (*installRecord, error) {
+	records, err := s.history()
+	if err != nil {
+		return nil, err
+	}
+	for i := len(records) - 1; i >= 0; i-- {
+		rec := records[i]
+		if rec.Binary == "" {
+			continue
+		}
+		if version != "" {
+			if cmp, err := compareVersions(rec.Version, version); err != nil || cmp != 0 {
+				continue
+			}
+		} else if cmp, err := compareVersions(rec.Version, current); err == nil && cmp == 0 {
+			continue
+		}
+		if _, err := os.Stat(rec.Binary); err != nil {
+			continue
+		}
+		return &rec, nil
+	}
+
+	if version != "" {
+		return nil, fmt.Errorf("versão %s não está entre os binários guardados (veja 00cli update --list-installed)", version)
+	}
+	return nil, fmt.Errorf("nenhuma versão anterior guardada para rollback")
+}
+
+// ensureCurrent guarda o binário em execução, se ainda não estiver no
+// histórico, para que a primeira atualização também possa ser revertida
+func (s *installStore) ensureCurrent(binary, version string) (string, error) {
+	records, err := s.history()
+	if err != nil {
+		return "", err
+	}
+	if n := len(records); n > 0 && records[n-1].Binary != "" {
+		if cmp, err := compareVersions(records[n-1].Version, version); err == nil && cmp == 0 {
+			if _, err := os.Stat(records[n-1].Binary); err == nil {
+				return records[n-1].Binary, nil
+			}
+		}
+	}
+
+	kept, err := s.keepBinary(binary, version)
+	if err != nil {
+		return "", err
+	}
+	installedAt := time.Now().UTC()
+	if info, err := os.Stat(binary); err == nil {
+		installedAt = info.ModTime().UTC()
+	}
+	if err := s.record(installRecord{Version: version, InstalledAt: installedAt, Binary: kept}); err != nil {
+		return "", err
+	}
+	return kept, nil
+}
+
+// installVersion instala src em target guardando o binário atual, valida a
+// versão instalada e restaura o binário anterior se a validação falhar
+func installVersion(src, target, version, current string, rollback bool) error {
+	store, err := newInstallStore()
+	if err != nil {
+		return err
+	}
+
+	backup, err := store.ensureCurrent(target, current)
+	if err != nil {
+		return fmt.Errorf("erro ao guardar binário atual, nada foi alterado: %w", err)
+	}
+	kept, err := store.keepBinary(src, version)
+	if err != nil {
+		return err
+	}
+
+	if err := installBinary(kept, target); err != nil {
+		return fmt.Errorf("erro ao instalar novo binário: %w", err)
+	}
+
+	if err := validateBinary(target, version); err != nil {
+		fmt.Printf("⚠️  Binário instalado falhou na validação: %v\n", err)
+		fmt.Printf("↩️  Restaurando %s...\n", current)
+		if restoreErr := installBinary(backup, target); restoreErr != nil {
+			return fmt.Errorf("erro ao restaurar %s (cópia em %s): %w", current, backup, restoreErr)
+		}
+		return fmt.Errorf("instalação de %s revertida para %s: %w", version, current, err)
+	}
+
+	return store.record(installRecord{
+		Version:     version,
+		InstalledAt: time.Now().UTC(),
+		Previous:    current,
+		Binary:      kept,
+		Rollback:    rollback,
+	})
+}
+
+// runUpdateRollback reinstala o binário guardado da versão anterior (ou de version)
+func runUpdateRollback(version string) error {
+	if version != "" && !strings.HasPrefix(version, "v") {
+		version = "v" + version
+	}
+
+	store, err := newInstallStore()
+	if err != nil {
+		return err
+	}
+	current := getCurrentVersion()
+	rec, err := store.previous(current, version)
+	if err != nil {
+		return err
+	}
+
+	currentBinary, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("erro ao obter caminho do binário: %w", err)
+	}
+
+	fmt.Printf("↩️  Voltando para %s (atual: %s)...\n", rec.Version, current)
+	fmt.Println("🧪 Validando binário guardado...")
+	if err := validateBinary(rec.Binary, rec.Version); err != nil {
+		return fmt.Errorf("binário guardado de %s é inválido, nada foi alterado: %w", rec.Version, err)
+	}
+
+	if err := installVersion(rec.Binary, currentBinary, rec.Version, current, true); err != nil {
+		return err
+	}
+
+	fmt.Printf("✅ Rollback concluído! Versão atual: %s\n", rec.Version)
+	return nil
+}
+
+// runListInstalled mostra o histórico de instalações e os binários guardados
+func runListInstalled() error {
+	store, err := newInstallStore()
+	if err != nil {
+		return err
+	}
+	records, err := store.history()
+	if err != nil {
+		return err
+	}
+	if len(records) == 0 {
+		fmt.Println("📭 Nenhuma instalação registrada por '00cli update'")
+		return nil
+	}
+
+	current := getCurrentVersion()
+	fmt.Printf("📦 Instalações (binários em %s):\n", store.binariesDir())
+	for i := len(records) - 1; i >= 0; i-- {
+		rec := records[i]
+		line := fmt.Sprintf("   %s  %-22s", rec.InstalledAt.Local().Format("2006-01-02 15:04"), rec.Version)
+
+		var notes []string
+		if cmp, err := compareVersions(rec.Version, current); err == nil && cmp == 0 && i == len(records)-1 {
+			notes = append(notes, "atual")
+		}
+		if rec.Rollback {
+			notes = append(notes, "rollback")
+		}
+		if rec.Previous != "" {
+			notes = append(notes, "anterior: "+rec.Previous)
+		}
+		if rec.Binary != "" {
+			if _, err := os.Stat(rec.Binary); err == nil {
+				notes = append(notes, "guardado")
+			}
+		}
+		if len(notes) > 0 {
+			line += " (" + strings.Join(notes, ", ") + ")"
+		}
+		fmt.Println(line)
+	}
+	fmt.Println("\n   Use '00cli update --rollback [--version <versão>]' para voltar a um binário guardado")
+	return nil
+}
+
+// validateBinary executa "<binário> version" e confere a versão reportada
+func validateBinary(path, expected string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), validateTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, path, "version")
+	cmd.Env = append(envWithout(os.Environ(), "00CLI_VERSION"), "00CLI_NO_UPDATE_CHECK=1")
+	output, err := cmd.Output()
+	if ctx.Err() != nil {
+		return fmt.Errorf("binário não respondeu a 'version' em %s", validateTimeout)
+	}
+	if err != nil {
+		return fmt.Errorf("binário falhou ao executar 'version': %w", err)
+	}
+
+	reported := ""
+	scanner := bufio.NewScanner(strings.NewReader(string(output)))
+	for scanner.Scan() {
+		if fields := strings.Fields(scanner.Text()); len(fields) == 3 && fields[1] == "version" {
+			reported = fields[2]
+			break
+		}
+	}
+	if reported == "" {
+		return fmt.Errorf("saída inesperada de 'version': %q", strings.TrimSpace(string(output)))
+	}
+	if cmp, err := compareVersions(reported, expected); err != nil || cmp != 0 {
+		return fmt.Errorf("binário reporta a versão %s, esperada %s", reported, expected)
+	}
+	return nil
+}
+
+// envWithout remove uma variável do ambiente
+func envWithout(env []string, name string) []string {
+	filtered := make([]string, 0, len(env))
+	for _, kv := range env {
+		if !strings.HasPrefix(kv, name+"=") {
+			filtered = append(filtered, kv)
+		}
+	}
+	return filtered
+}
+
+// installBinary copia src para um arquivo temporário no diretório de target e
+// o renomeia sobre target, para que target nunca fique parcialmente escrito
+func installBinary(src, target string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".00cli-install-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, in); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0755); err != nil {
+		return err
+	}
+
+	if runtime.GOOS == "windows" {
+		// Windows não substitui um executável em uso: movê-lo para .old primeiro
+		oldFile := target + ".old"
+		os.Remove(oldFile)
+		if _, err := os.Stat(target); err == nil {
+			if err := os.Rename(target, oldFile); err != nil {
+				return fmt.Errorf("erro ao renomear binário antigo: %w", err)
+			}
+		}
+		if err := os.Rename(tmp.Name(), target); err != nil {
+			os.Rename(oldFile, target) // Reverter em caso de erro
+			return err
+		}
+		return nil
+	}
+
+	return os.Rename(tmp.Name(), target)
+}
+
+// writeFileAtomic grava data em path via arquivo temporário e rename
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// fakeBinary cria um script que responde a "version" como o 00cli
+func fakeBinary(t *testing.T, dir, name, output string, exitCode int) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	script := fmt.Sprintf("#!/bin/sh\necho '%s'\nexit %d\n", output, exitCode)
+	if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+		t.Fatalf("erro ao criar binário falso: %v", err)
+	}
+	return path
+}
+
+func TestValidateBinary(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("binários falsos usam /bin/sh")
+	}
+	dir := t.TempDir()
+
+	if err := validateBinary(fakeBinary(t, dir, "ok", "00cli version v1.2.0", 0), "v1.2.0"); err != nil {
+		t.Errorf("binário válido rejeitado: %v", err)
+	}
+	if err := validateBinary(fakeBinary(t, dir, "outra", "00cli version v1.1.0", 0), "v1.2.0"); err == nil {
+		t.Error("esperado erro para versão divergente")
+	}
+	if err := validateBinary(fakeBinary(t, dir, "falha", "00cli version v1.2.0", 1), "v1.2.0"); err == nil {
+		t.Error("esperado erro para binário que falha")
+	}
+	if err := validateBinary(fakeBinary(t, dir, "lixo", "segmentation fault", 0), "v1.2.0"); err == nil {
+		t.Error("esperado erro para saída inesperada")
+	}
+}
+
+func TestInstallVersionAndRollback(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("binários falsos usam /bin/sh")
+	}
+	t.Setenv("00CLI_STATE_DIR", t.TempDir())
+	dir := t.TempDir()
+	target := fakeBinary(t, dir, "00cli", "00cli version v1.0.0", 0)
+
+	downloads := t.TempDir()
+	if err := installVersion(fakeBinary(t, downloads, "v1.1.0", "00cli version v1.1.0", 0), target, "v1.1.0", "v1.0.0", false); err != nil {
+		t.Fatalf("erro ao instalar v1.1.0: %v", err)
+	}
+	if err := validateBinary(target, "v1.1.0"); err != nil {
+		t.Fatalf("v1.1.0 não foi instalada: %v", err)
+	}
+
+	// Binário que não passa na validação após instalado: restaura o anterior
+	broken := fakeBinary(t, downloads, "v1.2.0", "00cli version v0.0.0", 0)
+	if err := installVersion(broken, target, "v1.2.0", "v1.1.0", false); err == nil {
+		t.Fatal("esperado erro ao instalar binário inválido")
+	}
+	if err := validateBinary(target, "v1.1.0"); err != nil {
+		t.Fatalf("binário anterior não foi restaurado: %v", err)
+	}
+
+	store, err := newInstallStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec, err := store.previous("v1.1.0", "")
+	if err != nil || rec.Version != "v1.0.0" {
+		t.Fatalf("esperado rollback para v1.0.0, obtido %+v (%v)", rec, err)
+	}
+	if err := installVersion(rec.Binary, target, rec.Version, "v1.1.0", true); err != nil {
+		t.Fatalf("erro no rollback: %v", err)
+	}
+	if err := validateBinary(target, "v1.0.0"); err != nil {
+		t.Errorf("rollback não restaurou v1.0.0: %v", err)
+	}
+	if _, err := store.previous("v1.0.0", "v9.9.9"); err == nil {
+		t.Error("esperado erro para versão não guardada")
+	}
+
+	records, err := store.history()
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := records[len(records)-1]
+	if len(records) != 3 || last.Version != "v1.0.0" || !last.Rollback || last.Previous != "v1.1.0" {
+		t.Errorf("histórico inesperado: %+v", records)
+	}
+}
+
+func TestInstallStorePrunesOldBinaries(t *testing.T) {
+	store := &installStore{Dir: t.TempDir(), Keep: 2}
+	src := filepath.Join(t.TempDir(), "00cli")
+	os.WriteFile(src, []byte("binário"), 0755)
+
+	var kept []string
+	for _, version := range []string{"v1.0.0", "v1.1.0", "v1.2.0"} {
+		path, err := store.keepBinary(src, version)
+		if err != nil {
+			t.Fatalf("erro ao guardar %s: %v", version, err)
+		}
+		if err := store.record(installRecord{Version: version, Binary: path}); err != nil {
+			t.Fatalf("erro ao registrar %s: %v", version, err)
+		}
+		kept = append(kept, path)
+	}
+
+	if _, err := os.Stat(kept[0]); !os.IsNotExist(err) {
+		t.Error("binário mais antigo deveria ter sido removido")
+	}
+	for _, path := range kept[1:] {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("binário recente removido: %s", path)
+		}
+	}
+	records, _ := store.history()
+	if len(records) != 3 || records[0].Binary != "" {
+		t.Errorf("histórico deveria manter o registro sem o binário: %+v", records)
+	}
+}
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/tstest3213/00cli/internal/bytesize"
+)
+
+const (
+	progressWidth    = 30
+	progressInterval = 200 * time.Millisecond
+)
+
+// progressBar desenha o andamento de um download em uma linha do terminal
+type progressBar struct {
+	out     io.Writer
+	started time.Time
+	base    int64 // bytes já baixados ao iniciar (download retomado)
+	current int64
+	total   int64
+	drawn   time.Time
+}
+
+func newProgressBar(out io.Writer) *progressBar {
+	return &progressBar{out: out}
+}
+
+// Start reinicia a medição de taxa a partir de offset bytes
+func (p *progressBar) Start(offset, total int64) {
+	p.started = time.Now()
+	p.base = offset
+	p.current = offset
+	p.total = total
+	p.draw()
+}
+
+// Set atualiza os bytes recebidos, redesenhando no máximo a cada progressInterval
+func (p *progressBar) Set(n int64) {
+	p.current = n
+	if time.Since(p.drawn) < progressInterval && n != p.total {
+		return
+	}
+	p.draw()
+}
+
+// Clear apaga a linha da barra (antes de outra mensagem)
+func (p *progressBar) Clear() {
+	if !p.drawn.IsZero() {
+		fmt.Fprint(p.out, "\r\033[K")
+	}
+}
+
+// Finish termina a linha da barra
+func (p *progressBar) Finish() {
+	if !p.drawn.IsZero() {
+		p.draw()
+		fmt.Fprintln(p.out)
+	}
+}
+
+func (p *progressBar) draw() {
+	p.drawn = time.Now()
+
+	rate := ""
+	if elapsed := time.Since(p.started).Seconds(); elapsed > 0 {
+		rate = bytesize.Format(int64(float64(p.current-p.base)/elapsed)) + "/s"
+	}
+
+	if p.total <= 0 {
+		fmt.Fprintf(p.out, "\r\033[K   %s  %s", bytesize.Format(p.current), rate)
+		return
+	}
+
+	filled := int(int64(progressWidth) * p.current / p.total)
+	if filled > progressWidth {
+		filled = progressWidth
+	}
+	bar := strings.Repeat("=", filled) + strings.Repeat(" ", progressWidth-filled)
+	fmt.Fprintf(p.out, "\r\033[K   [%s] %3d%%  %s / %s  %s",
+		bar, p.current*100/p.total, bytesize.Format(p.current), bytesize.Format(p.total), rate)
+}
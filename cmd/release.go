@@ -0,0 +1,334 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// defaultPlatforms é a matriz compilada quando settings.json não define publish.platforms
+var defaultPlatforms = []string{
+	"linux/amd64",
+	"linux/arm64",
+	"darwin/amd64",
+	"darwin/arm64",
+	"windows/amd64",
+}
+
+var releaseCmd = &cobra.Command{
+	Use:   "release",
+	Short: "Gerencia releases do 00cli",
+}
+
+var releasePublishCmd = &cobra.Command{
+	Use:   "publish [arquivos...]",
+	Short: "Compila e publica uma release no servidor de atualizações",
+	Long: `Compila o 00cli para cada plataforma (GOOS/GOARCH) com CGO_ENABLED=0, gera
+checksums.txt e envia binários e notas para o servidor de atualizações
+(00cli serve-updates), criando uma release versionada em /releases/<versão>.
+
+Arquivos passados como argumento são publicados como estão, sem compilar.
+
+A publicação usa o token Bearer de --token ou UPDATE_SERVER_TOKEN.`,
+	Example: `  00cli release publish --version v0.4.0 --notes-file CHANGELOG.md
+  00cli release publish --version v0.5.0-beta.1 --prerelease
+  00cli release publish --platforms linux/amd64,linux/arm64
+  00cli release publish dist/00cli-linux-amd64 dist/00cli-darwin-arm64`,
+	RunE: runReleasePublish,
+}
+
+var (
+	publishVersion    string
+	publishNotes      string
+	publishNotesFile  string
+	publishPlatforms  []string
+	publishServer     string
+	publishToken      string
+	publishOutput     string
+	publishPrerelease bool
+	publishReplace    bool
+	publishDryRun     bool
+)
+
+func init() {
+	releasePublishCmd.Flags().StringVar(&publishVersion, "version", "", "Versão da release (padrão: git describe --tags --always --dirty)")
+	releasePublishCmd.Flags().StringVar(&publishNotes, "notes", "", "Notas da release")
+	releasePublishCmd.Flags().StringVar(&publishNotesFile, "notes-file", "", "Arquivo com as notas da release")
+	releasePublishCmd.Flags().StringSliceVar(&publishPlatforms, "platforms", nil, "Plataformas GOOS/GOARCH (padrão: publish.platforms ou linux, darwin e windows)")
+	releasePublishCmd.Flags().StringVar(&publishServer, "server", "", "Servidor de atualizações (padrão: publish.server, update_server ou 00CLI_UPDATE_SERVER)")
+	releasePublishCmd.Flags().StringVar(&publishToken, "token", "", "Token de publicação (padrão: UPDATE_SERVER_TOKEN)")
+	releasePublishCmd.Flags().StringVarP(&publishOutput, "output", "o", "dist", "Diretório dos binários compilados")
+	releasePublishCmd.Flags().BoolVar(&publishPrerelease, "prerelease", false, "Marca a release como pré-release (canal beta)")
+	releasePublishCmd.Flags().BoolVar(&publishReplace, "replace", false, "Substitui uma release existente com a mesma versão")
+	releasePublishCmd.Flags().BoolVar(&publishDryRun, "dry-run", false, "Compila e gera checksums sem enviar")
+	releaseCmd.AddCommand(releasePublishCmd)
+	rootCmd.AddCommand(releaseCmd)
+}
+
+func runReleasePublish(cmd *cobra.Command, args []string) error {
+	var publish PublishConfig
+	if root, err := getProjectRoot(); err == nil {
+		if settings, err := loadSettings(root); err == nil {
+			publish = settings.Publish
+		}
+	}
+
+	version := publishVersion
+	if version == "" {
+		version = gitDescribe()
+	}
+	if version == "" {
+		return fmt.Errorf("não foi possível determinar a versão: use --version")
+	}
+	if _, err := parseVersion(version); err != nil {
+		return fmt.Errorf("versão inválida: %w", err)
+	}
+
+	notes := publishNotes
+	if publishNotesFile != "" {
+		data, err := os.ReadFile(publishNotesFile)
+		if err != nil {
+			return fmt.Errorf("erro ao ler notas da release: %w", err)
+		}
+		notes = string(data)
+	}
+
+	server := publishServer
+	if server == "" {
+		server = publish.Server
+	}
+	if server == "" {
+		server = getUpdateServerURL()
+	}
+	token := publishToken
+	if token == "" {
+		token = os.Getenv("UPDATE_SERVER_TOKEN")
+	}
+	if !publishDryRun {
+		if server == "" {
+			return fmt.Errorf("servidor de atualizações não configurado: use --server, publish.server ou update_server")
+		}
+		if token == "" {
+			return fmt.Errorf("token de publicação não informado: use --token ou UPDATE_SERVER_TOKEN")
+		}
+	}
+
+	files := args
+	if len(files) == 0 {
+		platforms := publishPlatforms
+		if len(platforms) == 0 {
+			platforms = publish.Platforms
+		}
+		if len(platforms) == 0 {
+			platforms = defaultPlatforms
+		}
+
+		var err error
+		files, err = buildPlatforms(version, publish.Package, platforms, publishOutput)
+		if err != nil {
+			return err
+		}
+	}
+
+	checksums, err := writeChecksums(files)
+	if err != nil {
+		return err
+	}
+
+	if publishDryRun {
+		fmt.Printf("🧪 Dry-run: %d arquivo(s) da versão %s não foram enviados\n", len(files), version)
+		return nil
+	}
+
+	fmt.Printf("📤 Publicando %s em %s...\n", version, customServerBaseURL(server))
+	release, err := publishRelease(customServerBaseURL(server), token, publishForm{
+		Version:    version,
+		Notes:      notes,
+		Checksums:  checksums,
+		Prerelease: publishPrerelease,
+		Replace:    publishReplace,
+		Files:      files,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("✅ Release %s publicada (%d arquivo(s))\n", release.TagName, len(release.Assets))
+	if release.HTMLURL != "" {
+		fmt.Printf("   %s\n", release.HTMLURL)
+	}
+	return nil
+}
+
+// gitDescribe retorna a versão do git describe ou vazio fora de um repositório
+func gitDescribe() string {
+	out, err := exec.Command("git", "describe", "--tags", "--always", "--dirty").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// buildPlatforms compila o pacote para cada plataforma em outputDir
+func buildPlatforms(version, pkg string, platforms []string, outputDir string) ([]string, error) {
+	if pkg == "" {
+		pkg = "."
+	}
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return nil, fmt.Errorf("erro ao criar diretório de saída: %w", err)
+	}
+
+	commit := ""
+	if out, err := exec.Command("git", "rev-parse", "HEAD").Output(); err == nil {
+		commit = strings.TrimSpace(string(out))
+	}
+	ldflags := fmt.Sprintf("-s -w -X main.version=%s -X main.commit=%s -X main.date=%s",
+		version, commit, time.Now().UTC().Format(time.RFC3339))
+
+	var files []string
+	for _, platform := range platforms {
+		goos, goarch, ok := strings.Cut(platform, "/")
+		if !ok || goos == "" || goarch == "" {
+			return nil, fmt.Errorf("plataforma inválida: %q (use GOOS/GOARCH)", platform)
+		}
+
+		name := fmt.Sprintf("00cli-%s-%s", goos, goarch)
+		if goos == "windows" {
+			name += ".exe"
+		}
+		output := filepath.Join(outputDir, name)
+
+		fmt.Printf("🔨 Compilando %s...\n", name)
+		build := exec.Command("go", "build", "-trimpath", "-ldflags", ldflags, "-o", output, pkg)
+		build.Env = append(os.Environ(), "GOOS="+goos, "GOARCH="+goarch, "CGO_ENABLED=0")
+		build.Stdout = os.Stdout
+		build.Stderr = os.Stderr
+		if err := build.Run(); err != nil {
+			return nil, fmt.Errorf("erro ao compilar %s: %w", platform, err)
+		}
+		files = append(files, output)
+	}
+	return files, nil
+}
+
+// writeChecksums gera checksums.txt (formato do sha256sum) ao lado dos arquivos
+func writeChecksums(files []string) (string, error) {
+	sorted := append([]string(nil), files...)
+	sort.Slice(sorted, func(i, j int) bool { return filepath.Base(sorted[i]) < filepath.Base(sorted[j]) })
+
+	var sums strings.Builder
+	for _, file := range sorted {
+		sum, err := sha256File(file)
+		if err != nil {
+			return "", fmt.Errorf("erro ao calcular checksum de %s: %w", file, err)
+		}
+		fmt.Fprintf(&sums, "%s  %s\n", sum, filepath.Base(file))
+	}
+
+	path := filepath.Join(filepath.Dir(sorted[0]), "checksums.txt")
+	if err := os.WriteFile(path, []byte(sums.String()), 0644); err != nil {
+		return "", fmt.Errorf("erro ao escrever checksums.txt: %w", err)
+	}
+	fmt.Printf("🔐 Checksums em %s\n", path)
+	return sums.String(), nil
+}
+
+// publishForm são os campos enviados para POST /releases
+type publishForm struct {
+	Version    string
+	Notes      string
+	Checksums  string
+	Prerelease bool
+	Replace    bool
+	Files      []string
+}
+
+// publishRelease envia a release em multipart para <baseURL>/releases, sem
+// carregar os binários em memória
+func publishRelease(baseURL, token string, form publishForm) (*Release, error) {
+	// Mesmo proxy e CAs do updater; sem timeout total, como nos downloads
+	client, err := newHTTPClient(0)
+	if err != nil {
+		return nil, err
+	}
+
+	body, writer := io.Pipe()
+	multi := multipart.NewWriter(writer)
+
+	go func() {
+		writer.CloseWithError(writePublishForm(multi, form))
+	}()
+
+	req, err := http.NewRequest(http.MethodPost, baseURL+"/releases", body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", multi.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("User-Agent", "00cli-publisher")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao enviar release: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		var failure struct {
+			Error string `json:"error"`
+		}
+		json.NewDecoder(resp.Body).Decode(&failure)
+		if failure.Error == "" {
+			failure.Error = resp.Status
+		}
+		return nil, fmt.Errorf("servidor recusou a publicação (%d): %s", resp.StatusCode, failure.Error)
+	}
+
+	var release Release
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return nil, fmt.Errorf("erro ao ler resposta do servidor: %w", err)
+	}
+	return &release, nil
+}
+
+func writePublishForm(multi *multipart.Writer, form publishForm) error {
+	fields := [][2]string{
+		{"version", form.Version},
+		{"body", form.Notes},
+		{"checksums", form.Checksums},
+		{"prerelease", fmt.Sprint(form.Prerelease)},
+		{"replace", fmt.Sprint(form.Replace)},
+	}
+	for _, field := range fields {
+		if err := multi.WriteField(field[0], field[1]); err != nil {
+			return err
+		}
+	}
+
+	for _, file := range form.Files {
+		part, err := multi.CreateFormFile("file", filepath.Base(file))
+		if err != nil {
+			return err
+		}
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(part, f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return multi.Close()
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"encoding/pem"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tstest3213/00cli/internal/updateserver"
+)
+
+func TestPublishRelease(t *testing.T) {
+	server := &updateserver.Server{Dir: t.TempDir(), Token: "segredo"}
+	ts := httptest.NewServer(server.Handler())
+	defer ts.Close()
+
+	dist := t.TempDir()
+	binary := filepath.Join(dist, getBinaryName())
+	if err := os.WriteFile(binary, []byte("binário publicado"), 0755); err != nil {
+		t.Fatalf("erro ao escrever binário: %v", err)
+	}
+
+	checksums, err := writeChecksums([]string{binary})
+	if err != nil {
+		t.Fatalf("erro ao gerar checksums: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dist, "checksums.txt")); err != nil {
+		t.Errorf("checksums.txt não foi gerado: %v", err)
+	}
+
+	form := publishForm{Version: "v0.4.0", Notes: "novidades", Checksums: checksums, Files: []string{binary}}
+	if _, err := publishRelease(ts.URL, "errado", form); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("esperado erro 401 com token inválido, obtido %v", err)
+	}
+
+	release, err := publishRelease(ts.URL+"/updates", "segredo", form)
+	if err != nil {
+		t.Fatalf("erro ao publicar: %v", err)
+	}
+	if release.TagName != "v0.4.0" || release.Body != "novidades" {
+		t.Errorf("release publicada inesperada: %+v", release)
+	}
+
+	// A release publicada fica disponível para update --version
+	t.Setenv("00CLI_UPDATE_SERVER", ts.URL+"/updates/latest")
+	found, err := getReleaseByTag("v0.4.0")
+	if err != nil {
+		t.Fatalf("erro ao buscar release publicada: %v", err)
+	}
+	asset := findBinaryAsset(found)
+	if asset == nil {
+		t.Fatalf("binário da plataforma ausente: %+v", found)
+	}
+
+	file := filepath.Join(t.TempDir(), "00cli-update")
+	if err := downloadFile(asset.BrowserDownloadURL, file); err != nil {
+		t.Fatalf("erro no download: %v", err)
+	}
+	if err := verifyDownload(found, asset, file); err != nil {
+		t.Errorf("download da release publicada deveria passar na verificação: %v", err)
+	}
+}
+
+func TestPublishReleaseWithCABundle(t *testing.T) {
+	server := &updateserver.Server{Dir: t.TempDir(), Token: "segredo"}
+	ts := httptest.NewTLSServer(server.Handler())
+	defer ts.Close()
+
+	binary := filepath.Join(t.TempDir(), getBinaryName())
+	if err := os.WriteFile(binary, []byte("binário"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	form := publishForm{Version: "v0.5.0", Files: []string{binary}}
+
+	// A publicação usa o mesmo bundle de CAs do updater
+	bundle := filepath.Join(t.TempDir(), "ca.pem")
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw})
+	if err := os.WriteFile(bundle, cert, 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SSL_CERT_FILE", "")
+	t.Setenv("00CLI_CA_BUNDLE", bundle)
+	if _, err := publishRelease(ts.URL, "segredo", form); err != nil {
+		t.Errorf("publicação com bundle de CAs falhou: %v", err)
+	}
+}
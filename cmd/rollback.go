@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tstest3213/00cli/internal/deploy"
+)
+
+var rollbackCmd = &cobra.Command{
+	Use:   "rollback [release]",
+	Short: "Volta o symlink current para uma release anterior",
+	Long: `Aponta <releases.path>/current para a release informada ou, se nenhuma for
+informada, para a release anterior à ativa, e executa novamente os comandos
+de releases.restart. Requer deploy do tipo ssh com releases.path configurado.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runRollback,
+}
+
+var listReleases bool
+
+func init() {
+	rollbackCmd.Flags().BoolVarP(&listReleases, "list", "l", false, "Apenas lista as releases disponíveis")
+	rootCmd.AddCommand(rollbackCmd)
+}
+
+func runRollback(cmd *cobra.Command, args []string) error {
+	root, err := getProjectRoot()
+	if err != nil {
+		return err
+	}
+
+	if err := checkProjectStructure(root); err != nil {
+		return fmt.Errorf("estrutura do projeto inválida: %w", err)
+	}
+
+	settings, err := loadSettings(root)
+	if err != nil {
+		return fmt.Errorf("erro ao carregar settings.json: %w", err)
+	}
+
+	deployConfig, err := loadDeployConfig(root)
+	if err != nil {
+		return fmt.Errorf("erro ao carregar deploy.json: %w", err)
+	}
+
+	env, err := applyEnvironment(settings, deployConfig, envName)
+	if err != nil {
+		return err
+	}
+
+	if deployConfig.Type != "ssh" || deployConfig.Releases.Path == "" {
+		return fmt.Errorf("rollback requer deploy do tipo ssh com releases.path configurado")
+	}
+
+	deployer, err := deploy.NewDeployer("ssh", sshDeployConfig(root, settings, deployConfig))
+	if err != nil {
+		return fmt.Errorf("erro ao criar deployer: %w", err)
+	}
+	if listReleases {
+		return printReleases(deployer, deployConfig.Releases.Path)
+	}
+
+	var release string
+	if len(args) > 0 {
+		release = args[0]
+	}
+
+	if env != "" {
+		fmt.Printf("⏪ Iniciando rollback (ambiente: %s)...\n", env)
+	} else {
+		fmt.Println("⏪ Iniciando rollback...")
+	}
+
+	// Com uma lista de hosts, o rollback é feito em cada servidor
+	var targets []string
+	switch d := deployer.(type) {
+	case *deploy.SSHDeployer:
+		defer d.Close()
+		target, err := d.Rollback(release)
+		if err != nil {
+			return fmt.Errorf("erro durante rollback: %w", err)
+		}
+		targets = []string{target}
+	case *deploy.MultiHostDeployer:
+		if targets, err = d.Rollback(release); err != nil {
+			return fmt.Errorf("erro durante rollback: %w", err)
+		}
+	}
+
+	var active []string
+	for _, target := range targets {
+		if len(active) == 0 || active[len(active)-1] != target {
+			active = append(active, target)
+		}
+	}
+	fmt.Printf("\n✅ Rollback concluído! Release ativa: %s\n", strings.Join(active, ", "))
+	return nil
+}
+
+// printReleases lista as releases de cada servidor, marcando a ativa
+func printReleases(deployer deploy.Deployer, path string) error {
+	var hosts []*deploy.SSHDeployer
+	multiHost := false
+	switch d := deployer.(type) {
+	case *deploy.SSHDeployer:
+		hosts = []*deploy.SSHDeployer{d}
+	case *deploy.MultiHostDeployer:
+		var err error
+		if hosts, err = d.HostDeployers(); err != nil {
+			return err
+		}
+		multiHost = true
+	}
+
+	for _, host := range hosts {
+		defer host.Close()
+		releases, current, err := host.ListReleases()
+		if err != nil {
+			return err
+		}
+		if multiHost {
+			fmt.Printf("📂 Releases em %s:%s:\n", host.Name, path)
+		} else {
+			fmt.Printf("📂 Releases em %s:\n", path)
+		}
+		for _, rel := range releases {
+			marker := "  "
+			if rel == current {
+				marker = "➜ "
+			}
+			fmt.Printf("   %s%s\n", marker, rel)
+		}
+	}
+	return nil
+}
@@ -7,37 +7,176 @@ import (
 	"path/filepath"
 
 	"github.com/spf13/cobra"
+	"github.com/tstest3213/00cli/internal/deploy"
 )
 
 var (
 	projectPath string
 	verbose     bool
+	envName     string
 )
 
+// ServerConfig representa os dados de conexão com o servidor
+type ServerConfig struct {
+	Host     string `json:"host"`
+	Port     int    `json:"port,omitempty"` // vazio: Port do ssh_config ou 22
+	User     string `json:"user,omitempty"` // vazio: User do ssh_config ou usuário local
+	SSHKey   string `json:"ssh_key,omitempty"`
+	Password string `json:"password,omitempty"`
+	HostKey  string `json:"host_key,omitempty"` // chave fixada do servidor ("SHA256:..." ou formato authorized_keys)
+
+	// IdentityFiles são chaves privadas tentadas em ordem depois de ssh_key
+	IdentityFiles []string `json:"identity_files,omitempty"`
+	// SSHConfig é o ssh_config que resolve host como alias (padrão: ~/.ssh/config; "none" desativa)
+	SSHConfig string `json:"ssh_config,omitempty"`
+	// Jump são os bastions atravessados em ordem até o servidor
+	Jump []JumpConfig `json:"jump,omitempty"`
+	// KeepAlive é o intervalo entre keepalives da conexão (ex: "30s"; "-1s" desativa)
+	KeepAlive string `json:"keepalive,omitempty"`
+}
+
+// JumpConfig é um bastion do caminho até o servidor; campos vazios são
+// resolvidos pelo ~/.ssh/config
+type JumpConfig struct {
+	Host     string `json:"host"`
+	Port     int    `json:"port,omitempty"`
+	User     string `json:"user,omitempty"`
+	SSHKey   string `json:"ssh_key,omitempty"`
+	Password string `json:"password,omitempty"`
+	HostKey  string `json:"host_key,omitempty"`
+}
+
+// HostConfig é um servidor de um deploy multi-host; campos vazios herdam
+// os valores de server
+type HostConfig struct {
+	Name    string   `json:"name,omitempty"`
+	Host    string   `json:"host"`
+	Port    int      `json:"port,omitempty"`
+	User    string   `json:"user,omitempty"`
+	HostKey string   `json:"host_key,omitempty"`
+	Roles   []string `json:"roles,omitempty"` // papéis do servidor (ex: web, worker)
+}
+
 // Settings representa as configurações do servidor
 type Settings struct {
-	Server struct {
-		Host     string `json:"host"`
-		Port     int    `json:"port"`
-		User     string `json:"user"`
-		SSHKey   string `json:"ssh_key,omitempty"`
-		Password string `json:"password,omitempty"`
-	} `json:"server"`
-	CurrentVersion string `json:"current_version"`
-	ProjectName    string `json:"project_name,omitempty"`
-	UpdateServer   string `json:"update_server,omitempty"` // URL do servidor de atualizações (ex: http://192.168.1.100:8080/updates)
+	Server         ServerConfig `json:"server"`
+	CurrentVersion string       `json:"current_version"`
+	ProjectName    string       `json:"project_name,omitempty"`
+	UpdateServer   string       `json:"update_server,omitempty"`  // URL do servidor de atualizações (ex: http://192.168.1.100:8080/updates)
+	UpdateChannel  string       `json:"update_channel,omitempty"` // canal de atualização: "stable" (padrão) ou "beta"
+	UpdateKeep     int          `json:"update_keep,omitempty"`    // binários guardados para 00cli update --rollback (padrão: 3)
+	UpdateAssets   []string     `json:"update_assets,omitempty"`  // templates de nome de asset (ex: "00cli_{{.Version}}_{{.OS}}_{{.Arch}}.tar.gz")
+
+	// UpdateCheckInterval é o intervalo entre verificações de nova versão (ex: "24h", "0" sempre consulta)
+	UpdateCheckInterval string `json:"update_check_interval,omitempty"`
+
+	// Hosts executa o deploy ssh em vários servidores com a configuração de server
+	Hosts []HostConfig `json:"hosts,omitempty"`
+
+	// Publish configura o 00cli release publish
+	Publish PublishConfig `json:"publish,omitempty"`
+
+	// Environments sobrescreve o servidor por ambiente (ex: staging, production)
+	Environments       map[string]SettingsEnvironment `json:"environments,omitempty"`
+	DefaultEnvironment string                         `json:"default_environment,omitempty"`
+}
+
+// PublishConfig define a matriz de plataformas e o servidor de publicação de releases
+type PublishConfig struct {
+	Platforms []string `json:"platforms,omitempty"` // GOOS/GOARCH (ex: linux/amd64)
+	Server    string   `json:"server,omitempty"`    // servidor de atualizações (padrão: update_server)
+	Package   string   `json:"package,omitempty"`   // pacote Go compilado (padrão: .)
+}
+
+// SettingsEnvironment contém as configurações específicas de um ambiente;
+// campos vazios herdam os valores de nível superior
+type SettingsEnvironment struct {
+	Server ServerConfig `json:"server"`
+	Hosts  []HostConfig `json:"hosts,omitempty"` // substitui a lista de hosts de nível superior
 }
 
 // DeployConfig representa a configuração de deploy
 type DeployConfig struct {
 	Type        string            `json:"type"` // "ssh", "git", etc
 	Commands    []string          `json:"commands,omitempty"`
-	Scripts     []string          `json:"scripts,omitempty"`
+	SessionMode string            `json:"session_mode,omitempty"`       // "persistent" (padrão) ou "isolated"
+	Shell       string            `json:"shell,omitempty"`              // shell remoto do modo persistent
+	Scripts     []string          `json:"scripts,omitempty"`            // arquivos locais executados no servidor após commands
+	Interpreter string            `json:"script_interpreter,omitempty"` // interpretador dos scripts (padrão: shebang ou /bin/sh)
+	Environment map[string]string `json:"environment,omitempty"`
+	Provision   struct {
+		Path   string   `json:"path"`
+		Files  []string `json:"files,omitempty"`
+		Target string   `json:"target,omitempty"` // diretório remoto de destino dos arquivos
+	} `json:"provision"`
+	Build struct {
+		Commands []string `json:"commands,omitempty"` // executados localmente na raiz do projeto
+		Output   string   `json:"output,omitempty"`   // diretório ou .tar.gz gerado (ex: dist)
+		Target   string   `json:"target,omitempty"`   // destino remoto do artefato; relativo à release com releases.path
+	} `json:"build"`
+	Sync struct {
+		Path    string   `json:"path,omitempty"`    // diretório local espelhado no servidor (ex: dist)
+		Target  string   `json:"target,omitempty"`  // diretório remoto; relativo à release com releases.path
+		Delete  bool     `json:"delete,omitempty"`  // remove do destino o que não existe localmente
+		Exclude []string `json:"exclude,omitempty"` // padrões ignorados, além dos do .00cliignore
+	} `json:"sync"`
+	Releases struct {
+		Path    string   `json:"path,omitempty"`    // diretório base no servidor (releases/, shared/, current)
+		Keep    int      `json:"keep,omitempty"`    // releases mantidas (padrão: 5)
+		Shared  []string `json:"shared,omitempty"`  // caminhos persistentes em shared/ ("storage/" para diretórios)
+		Restart []string `json:"restart,omitempty"` // comandos executados após a troca de current e no rollback
+	} `json:"releases"`
+	Docker struct {
+		ComposeFile string   `json:"compose_file,omitempty"` // padrão: docker-compose.yml ou provision/docker-compose.yml
+		ProjectName string   `json:"project_name,omitempty"`
+		Profiles    []string `json:"profiles,omitempty"`
+		EnvFile     string   `json:"env_file,omitempty"`
+		Remote      bool     `json:"remote,omitempty"`     // executar o compose no servidor via SSH
+		RemoteDir   string   `json:"remote_dir,omitempty"` // diretório remoto do compose file (padrão: nome do projeto)
+	} `json:"docker"`
+	Parallel struct {
+		Concurrency int      `json:"concurrency,omitempty"` // máximo de hosts simultâneos (padrão: todos)
+		Rolling     bool     `json:"rolling,omitempty"`     // deploy em lotes, parando no primeiro lote com falha
+		BatchSize   int      `json:"batch_size,omitempty"`  // hosts por lote no modo rolling (padrão: 1)
+		Roles       []string `json:"roles,omitempty"`       // apenas hosts com algum destes papéis
+	} `json:"parallel"`
+
+	// Stages são os estágios do pipeline de deploy (pre_deploy, upload, build,
+	// migrate, switch, post_deploy, on_failure, on_success); commands equivale
+	// a stages.build
+	Stages map[string]StageConfig `json:"stages,omitempty"`
+
+	// Environments sobrescreve comandos, variáveis e provision por ambiente
+	Environments map[string]DeployEnvironment `json:"environments,omitempty"`
+}
+
+// StageConfig é um estágio do pipeline de deploy
+type StageConfig struct {
+	Commands        []string `json:"commands"`
+	Scripts         []string `json:"scripts,omitempty"`           // arquivos locais executados após commands
+	Interpreter     string   `json:"interpreter,omitempty"`       // interpretador dos scripts (padrão: shebang ou /bin/sh)
+	Dir             string   `json:"dir,omitempty"`               // diretório de trabalho (relativo: ao padrão do estágio)
+	Target          string   `json:"target,omitempty"`            // "local" ou "remote" (padrão: conforme o tipo de deploy)
+	Timeout         string   `json:"timeout,omitempty"`           // limite do estágio (ex: "10m")
+	ContinueOnError bool     `json:"continue_on_error,omitempty"` // falha apenas avisa e o deploy segue
+}
+
+// DeployEnvironment contém a configuração de deploy de um ambiente; campos
+// vazios herdam os valores de nível superior e Environment é mesclado
+type DeployEnvironment struct {
+	Commands    []string          `json:"commands,omitempty"`
 	Environment map[string]string `json:"environment,omitempty"`
 	Provision   struct {
-		Path  string   `json:"path"`
-		Files []string `json:"files,omitempty"`
+		Files  []string `json:"files,omitempty"`
+		Target string   `json:"target,omitempty"`
 	} `json:"provision"`
+	Sync struct {
+		Target string `json:"target,omitempty"`
+	} `json:"sync"`
+	Releases struct {
+		Path string `json:"path,omitempty"`
+	} `json:"releases"`
+	Stages map[string]StageConfig `json:"stages,omitempty"` // substitui os estágios de mesmo nome
 }
 
 var rootCmd = &cobra.Command{
@@ -46,6 +185,14 @@ var rootCmd = &cobra.Command{
 	Long: `00cli é uma ferramenta CLI inspirada no agent-cursor para gerenciar
 deploys e configurações de projetos. O programa verifica automaticamente
 por atualizações e requer arquivos de configuração em ./.00cli/`,
+	// A verificação de atualizações começa depois que as flags (-p) foram
+	// interpretadas, e o aviso é exibido quando o comando termina
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		startUpdateCheck(cmd)
+	},
+	PersistentPostRun: func(cmd *cobra.Command, args []string) {
+		updateNotice()
+	},
 }
 
 func Execute() error {
@@ -55,6 +202,7 @@ func Execute() error {
 func init() {
 	rootCmd.PersistentFlags().StringVarP(&projectPath, "project", "p", "", "Caminho do projeto (padrão: diretório atual)")
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Modo verboso")
+	rootCmd.PersistentFlags().StringVarP(&envName, "env", "e", "", "Ambiente definido em environments (padrão: default_environment)")
 }
 
 // getProjectRoot retorna o diretório raiz do projeto
@@ -87,6 +235,35 @@ func checkProjectStructure(root string) error {
 	return nil
 }
 
+// provisionDir retorna o caminho absoluto do diretório de provision
+// (provision.path relativo à raiz do projeto; padrão: ./provision)
+func provisionDir(root string, config *DeployConfig) string {
+	dir := config.Provision.Path
+	if dir == "" {
+		dir = "provision"
+	}
+	return resolveProjectPath(root, dir)
+}
+
+// resolveProjectPath resolve um caminho do deploy.json relativo à raiz do projeto
+func resolveProjectPath(root, dir string) string {
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(root, dir)
+	}
+	return dir
+}
+
+// knownHostsFiles retorna os arquivos known_hosts usados para validar o
+// servidor: o do usuário (~/.ssh/known_hosts) e o opcional do projeto
+// (.00cli/known_hosts). Novas chaves aceitas são gravadas no primeiro.
+func knownHostsFiles(root string) []string {
+	var files []string
+	if userFile := deploy.DefaultKnownHostsFile(); userFile != "" {
+		files = append(files, userFile)
+	}
+	return append(files, filepath.Join(root, ".00cli", "known_hosts"))
+}
+
 // loadSettings carrega o arquivo settings.json
 func loadSettings(root string) (*Settings, error) {
 	path := filepath.Join(root, ".00cli", "settings.json")
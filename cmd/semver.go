@@ -0,0 +1,17 @@
+package cmd
+
+import "github.com/tstest3213/00cli/internal/semver"
+
+// semVersion é uma versão semântica; ver semver.Version
+type semVersion = semver.Version
+
+// parseVersion interpreta versões como "v1.2.3", "1.2.3-beta.1+build.5" ou
+// "v0.3.0-5-gabc1234-dirty"
+func parseVersion(s string) (semVersion, error) {
+	return semver.Parse(s)
+}
+
+// compareVersions compara duas versões em texto
+func compareVersions(a, b string) (int, error) {
+	return semver.CompareStrings(a, b)
+}
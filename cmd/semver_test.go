@@ -0,0 +1,15 @@
+package cmd
+
+import "testing"
+
+// Os casos de interpretação e comparação ficam em internal/semver; aqui só
+// os wrappers usados pelo updater
+func TestVersionWrappers(t *testing.T) {
+	v, err := parseVersion("v1.2.3-beta.1")
+	if err != nil || v.Minor != 2 || !v.IsPrerelease() {
+		t.Errorf("parseVersion: %+v, %v", v, err)
+	}
+	if cmp, err := compareVersions("v1.10.0", "v1.9.0"); err != nil || cmp != 1 {
+		t.Errorf("compareVersions: %d, %v", cmp, err)
+	}
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tstest3213/00cli/internal/updateserver"
+)
+
+var serveUpdatesCmd = &cobra.Command{
+	Use:   "serve-updates",
+	Short: "Inicia o servidor de atualizações do 00cli",
+	Long: `Serve a API de atualizações (/latest, /download/<binário>, /health e
+/upload) a partir de <dir>/binaries, com o sha256 de cada binário. É o mesmo
+contrato de server-update/api.py, sem depender de Python.
+
+Releases versionadas publicadas com "00cli release publish" ficam em
+<dir>/releases/<versão> e têm precedência em /latest.
+
+O upload (POST /upload e POST /releases) exige o token de --token ou
+UPDATE_SERVER_TOKEN e fica desabilitado sem ele.`,
+	RunE: runServeUpdates,
+}
+
+var (
+	serveHost    string
+	servePort    int
+	serveDir     string
+	serveToken   string
+	serveBaseURL string
+	serveVersion string
+)
+
+func init() {
+	serveUpdatesCmd.Flags().StringVar(&serveHost, "host", "0.0.0.0", "Endereço de bind")
+	serveUpdatesCmd.Flags().IntVar(&servePort, "port", 8080, "Porta")
+	serveUpdatesCmd.Flags().StringVar(&serveDir, "dir", ".", "Diretório com binaries/ e metadata.json")
+	serveUpdatesCmd.Flags().StringVar(&serveToken, "token", "", "Token exigido no upload (padrão: UPDATE_SERVER_TOKEN)")
+	serveUpdatesCmd.Flags().StringVar(&serveBaseURL, "base-url", "", "URL pública usada nos links de download (ex: https://updates.exemplo.com)")
+	serveUpdatesCmd.Flags().StringVar(&serveVersion, "version", "", "Versão anunciada se metadata.json não definir tag_name")
+	rootCmd.AddCommand(serveUpdatesCmd)
+}
+
+func runServeUpdates(cmd *cobra.Command, args []string) error {
+	token := serveToken
+	if token == "" {
+		token = os.Getenv("UPDATE_SERVER_TOKEN")
+	}
+
+	server := &updateserver.Server{
+		Dir:     serveDir,
+		Token:   token,
+		BaseURL: serveBaseURL,
+		Version: serveVersion,
+	}
+	if err := os.MkdirAll(server.BinariesDir(), 0755); err != nil {
+		return fmt.Errorf("erro ao criar diretório de binários: %w", err)
+	}
+
+	addr := net.JoinHostPort(serveHost, strconv.Itoa(servePort))
+	fmt.Printf("🚀 Servidor de atualizações em http://%s\n", addr)
+	fmt.Printf("📁 Binários em: %s\n", server.BinariesDir())
+	if token == "" {
+		fmt.Println("⚠️  Sem token: upload desabilitado (use --token ou UPDATE_SERVER_TOKEN)")
+	}
+
+	httpServer := &http.Server{
+		Addr:              addr,
+		Handler:           server.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	if err := httpServer.ListenAndServe(); err != nil {
+		return fmt.Errorf("erro no servidor de atualizações: %w", err)
+	}
+	return nil
+}
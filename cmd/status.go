@@ -3,9 +3,10 @@ package cmd
 import (
 	"fmt"
 	"os"
-	"path/filepath"
+	"strings"
 
 	"github.com/spf13/cobra"
+	"github.com/tstest3213/00cli/internal/deploy"
 )
 
 var statusCmd = &cobra.Command{
@@ -40,10 +41,50 @@ func runStatus(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("erro ao carregar settings: %w", err)
 	}
 
+	// Carregar deploy config
+	deployConfig, err := loadDeployConfig(root)
+	if err != nil {
+		return fmt.Errorf("erro ao carregar deploy.json: %w", err)
+	}
+
+	env, err := applyEnvironment(settings, deployConfig, envName)
+	if err != nil {
+		return err
+	}
+
+	if names := environmentNames(settings, deployConfig); len(names) > 0 {
+		fmt.Println("\n🌎 Ambientes:")
+		for _, name := range names {
+			marker := "  "
+			if name == env {
+				marker = "➜ "
+			}
+			suffix := ""
+			if name == settings.DefaultEnvironment {
+				suffix = " (padrão)"
+			}
+			fmt.Printf("   %s%s%s\n", marker, name, suffix)
+		}
+	}
+
 	fmt.Println("\n📋 Configurações do Servidor:")
 	fmt.Printf("   Host: %s\n", settings.Server.Host)
-	fmt.Printf("   Porta: %d\n", settings.Server.Port)
-	fmt.Printf("   Usuário: %s\n", settings.Server.User)
+	if settings.Server.Port != 0 {
+		fmt.Printf("   Porta: %d\n", settings.Server.Port)
+	} else {
+		fmt.Println("   Porta: ssh_config ou 22")
+	}
+	if settings.Server.User != "" {
+		fmt.Printf("   Usuário: %s\n", settings.Server.User)
+	} else {
+		fmt.Println("   Usuário: ssh_config ou usuário local")
+	}
+	if len(settings.Hosts) > 0 {
+		fmt.Println("   Hosts:")
+		for _, host := range settings.Hosts {
+			fmt.Printf("     - %s\n", describeHost(settings, host))
+		}
+	}
 	fmt.Printf("   Versão atual: %s\n", settings.CurrentVersion)
 
 	if settings.ProjectName != "" {
@@ -51,7 +92,7 @@ func runStatus(cmd *cobra.Command, args []string) error {
 	}
 
 	// Verificar provision
-	provisionPath := filepath.Join(root, "provision")
+	provisionPath := provisionDir(root, deployConfig)
 	if info, err := os.Stat(provisionPath); err == nil {
 		fmt.Printf("\n📦 Diretório /provision/ existe")
 		if info.IsDir() {
@@ -62,17 +103,32 @@ func runStatus(cmd *cobra.Command, args []string) error {
 		fmt.Println("\n⚠️  Diretório /provision/ não encontrado")
 	}
 
-	// Carregar deploy config
-	deployConfig, err := loadDeployConfig(root)
-	if err != nil {
-		return fmt.Errorf("erro ao carregar deploy.json: %w", err)
-	}
-
 	fmt.Printf("\n🚀 Configuração de Deploy:")
 	fmt.Printf("   Tipo: %s\n", deployConfig.Type)
 	if deployConfig.Provision.Path != "" {
 		fmt.Printf("   Path provision: %s\n", deployConfig.Provision.Path)
 	}
+	if deployConfig.Provision.Target != "" {
+		fmt.Printf("   Destino provision: %s\n", deployConfig.Provision.Target)
+	}
+	if len(deployConfig.Build.Commands) > 0 {
+		fmt.Printf("   Build local: %d comando(s) → %s\n", len(deployConfig.Build.Commands), deployConfig.Build.Output)
+	}
+	if deployConfig.Sync.Path != "" {
+		fmt.Printf("   Sync: %s → %s\n", deployConfig.Sync.Path, deployConfig.Sync.Target)
+	}
+	if len(deployConfig.Scripts) > 0 {
+		fmt.Printf("   Scripts: %s\n", strings.Join(deployConfig.Scripts, ", "))
+	}
+	if len(deployConfig.Stages) > 0 {
+		var stages []string
+		for _, name := range deploy.StageNames() {
+			if stage, ok := deployConfig.Stages[name]; ok {
+				stages = append(stages, fmt.Sprintf("%s (%d)", name, len(stage.Commands)))
+			}
+		}
+		fmt.Printf("   Estágios: %s\n", strings.Join(stages, ", "))
+	}
 
 	return nil
 }
@@ -6,7 +6,6 @@ import (
 	"io"
 	"net/http"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"runtime"
 	"strings"
@@ -19,20 +18,34 @@ const (
 	githubRepoOwner = "tstest3213"
 	githubRepoName  = "00cli"
 	githubAPIURL    = "https://api.github.com/repos/%s/%s/releases/latest"
+	githubListURL   = "https://api.github.com/repos/%s/%s/releases"
+	githubTagURL    = "https://api.github.com/repos/%s/%s/releases/tags/%s"
+
+	// Canais de atualização: stable ignora pré-releases, beta as inclui
+	channelStable = "stable"
+	channelBeta   = "beta"
 )
 
 // Release representa uma release (GitHub ou servidor customizado)
 type Release struct {
-	TagName     string    `json:"tag_name"`
-	Name        string    `json:"name"`
-	PublishedAt time.Time `json:"published_at"`
-	HTMLURL     string    `json:"html_url"`
-	Body        string    `json:"body"`
-	Assets      []struct {
-		Name               string `json:"name"`
-		BrowserDownloadURL string `json:"browser_download_url"`
-		Size               int    `json:"size"`
-	} `json:"assets"`
+	TagName     string         `json:"tag_name"`
+	Name        string         `json:"name"`
+	PublishedAt time.Time      `json:"published_at"`
+	HTMLURL     string         `json:"html_url"`
+	Body        string         `json:"body"`
+	Prerelease  bool           `json:"prerelease"`
+	Draft       bool           `json:"draft"`
+	Assets      []ReleaseAsset `json:"assets"`
+}
+
+// ReleaseAsset é um arquivo de uma release. O checksum vem do campo sha256
+// (servidor customizado) ou digest (API do GitHub, formato "sha256:<hex>").
+type ReleaseAsset struct {
+	Name               string `json:"name"`
+	BrowserDownloadURL string `json:"browser_download_url"`
+	Size               int    `json:"size"`
+	SHA256             string `json:"sha256,omitempty"`
+	Digest             string `json:"digest,omitempty"`
 }
 
 // GitHubRelease mantém compatibilidade
@@ -43,34 +56,37 @@ var updateCmd = &cobra.Command{
 	Short: "Atualiza o 00cli para a versão mais recente",
 	Long: `Verifica e instala automaticamente a versão mais recente do 00cli.
 Pode usar servidor customizado (configurado em .00cli/settings.json ou variável 00CLI_UPDATE_SERVER)
-ou GitHub como fallback.`,
-	RunE: runUpdate,
-}
+ou GitHub como fallback.
 
-func init() {
-	rootCmd.AddCommand(updateCmd)
+O canal "stable" (padrão) considera apenas versões finais; "beta" inclui
+pré-releases. Use --version para fixar uma versão específica, inclusive
+anterior à atual.
+
+Antes de substituir o binário atual, a nova versão é validada executando
+"00cli version"; se falhar, o binário anterior é restaurado. Os últimos
+binários instalados ficam guardados no diretório de estado do usuário
+(update_keep no settings.json, padrão 3): use --list-installed para vê-los e
+--rollback para voltar à versão anterior (ou a --version).`,
+	RunE: runUpdate,
 }
 
-// CheckForUpdates verifica se há atualizações disponíveis
-func CheckForUpdates(currentVersion string) {
-	// Aguardar um pouco para não bloquear o início do programa
-	time.Sleep(500 * time.Millisecond)
+var (
+	updateChannel string
+	updateVersion string
+	updateCheck   bool
 
-	release, err := getLatestRelease()
-	if err != nil {
-		return // Falha silenciosa
-	}
+	updateRollback      bool
+	updateListInstalled bool
+)
 
-	// Comparar versões
-	if release.TagName != "" && release.TagName != currentVersion {
-		fmt.Printf("\n⚠️  Nova versão disponível: %s (atual: %s)\n", release.TagName, currentVersion)
-		fmt.Printf("   Execute '00cli update' para atualizar automaticamente\n")
-		if release.HTMLURL != "" {
-			fmt.Printf("   Ou baixe em: %s\n\n", release.HTMLURL)
-		} else {
-			fmt.Println()
-		}
-	}
+func init() {
+	updateCmd.Flags().StringVar(&updateChannel, "channel", "", "Canal de atualização: stable ou beta (padrão: update_channel ou stable)")
+	updateCmd.Flags().StringVar(&updateVersion, "version", "", "Instala a versão informada (ex: v0.2.0), mesmo que seja anterior à atual")
+	updateCmd.Flags().BoolVar(&updateCheck, "check", false, "Apenas verifica se há atualização, sem instalar")
+	updateCmd.Flags().BoolVar(&updateRollback, "rollback", false, "Volta para o binário instalado anteriormente (ou para --version)")
+	updateCmd.Flags().BoolVar(&updateListInstalled, "list-installed", false, "Lista os binários guardados e o histórico de instalações")
+	updateCmd.MarkFlagsMutuallyExclusive("rollback", "list-installed", "check")
+	rootCmd.AddCommand(updateCmd)
 }
 
 // getUpdateServerURL obtém a URL do servidor de atualizações configurado
@@ -91,32 +107,135 @@ func getUpdateServerURL() string {
 	return "" // Usar GitHub como padrão
 }
 
-// getLatestRelease obtém a última release (servidor customizado ou GitHub)
-func getLatestRelease() (*Release, error) {
+// getUpdateChannel obtém o canal de atualização: flag, variável
+// 00CLI_UPDATE_CHANNEL, update_channel do settings.json ou stable
+func getUpdateChannel(flag string) (string, error) {
+	channel := flag
+	if channel == "" {
+		channel = os.Getenv("00CLI_UPDATE_CHANNEL")
+	}
+	if channel == "" {
+		root, _ := getProjectRoot()
+		if settings, err := loadSettings(root); err == nil {
+			channel = settings.UpdateChannel
+		}
+	}
+
+	switch channel {
+	case "":
+		return channelStable, nil
+	case channelStable, channelBeta:
+		return channel, nil
+	default:
+		return "", fmt.Errorf("canal de atualização inválido: %s (use %q ou %q)", channel, channelStable, channelBeta)
+	}
+}
+
+// isPrerelease informa se a release é uma pré-release, pelo campo do GitHub
+// ou pela própria tag (ex: v1.0.0-beta.1)
+func isPrerelease(release *Release) bool {
+	if release.Prerelease {
+		return true
+	}
+	v, err := parseVersion(release.TagName)
+	return err == nil && v.IsPrerelease()
+}
+
+// channelAllows informa se a release pertence ao canal
+func channelAllows(channel string, release *Release) bool {
+	return channel == channelBeta || !isPrerelease(release)
+}
+
+// getLatestRelease obtém a última release do canal (servidor customizado ou GitHub)
+func getLatestRelease(channel string) (*Release, error) {
+	return getLatestReleaseFrom(getUpdateServerURL(), channel)
+}
+
+// getLatestReleaseFrom obtém a release mais recente do canal no servidor
+// customServer (vazio: GitHub), sem consultar a configuração do projeto
+func getLatestReleaseFrom(customServer, channel string) (*Release, error) {
 	// Verificar se há servidor customizado configurado
-	customServer := getUpdateServerURL()
 	if customServer != "" {
-		release, err := getLatestReleaseFromCustomServer(customServer)
-		if err == nil {
+		release, err := getLatestReleaseFromCustomServer(customServer, channel)
+		if err == nil && channelAllows(channel, release) {
 			return release, nil
 		}
 		// Se falhar, tentar GitHub como fallback
 	}
 
 	// Usar GitHub como padrão ou fallback
+	if channel == channelBeta {
+		return getNewestReleaseFromGitHub()
+	}
 	return getLatestReleaseFromGitHub()
 }
 
+// getReleaseByTag obtém uma versão específica (servidor customizado, se a
+// oferecer, ou GitHub)
+func getReleaseByTag(tag string) (*Release, error) {
+	if customServer := getUpdateServerURL(); customServer != "" {
+		// Servidores com releases versionadas (00cli serve-updates) respondem
+		// em /releases/<versão>
+		if release, err := getCustomServerRelease(customServerBaseURL(customServer) + "/releases/" + tag); err == nil {
+			return release, nil
+		}
+		release, err := getLatestReleaseFromCustomServer(customServer, channelBeta)
+		if err == nil {
+			if cmp, err := compareVersions(release.TagName, tag); err == nil && cmp == 0 {
+				return release, nil
+			}
+		}
+	}
+
+	var release Release
+	url := fmt.Sprintf(githubTagURL, githubRepoOwner, githubRepoName, tag)
+	if err := getGitHubJSON(url, &release); err != nil {
+		return nil, fmt.Errorf("versão %s não encontrada: %w", tag, err)
+	}
+	return &release, nil
+}
+
 // getLatestReleaseFromCustomServer obtém release do servidor customizado
-func getLatestReleaseFromCustomServer(serverURL string) (*Release, error) {
+func getLatestReleaseFromCustomServer(serverURL, channel string) (*Release, error) {
 	// Garantir que a URL termina com /latest ou /updates/latest
 	url := strings.TrimSuffix(serverURL, "/")
 	if !strings.HasSuffix(url, "/latest") && !strings.HasSuffix(url, "/updates") {
 		url += "/latest"
 	}
+	baseURL := customServerBaseURL(serverURL)
+	if channel == channelBeta {
+		url += "?channel=" + channelBeta
+	}
 
-	client := &http.Client{
-		Timeout: 10 * time.Second,
+	release, err := getCustomServerRelease(url)
+	if err != nil {
+		return nil, err
+	}
+
+	// Se o servidor retornar apenas URL do binário, construir asset
+	if len(release.Assets) == 0 && release.TagName != "" {
+		binaryName := getBinaryName()
+		release.Assets = []ReleaseAsset{
+			{
+				Name:               binaryName,
+				BrowserDownloadURL: fmt.Sprintf("%s/download/%s", baseURL, binaryName),
+			},
+		}
+	}
+
+	return release, nil
+}
+
+// customServerBaseURL remove o /latest da URL configurada do servidor
+func customServerBaseURL(serverURL string) string {
+	return strings.TrimSuffix(strings.TrimSuffix(serverURL, "/"), "/latest")
+}
+
+// getCustomServerRelease busca uma release em formato JSON do servidor customizado
+func getCustomServerRelease(url string) (*Release, error) {
+	client, err := newHTTPClient(10 * time.Second)
+	if err != nil {
+		return nil, err
 	}
 
 	req, err := http.NewRequest("GET", url, nil)
@@ -146,62 +265,82 @@ func getLatestReleaseFromCustomServer(serverURL string) (*Release, error) {
 	if err := json.Unmarshal(body, &release); err != nil {
 		return nil, err
 	}
+	return &release, nil
+}
 
-	// Se o servidor retornar apenas URL do binário, construir asset
-	if len(release.Assets) == 0 && release.TagName != "" {
-		binaryName := getBinaryName()
-		baseURL := strings.TrimSuffix(url, "/latest")
-		release.Assets = []struct {
-			Name               string `json:"name"`
-			BrowserDownloadURL string `json:"browser_download_url"`
-			Size               int    `json:"size"`
-		}{
-			{
-				Name:               binaryName,
-				BrowserDownloadURL: fmt.Sprintf("%s/download/%s", baseURL, binaryName),
-			},
-		}
+// getLatestReleaseFromGitHub obtém a última release estável do GitHub
+func getLatestReleaseFromGitHub() (*Release, error) {
+	var release Release
+	if err := getGitHubJSON(fmt.Sprintf(githubAPIURL, githubRepoOwner, githubRepoName), &release); err != nil {
+		return nil, err
 	}
-
 	return &release, nil
 }
 
-// getLatestReleaseFromGitHub obtém a última release do GitHub
-func getLatestReleaseFromGitHub() (*Release, error) {
-	url := fmt.Sprintf(githubAPIURL, githubRepoOwner, githubRepoName)
+// getNewestReleaseFromGitHub obtém a maior versão publicada no GitHub,
+// incluindo pré-releases
+func getNewestReleaseFromGitHub() (*Release, error) {
+	var releases []Release
+	if err := getGitHubJSON(fmt.Sprintf(githubListURL, githubRepoOwner, githubRepoName), &releases); err != nil {
+		return nil, err
+	}
+
+	var newest *Release
+	var newestVersion semVersion
+	for i := range releases {
+		if releases[i].Draft {
+			continue
+		}
+		v, err := parseVersion(releases[i].TagName)
+		if err != nil {
+			continue
+		}
+		if newest == nil || v.Compare(newestVersion) > 0 {
+			newest, newestVersion = &releases[i], v
+		}
+	}
+
+	if newest == nil {
+		return nil, fmt.Errorf("nenhuma release encontrada")
+	}
+	return newest, nil
+}
 
-	client := &http.Client{
-		Timeout: 10 * time.Second,
+// getGitHubJSON consulta a API do GitHub e decodifica a resposta em v
+func getGitHubJSON(url string, v interface{}) error {
+	client, err := newHTTPClient(10 * time.Second)
+	if err != nil {
+		return err
 	}
 
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	req.Header.Set("User-Agent", "00cli-updater")
+	// Com token o limite da API sobe de 60 para 5000 requisições por hora
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
 
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("status code: %d", resp.StatusCode)
+		return fmt.Errorf("status code: %d", resp.StatusCode)
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, err
-	}
-
-	var release Release
-	if err := json.Unmarshal(body, &release); err != nil {
-		return nil, err
+		return err
 	}
 
-	return &release, nil
+	return json.Unmarshal(body, v)
 }
 
 // getBinaryName retorna o nome do binário baseado no OS e ARCH
@@ -218,12 +357,27 @@ func getBinaryName() string {
 }
 
 // findBinaryAsset encontra o asset correto para a plataforma atual
-func findBinaryAsset(release *Release) string {
-	binaryName := getBinaryName()
+// (binário puro ou pacote, conforme os templates de update_assets)
+func findBinaryAsset(release *Release) *ReleaseAsset {
+	names, err := assetNames(getAssetTemplates(), release.TagName)
+	if err != nil {
+		fmt.Printf("⚠️  %v\n", err)
+	}
+	for _, name := range names {
+		for i := range release.Assets {
+			if release.Assets[i].Name == name {
+				return &release.Assets[i]
+			}
+		}
+	}
+	return findAsset(release, getBinaryName())
+}
 
-	for _, asset := range release.Assets {
-		if asset.Name == binaryName {
-			return asset.BrowserDownloadURL
+// findAsset encontra um asset da release pelo nome
+func findAsset(release *Release, name string) *ReleaseAsset {
+	for i := range release.Assets {
+		if release.Assets[i].Name == name {
+			return &release.Assets[i]
 		}
 	}
 
@@ -231,34 +385,85 @@ func findBinaryAsset(release *Release) string {
 	if customServer := getUpdateServerURL(); customServer != "" {
 		baseURL := strings.TrimSuffix(customServer, "/latest")
 		baseURL = strings.TrimSuffix(baseURL, "/updates")
-		return fmt.Sprintf("%s/download/%s", baseURL, binaryName)
+		return &ReleaseAsset{
+			Name:               name,
+			BrowserDownloadURL: fmt.Sprintf("%s/download/%s", baseURL, name),
+		}
 	}
 
-	return ""
+	return nil
 }
 
 // runUpdate executa a atualização
 func runUpdate(cmd *cobra.Command, args []string) error {
+	switch {
+	case updateListInstalled:
+		return runListInstalled()
+	case updateRollback:
+		return runUpdateRollback(updateVersion)
+	}
+
+	channel, err := getUpdateChannel(updateChannel)
+	if err != nil {
+		return err
+	}
+
 	fmt.Println("🔍 Verificando atualizações...")
 
-	release, err := getLatestRelease()
+	var release *Release
+	if updateVersion != "" {
+		tag := updateVersion
+		if !strings.HasPrefix(tag, "v") {
+			tag = "v" + tag
+		}
+		if _, err := parseVersion(tag); err != nil {
+			return err
+		}
+		release, err = getReleaseByTag(tag)
+	} else {
+		release, err = getLatestRelease(channel)
+	}
 	if err != nil {
 		return fmt.Errorf("erro ao verificar atualizações: %w", err)
 	}
 
 	// Obter versão atual
 	currentVersion := getCurrentVersion()
-	if release.TagName == currentVersion {
+	cmp, cmpErr := compareVersions(release.TagName, currentVersion)
+	if cmpErr != nil {
+		fmt.Printf("⚠️  Não foi possível comparar as versões: %v\n", cmpErr)
+	}
+
+	switch {
+	case cmpErr == nil && updateVersion == "" && cmp <= 0:
 		fmt.Printf("✅ Você já está na versão mais recente: %s\n", currentVersion)
+		if cmp < 0 {
+			fmt.Printf("   Última versão no canal %s: %s\n", channel, release.TagName)
+		}
+		return nil
+	case cmpErr == nil && cmp == 0:
+		fmt.Printf("✅ Você já está na versão %s\n", currentVersion)
+		return nil
+	case cmpErr == nil && cmp < 0:
+		fmt.Printf("📦 Downgrade solicitado: %s (atual: %s)\n", release.TagName, currentVersion)
+	default:
+		fmt.Printf("📦 Nova versão encontrada: %s (atual: %s)\n", release.TagName, currentVersion)
+	}
+
+	if updateCheck {
+		if updateVersion != "" {
+			fmt.Printf("   Execute '00cli update --version %s' para instalar\n", release.TagName)
+		} else {
+			fmt.Println("   Execute '00cli update' para instalar")
+		}
 		return nil
 	}
 
-	fmt.Printf("📦 Nova versão encontrada: %s (atual: %s)\n", release.TagName, currentVersion)
 	fmt.Println("🚀 Iniciando atualização...")
 
 	// Encontrar o binário correto
-	downloadURL := findBinaryAsset(release)
-	if downloadURL == "" {
+	asset := findBinaryAsset(release)
+	if asset == nil {
 		return fmt.Errorf("binário não encontrado para %s/%s. Baixe manualmente em: %s", runtime.GOOS, runtime.GOARCH, release.HTMLURL)
 	}
 
@@ -268,110 +473,79 @@ func runUpdate(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("erro ao obter caminho do binário: %w", err)
 	}
 
-	// Criar arquivo temporário
-	tmpFile := filepath.Join(os.TempDir(), "00cli-update")
+	// Arquivo temporário único no diretório do binário, para que usuários
+	// simultâneos não colidam e o rename final seja no mesmo sistema de arquivos
+	pattern := ".00cli-update-*"
 	if runtime.GOOS == "windows" {
-		tmpFile += ".exe"
+		pattern += ".exe"
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(currentBinary), pattern)
+	if err != nil {
+		return fmt.Errorf("erro ao criar arquivo temporário em %s: %w", filepath.Dir(currentBinary), err)
 	}
+	tmp.Close()
+	tmpFile := tmp.Name()
+	defer os.Remove(tmpFile)
 
 	fmt.Printf("⬇️  Baixando %s...\n", release.TagName)
 
 	// Baixar novo binário
-	if err := downloadFile(downloadURL, tmpFile); err != nil {
+	if err := downloadFile(asset.BrowserDownloadURL, tmpFile); err != nil {
 		return fmt.Errorf("erro ao baixar: %w", err)
 	}
 
-	// Tornar executável (Unix)
-	if runtime.GOOS != "windows" {
-		if err := os.Chmod(tmpFile, 0755); err != nil {
-			return fmt.Errorf("erro ao tornar executável: %w", err)
-		}
+	// Verificar integridade antes de tocar no binário atual
+	fmt.Println("🔐 Verificando integridade...")
+	if err := verifyDownload(release, asset, tmpFile); err != nil {
+		return fmt.Errorf("atualização abortada, binário atual mantido: %w", err)
 	}
 
-	fmt.Println("📦 Instalando nova versão...")
-
-	// Substituir binário antigo
-	if runtime.GOOS == "windows" {
-		// Windows: precisa fechar o processo primeiro
-		oldFile := currentBinary + ".old"
-		if err := os.Rename(currentBinary, oldFile); err != nil {
-			return fmt.Errorf("erro ao renomear binário antigo: %w", err)
-		}
-		if err := os.Rename(tmpFile, currentBinary); err != nil {
-			os.Rename(oldFile, currentBinary) // Reverter em caso de erro
-			return fmt.Errorf("erro ao instalar novo binário: %w", err)
+	// Pacotes (tar.gz/zip) são extraídos ao lado do binário atual
+	binaryFile := tmpFile
+	var bundle *releaseBundle
+	if kind := archiveKind(asset.Name); kind != "" {
+		fmt.Printf("📂 Extraindo %s...\n", asset.Name)
+		extractDir, err := os.MkdirTemp(filepath.Dir(currentBinary), ".00cli-extract-*")
+		if err != nil {
+			return fmt.Errorf("erro ao criar diretório temporário: %w", err)
 		}
-		os.Remove(oldFile)
-	} else {
-		// Unix: pode substituir diretamente
-		if err := os.Rename(tmpFile, currentBinary); err != nil {
-			return fmt.Errorf("erro ao instalar novo binário: %w", err)
+		defer os.RemoveAll(extractDir)
+
+		bundle, err = extractRelease(tmpFile, kind, extractDir)
+		if err != nil {
+			return fmt.Errorf("atualização abortada, binário atual mantido: %w", err)
 		}
+		binaryFile = bundle.Binary
 	}
 
-	fmt.Printf("✅ Atualização concluída! Nova versão: %s\n", release.TagName)
-	fmt.Println("   Execute '00cli version' para verificar.")
-
-	return nil
-}
+	// Tornar executável (Unix)
+	if runtime.GOOS != "windows" {
+		if err := os.Chmod(binaryFile, 0755); err != nil {
+			return fmt.Errorf("erro ao tornar executável: %w", err)
+		}
+	}
 
-// downloadFile baixa um arquivo de uma URL
-func downloadFile(url, dest string) error {
-	client := &http.Client{
-		Timeout: 60 * time.Second,
+	fmt.Println("🧪 Validando novo binário...")
+	if err := validateBinary(binaryFile, release.TagName); err != nil {
+		return fmt.Errorf("atualização abortada, binário atual mantido: %w", err)
 	}
 
-	resp, err := client.Get(url)
-	if err != nil {
+	fmt.Println("📦 Instalando nova versão...")
+	if err := installVersion(binaryFile, currentBinary, release.TagName, currentVersion, false); err != nil {
 		return err
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("status code: %d", resp.StatusCode)
+	if bundle != nil {
+		installExtras(bundle)
 	}
 
-	file, err := os.Create(dest)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
+	fmt.Printf("✅ Atualização concluída! Nova versão: %s\n", release.TagName)
+	fmt.Println("   Execute '00cli version' para verificar.")
 
-	_, err = io.Copy(file, resp.Body)
-	return err
+	return nil
 }
 
-// getCurrentVersion obtém a versão atual do binário
+// getCurrentVersion obtém a versão atual do binário a partir dos dados de
+// build recebidos por main
 func getCurrentVersion() string {
-	// Tentar ler da variável de ambiente (setada no build)
-	if v := os.Getenv("00CLI_VERSION"); v != "" {
-		return v
-	}
-
-	// Tentar executar o comando version
-	exe, err := os.Executable()
-	if err != nil {
-		return "v0.0.0"
-	}
-
-	cmd := exec.Command(exe, "version")
-	output, err := cmd.Output()
-	if err != nil {
-		return "v0.0.0"
-	}
-
-	// Extrair versão da saída (formato: "00cli version v0.1.0")
-	lines := strings.Split(string(output), "\n")
-	for _, line := range lines {
-		if strings.Contains(line, "version") {
-			parts := strings.Fields(line)
-			for _, part := range parts {
-				if strings.HasPrefix(part, "v") {
-					return part
-				}
-			}
-		}
-	}
-
-	return "v0.0.0"
+	return currentBuildInfo().Version
 }
@@ -0,0 +1,182 @@
+package cmd
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tstest3213/00cli/internal/updateserver"
+)
+
+// newAssetServer serve os arquivos informados em /<nome>
+func newAssetServer(t *testing.T, files map[string][]byte) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, ok := files[r.URL.Path[1:]]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(data)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func writeBinary(t *testing.T, content []byte) string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "00cli-update")
+	if err := os.WriteFile(file, content, 0644); err != nil {
+		t.Fatalf("erro ao escrever binário: %v", err)
+	}
+	return file
+}
+
+func TestParseChecksums(t *testing.T) {
+	data := []byte("ABC123  00cli-linux-amd64\ndef456 *00cli-windows-amd64.exe\n\ninválida\n")
+	sums := parseChecksums(data)
+
+	if sums["00cli-linux-amd64"] != "abc123" {
+		t.Errorf("checksum linux incorreto: %q", sums["00cli-linux-amd64"])
+	}
+	if sums["00cli-windows-amd64.exe"] != "def456" {
+		t.Errorf("checksum windows (modo binário) incorreto: %q", sums["00cli-windows-amd64.exe"])
+	}
+	if len(sums) != 2 {
+		t.Errorf("esperado 2 checksums, obtido %d", len(sums))
+	}
+}
+
+func TestVerifyDownloadChecksums(t *testing.T) {
+	content := []byte("novo binário")
+	digest := sha256.Sum256(content)
+	sum := hex.EncodeToString(digest[:])
+	file := writeBinary(t, content)
+
+	srv := newAssetServer(t, map[string][]byte{
+		"checksums.txt": []byte(fmt.Sprintf("%s  00cli-linux-amd64\n", sum)),
+		"bad.txt":       []byte(fmt.Sprintf("%s  00cli-linux-amd64\n", sum[:60]+"0000")),
+	})
+
+	asset := ReleaseAsset{Name: "00cli-linux-amd64"}
+	release := &Release{Assets: []ReleaseAsset{
+		asset,
+		{Name: "checksums.txt", BrowserDownloadURL: srv.URL + "/checksums.txt"},
+	}}
+	if err := verifyDownload(release, &release.Assets[0], file); err != nil {
+		t.Errorf("checksums.txt correto deveria passar: %v", err)
+	}
+
+	release.Assets[1].BrowserDownloadURL = srv.URL + "/bad.txt"
+	if err := verifyDownload(release, &release.Assets[0], file); err == nil {
+		t.Error("esperado erro com checksums.txt divergente")
+	}
+
+	// sha256 publicado pelo servidor de atualizações
+	release = &Release{Assets: []ReleaseAsset{{Name: asset.Name, SHA256: sum}}}
+	if err := verifyDownload(release, &release.Assets[0], file); err != nil {
+		t.Errorf("sha256 do asset correto deveria passar: %v", err)
+	}
+	release.Assets[0].Digest = "sha256:" + sum[:60] + "0000"
+	if err := verifyDownload(release, &release.Assets[0], file); err == nil {
+		t.Error("esperado erro com digest divergente")
+	}
+}
+
+func TestVerifyDownloadSignature(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("erro ao gerar chave: %v", err)
+	}
+
+	previous := updatePublicKey
+	updatePublicKey = base64.StdEncoding.EncodeToString(pub)
+	t.Cleanup(func() { updatePublicKey = previous })
+
+	content := []byte("novo binário")
+	file := writeBinary(t, content)
+
+	srv := newAssetServer(t, map[string][]byte{
+		"00cli-linux-amd64.sig": []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(priv, content))),
+		"other.sig":             ed25519.Sign(priv, []byte("outro conteúdo")),
+	})
+
+	release := &Release{Assets: []ReleaseAsset{
+		{Name: "00cli-linux-amd64"},
+		{Name: "00cli-linux-amd64.sig", BrowserDownloadURL: srv.URL + "/00cli-linux-amd64.sig"},
+	}}
+	if err := verifyDownload(release, &release.Assets[0], file); err != nil {
+		t.Errorf("assinatura válida deveria passar: %v", err)
+	}
+
+	release.Assets[1].BrowserDownloadURL = srv.URL + "/other.sig"
+	if err := verifyDownload(release, &release.Assets[0], file); err == nil {
+		t.Error("esperado erro com assinatura de outro conteúdo")
+	}
+
+	release.Assets = release.Assets[:1]
+	if err := verifyDownload(release, &release.Assets[0], file); err == nil {
+		t.Error("esperado erro quando a release não tem assinatura")
+	}
+}
+
+func TestGetLatestReleaseFromCustomServer(t *testing.T) {
+	server := &updateserver.Server{Dir: t.TempDir(), Version: "v0.2.0"}
+	if err := os.MkdirAll(server.BinariesDir(), 0755); err != nil {
+		t.Fatalf("erro ao criar diretório: %v", err)
+	}
+	content := []byte("novo binário")
+	if err := os.WriteFile(filepath.Join(server.BinariesDir(), getBinaryName()), content, 0755); err != nil {
+		t.Fatalf("erro ao escrever binário: %v", err)
+	}
+
+	ts := httptest.NewServer(server.Handler())
+	defer ts.Close()
+
+	for _, url := range []string{ts.URL, ts.URL + "/latest", ts.URL + "/updates"} {
+		release, err := getLatestReleaseFromCustomServer(url, channelStable)
+		if err != nil {
+			t.Fatalf("erro ao consultar %s: %v", url, err)
+		}
+		if release.TagName != "v0.2.0" {
+			t.Errorf("%s: esperado v0.2.0, obtido %s", url, release.TagName)
+		}
+
+		asset := findBinaryAsset(release)
+		if asset == nil || asset.SHA256 == "" {
+			t.Fatalf("%s: asset da plataforma sem checksum: %+v", url, asset)
+		}
+
+		file := filepath.Join(t.TempDir(), "00cli-update")
+		if err := downloadFile(asset.BrowserDownloadURL, file); err != nil {
+			t.Fatalf("%s: erro no download: %v", url, err)
+		}
+		if err := verifyDownload(release, asset, file); err != nil {
+			t.Errorf("%s: download deveria passar na verificação: %v", url, err)
+		}
+	}
+}
+
+func TestChannelAllows(t *testing.T) {
+	stable := &Release{TagName: "v1.0.0"}
+	beta := &Release{TagName: "v1.1.0-beta.1"}
+	flagged := &Release{TagName: "v1.1.0", Prerelease: true}
+
+	if !channelAllows(channelStable, stable) || !channelAllows(channelBeta, stable) {
+		t.Error("release estável deveria valer para todos os canais")
+	}
+	if channelAllows(channelStable, beta) || channelAllows(channelStable, flagged) {
+		t.Error("canal stable não deveria aceitar pré-releases")
+	}
+	if !channelAllows(channelBeta, beta) || !channelAllows(channelBeta, flagged) {
+		t.Error("canal beta deveria aceitar pré-releases")
+	}
+}
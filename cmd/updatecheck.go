@@ -0,0 +1,192 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tstest3213/00cli/internal/tty"
+)
+
+const (
+	updateCheckFile = "update-check.json"
+
+	// defaultUpdateCheckInterval é o intervalo entre consultas de nova versão
+	defaultUpdateCheckInterval = 24 * time.Hour
+
+	// updateCheckWait é quanto o aviso espera por uma consulta ainda em
+	// andamento depois que o comando termina
+	updateCheckWait = time.Second
+)
+
+// ciEnvVars indicam execução em integração contínua, onde o aviso é omitido
+var ciEnvVars = []string{"CI", "GITHUB_ACTIONS", "GITLAB_CI", "BUILDKITE", "JENKINS_URL", "TF_BUILD", "TEAMCITY_VERSION"}
+
+// updateCheckState é o resultado da última consulta, guardado no diretório
+// de estado do usuário para não consultar o servidor a cada execução
+type updateCheckState struct {
+	CheckedAt     time.Time `json:"checked_at"`
+	Channel       string    `json:"channel"`
+	LatestVersion string    `json:"latest_version,omitempty"`
+	HTMLURL       string    `json:"html_url,omitempty"`
+}
+
+var (
+	// updateNotice exibe o aviso da verificação iniciada por startUpdateCheck
+	updateNotice = func() {}
+
+	// updateNoticeOutput recebe o aviso; a verificação só roda se for um
+	// terminal (variável para os testes)
+	updateNoticeOutput io.Writer = os.Stderr
+	updateNoticeTTY              = func() bool { return tty.IsTerminal(os.Stderr) }
+)
+
+// startUpdateCheck inicia a verificação de atualizações em background para o
+// comando resolvido pelo cobra, com as flags já interpretadas. O aviso é
+// exibido por updateNotice, depois que o comando termina, para que não se
+// misture à saída do comando.
+func startUpdateCheck(cmd *cobra.Command) {
+	if !updateCheckAllowed(cmd.Name()) || !updateNoticeTTY() {
+		return
+	}
+
+	// A configuração do projeto é lida aqui; a goroutine recebe só valores
+	channel, err := getUpdateChannel("")
+	if err != nil {
+		return
+	}
+	server := getUpdateServerURL()
+	interval := getUpdateCheckInterval()
+
+	path, err := updateCheckPath()
+	if err != nil {
+		return
+	}
+	current := getCurrentVersion()
+	out := updateNoticeOutput
+
+	if state, ok := loadUpdateCheck(path); ok && state.fresh(channel, interval, time.Now()) {
+		updateNotice = func() { printUpdateNotice(out, state, current) }
+		return
+	}
+
+	done := make(chan updateCheckState, 1)
+	go func(server, channel string) {
+		state := updateCheckState{CheckedAt: time.Now().UTC(), Channel: channel}
+		// Falhas também são guardadas: sem rede, a próxima tentativa só
+		// acontece depois do intervalo
+		if release, err := getLatestReleaseFrom(server, channel); err == nil {
+			state.LatestVersion = release.TagName
+			state.HTMLURL = release.HTMLURL
+		}
+		saveUpdateCheck(path, state)
+		done <- state
+	}(server, channel)
+
+	updateNotice = func() {
+		select {
+		case state := <-done:
+			printUpdateNotice(out, state, current)
+		case <-time.After(updateCheckWait):
+		}
+	}
+}
+
+// updateCheckAllowed decide se a verificação roda para o comando: nunca em
+// update, em completion, com 00CLI_NO_UPDATE_CHECK ou em CI
+func updateCheckAllowed(command string) bool {
+	switch command {
+	case "update", "completion", "__complete", "__completeNoDesc", "serve-updates", "help":
+		return false
+	}
+	if v := os.Getenv("00CLI_NO_UPDATE_CHECK"); v != "" && v != "0" && v != "false" {
+		return false
+	}
+	for _, name := range ciEnvVars {
+		if v := os.Getenv(name); v != "" && v != "false" {
+			return false
+		}
+	}
+	return true
+}
+
+// getUpdateCheckInterval obtém o intervalo entre verificações:
+// 00CLI_UPDATE_CHECK_INTERVAL, update_check_interval do settings.json ou 24h
+func getUpdateCheckInterval() time.Duration {
+	value := os.Getenv("00CLI_UPDATE_CHECK_INTERVAL")
+	if value == "" {
+		root, _ := getProjectRoot()
+		if settings, err := loadSettings(root); err == nil {
+			value = settings.UpdateCheckInterval
+		}
+	}
+	if value == "" {
+		return defaultUpdateCheckInterval
+	}
+
+	interval, err := time.ParseDuration(value)
+	if err != nil || interval < 0 {
+		return defaultUpdateCheckInterval
+	}
+	return interval
+}
+
+// fresh indica se o resultado guardado ainda vale para o canal
+func (s updateCheckState) fresh(channel string, interval time.Duration, now time.Time) bool {
+	return s.Channel == channel && now.Sub(s.CheckedAt) < interval && !s.CheckedAt.After(now)
+}
+
+func updateCheckPath() (string, error) {
+	dir, err := userStateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, updateCheckFile), nil
+}
+
+func loadUpdateCheck(path string) (updateCheckState, bool) {
+	var state updateCheckState
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return state, false
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return state, false
+	}
+	return state, true
+}
+
+func saveUpdateCheck(path string, state updateCheckState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data, 0644)
+}
+
+// printUpdateNotice avisa apenas se a versão guardada for mais nova; versões
+// que não podem ser comparadas (builds locais sem tag) não geram aviso
+func printUpdateNotice(w io.Writer, state updateCheckState, current string) {
+	if state.LatestVersion == "" {
+		return
+	}
+	if cmp, err := compareVersions(state.LatestVersion, current); err != nil || cmp <= 0 {
+		return
+	}
+
+	var notice strings.Builder
+	fmt.Fprintf(&notice, "\n⚠️  Nova versão disponível: %s (atual: %s)\n", state.LatestVersion, current)
+	fmt.Fprintf(&notice, "   Execute '00cli update' para atualizar automaticamente\n")
+	if state.HTMLURL != "" {
+		fmt.Fprintf(&notice, "   Ou baixe em: %s\n", state.HTMLURL)
+	}
+	fmt.Fprint(w, notice.String())
+}
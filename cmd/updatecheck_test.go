@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tstest3213/00cli/internal/tty"
+)
+
+func TestUpdateCheckAllowed(t *testing.T) {
+	for _, name := range append([]string{"00CLI_NO_UPDATE_CHECK"}, ciEnvVars...) {
+		t.Setenv(name, "")
+	}
+
+	if !updateCheckAllowed("deploy") {
+		t.Error("verificação deveria rodar fora de CI")
+	}
+	if updateCheckAllowed("update") || updateCheckAllowed("__complete") {
+		t.Error("verificação não deveria rodar em update nem em completion")
+	}
+	t.Setenv("CI", "true")
+	if updateCheckAllowed("deploy") {
+		t.Error("verificação não deveria rodar em CI")
+	}
+	t.Setenv("CI", "")
+	t.Setenv("00CLI_NO_UPDATE_CHECK", "1")
+	if updateCheckAllowed("deploy") {
+		t.Error("verificação não deveria rodar com 00CLI_NO_UPDATE_CHECK")
+	}
+
+	// Arquivos comuns não são terminais
+	file, err := os.CreateTemp(t.TempDir(), "saida")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if tty.IsTerminal(file) {
+		t.Error("arquivo comum não deveria ser considerado terminal")
+	}
+}
+
+func TestUpdateCheckUsesProjectFlag(t *testing.T) {
+	for _, name := range append([]string{"00CLI_NO_UPDATE_CHECK", "00CLI_UPDATE_CHANNEL", "00CLI_UPDATE_SERVER", "00CLI_UPDATE_CHECK_INTERVAL"}, ciEnvVars...) {
+		t.Setenv(name, "")
+	}
+	t.Setenv("00CLI_STATE_DIR", t.TempDir())
+
+	queries := make(chan string, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries <- r.URL.RawQuery
+		w.Write([]byte(`{"tag_name": "v99.0.0"}`))
+	}))
+	defer ts.Close()
+
+	// O canal e o servidor vêm do projeto de -p, não do diretório atual
+	project := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(project, ".00cli"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	settings := `{"update_channel": "beta", "update_server": "` + ts.URL + `/updates"}`
+	if err := os.WriteFile(filepath.Join(project, ".00cli", "settings.json"), []byte(settings), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	defer func(w io.Writer, isTTY func() bool) {
+		updateNoticeOutput, updateNoticeTTY = w, isTTY
+		updateNotice = func() {}
+		projectPath = ""
+	}(updateNoticeOutput, updateNoticeTTY)
+	updateNoticeOutput = &out
+	updateNoticeTTY = func() bool { return true }
+
+	rootCmd.SetArgs([]string{"-p", project, "version", "--short"})
+	defer rootCmd.SetArgs(nil)
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("erro ao executar: %v", err)
+	}
+
+	select {
+	case query := <-queries:
+		if query != "channel=beta" {
+			t.Errorf("esperado canal beta do settings.json de -p, obtido %q", query)
+		}
+	default:
+		t.Fatal("servidor de atualizações do projeto não consultado")
+	}
+	if !strings.Contains(out.String(), "Nova versão disponível: v99.0.0") {
+		t.Errorf("aviso esperado, obtido %q", out.String())
+	}
+}
+
+func TestUpdateCheckCache(t *testing.T) {
+	now := time.Now()
+	state := updateCheckState{CheckedAt: now.Add(-time.Hour), Channel: channelStable, LatestVersion: "v1.2.0"}
+
+	if !state.fresh(channelStable, 24*time.Hour, now) {
+		t.Error("consulta de 1h atrás deveria valer com intervalo de 24h")
+	}
+	if state.fresh(channelBeta, 24*time.Hour, now) {
+		t.Error("troca de canal deveria invalidar o cache")
+	}
+	if state.fresh(channelStable, 30*time.Minute, now) {
+		t.Error("consulta mais antiga que o intervalo deveria expirar")
+	}
+
+	path := t.TempDir() + "/update-check.json"
+	if err := saveUpdateCheck(path, state); err != nil {
+		t.Fatalf("erro ao salvar cache: %v", err)
+	}
+	loaded, ok := loadUpdateCheck(path)
+	if !ok || loaded.LatestVersion != "v1.2.0" || !loaded.CheckedAt.Equal(state.CheckedAt) {
+		t.Errorf("cache carregado incorretamente: %+v", loaded)
+	}
+
+	var out bytes.Buffer
+	printUpdateNotice(&out, loaded, "v1.1.0")
+	if !strings.Contains(out.String(), "Nova versão disponível: v1.2.0") {
+		t.Errorf("aviso esperado, obtido %q", out.String())
+	}
+	out.Reset()
+	printUpdateNotice(&out, loaded, "v1.2.0-3-gabc1234")
+	if out.Len() != 0 {
+		t.Errorf("build local mais novo não deveria gerar aviso: %q", out.String())
+	}
+}
+
+func TestGetUpdateCheckInterval(t *testing.T) {
+	t.Setenv("00CLI_UPDATE_CHECK_INTERVAL", "6h")
+	if got := getUpdateCheckInterval(); got != 6*time.Hour {
+		t.Errorf("esperado 6h, obtido %s", got)
+	}
+	t.Setenv("00CLI_UPDATE_CHECK_INTERVAL", "inválido")
+	if got := getUpdateCheckInterval(); got != defaultUpdateCheckInterval {
+		t.Errorf("intervalo inválido deveria usar o padrão, obtido %s", got)
+	}
+}
+
+func TestGitHubTokenHeader(t *testing.T) {
+	var auth string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		w.Write([]byte(`{"tag_name": "v1.0.0"}`))
+	}))
+	defer ts.Close()
+
+	t.Setenv("GITHUB_TOKEN", "ghp_teste")
+	var release Release
+	if err := getGitHubJSON(ts.URL, &release); err != nil {
+		t.Fatalf("erro na consulta: %v", err)
+	}
+	if auth != "Bearer ghp_teste" || release.TagName != "v1.0.0" {
+		t.Errorf("token não enviado (Authorization=%q)", auth)
+	}
+}
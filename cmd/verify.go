@@ -0,0 +1,225 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// updatePublicKey é a chave pública ed25519 (base64, 32 bytes ou DER PKIX)
+// usada para verificar a assinatura das atualizações. É definida no build:
+//
+//	-ldflags "-X github.com/tstest3213/00cli/cmd.updatePublicKey=<base64>"
+//
+// Vazia desativa a verificação de assinatura.
+var updatePublicKey = ""
+
+const (
+	checksumsAssetName = "checksums.txt"
+	signatureSuffix    = ".sig"
+	maxMetadataSize    = 1 << 20 // limite para checksums.txt e assinaturas
+)
+
+// verifyDownload confere o arquivo baixado contra os checksums publicados
+// na release e, se houver chave pública embutida, contra a assinatura
+// ed25519 do asset. Qualquer divergência retorna erro.
+func verifyDownload(release *Release, asset *ReleaseAsset, file string) error {
+	sum, err := sha256File(file)
+	if err != nil {
+		return fmt.Errorf("erro ao calcular checksum: %w", err)
+	}
+
+	expected, err := expectedChecksums(release, asset)
+	if err != nil {
+		return err
+	}
+
+	for source, want := range expected {
+		if !strings.EqualFold(want, sum) {
+			return fmt.Errorf("checksum SHA-256 divergente (%s)\n   esperado: %s\n   obtido:   %s", source, want, sum)
+		}
+	}
+	if len(expected) > 0 {
+		fmt.Printf("   ✅ SHA-256 conferido: %s\n", sum)
+	} else {
+		fmt.Println("   ⚠️  A release não publica checksum para este binário; integridade não verificada")
+	}
+
+	if updatePublicKey == "" {
+		return nil
+	}
+
+	pub, err := parseUpdatePublicKey(updatePublicKey)
+	if err != nil {
+		return err
+	}
+	if err := verifySignature(release, asset, file, pub); err != nil {
+		return err
+	}
+	fmt.Println("   ✅ Assinatura ed25519 válida")
+	return nil
+}
+
+// expectedChecksums reúne os checksums publicados para o asset, indexados
+// pela origem (metadados do asset ou checksums.txt)
+func expectedChecksums(release *Release, asset *ReleaseAsset) (map[string]string, error) {
+	expected := make(map[string]string)
+
+	if asset.SHA256 != "" {
+		expected["sha256 do asset"] = asset.SHA256
+	}
+	if algo, digest, ok := strings.Cut(asset.Digest, ":"); ok && algo == "sha256" {
+		expected["digest do asset"] = digest
+	}
+
+	if checksums := findChecksumsAsset(release); checksums != nil {
+		data, err := fetchSmall(checksums.BrowserDownloadURL)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao baixar %s: %w", checksums.Name, err)
+		}
+		sum, ok := parseChecksums(data)[asset.Name]
+		if !ok {
+			return nil, fmt.Errorf("%s não contém %s", checksums.Name, asset.Name)
+		}
+		expected[checksums.Name] = sum
+	}
+
+	return expected, nil
+}
+
+// findChecksumsAsset procura o arquivo de checksums da release
+// ("checksums.txt" ou "<projeto>_<versão>_checksums.txt")
+func findChecksumsAsset(release *Release) *ReleaseAsset {
+	for i, asset := range release.Assets {
+		if asset.Name == checksumsAssetName || strings.HasSuffix(asset.Name, "_"+checksumsAssetName) {
+			return &release.Assets[i]
+		}
+	}
+	return nil
+}
+
+// parseChecksums lê o formato do sha256sum ("<hex>  <nome>" ou "<hex> *<nome>")
+func parseChecksums(data []byte) map[string]string {
+	sums := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		sums[strings.TrimPrefix(fields[1], "*")] = strings.ToLower(fields[0])
+	}
+	return sums
+}
+
+// verifySignature baixa <asset>.sig e valida a assinatura do arquivo
+func verifySignature(release *Release, asset *ReleaseAsset, file string, pub ed25519.PublicKey) error {
+	sigAsset := findAsset(release, asset.Name+signatureSuffix)
+	if sigAsset == nil {
+		return fmt.Errorf("assinatura %s%s não encontrada na release", asset.Name, signatureSuffix)
+	}
+
+	data, err := fetchSmall(sigAsset.BrowserDownloadURL)
+	if err != nil {
+		return fmt.Errorf("erro ao baixar assinatura: %w", err)
+	}
+	sig, err := decodeSignature(data)
+	if err != nil {
+		return err
+	}
+
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("erro ao ler binário baixado: %w", err)
+	}
+	if !ed25519.Verify(pub, content, sig) {
+		return fmt.Errorf("assinatura ed25519 inválida para %s", asset.Name)
+	}
+	return nil
+}
+
+// parseUpdatePublicKey aceita a chave em base64, crua (32 bytes) ou DER PKIX
+// (saída de "openssl pkey -pubout -outform DER")
+func parseUpdatePublicKey(encoded string) (ed25519.PublicKey, error) {
+	der, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("chave pública de atualização inválida: %w", err)
+	}
+	if len(der) == ed25519.PublicKeySize {
+		return ed25519.PublicKey(der), nil
+	}
+
+	key, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("chave pública de atualização inválida: %w", err)
+	}
+	pub, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("chave pública de atualização não é ed25519")
+	}
+	return pub, nil
+}
+
+// decodeSignature aceita a assinatura crua (64 bytes) ou em base64
+func decodeSignature(data []byte) ([]byte, error) {
+	if len(data) == ed25519.SignatureSize {
+		return data, nil
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return nil, fmt.Errorf("formato de assinatura inválido")
+	}
+	return sig, nil
+}
+
+// fetchSmall baixa um arquivo pequeno de metadados (checksums, assinatura)
+func fetchSmall(url string) ([]byte, error) {
+	client, err := newHTTPClient(10 * time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status code: %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxMetadataSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxMetadataSize {
+		return nil, fmt.Errorf("arquivo maior que %d bytes", maxMetadataSize)
+	}
+	return data, nil
+}
+
+// sha256File calcula o sha256 de um arquivo local
+func sha256File(file string) (string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -1,10 +1,8 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
-	"os"
-	"os/exec"
-	"strings"
 
 	"github.com/spf13/cobra"
 )
@@ -12,48 +10,48 @@ import (
 var versionCmd = &cobra.Command{
 	Use:   "version",
 	Short: "Mostra a versão do 00cli",
-	Long:  `Mostra a versão atual do 00cli instalada.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		version := getVersion()
-		fmt.Printf("00cli version %s\n", version)
-	},
+	Long: `Mostra a versão atual do 00cli instalada, com commit, data de build e
+versão do Go. Use --short para apenas a versão ou --json para saída estruturada.`,
+	RunE: runVersion,
 }
 
+var (
+	versionJSON  bool
+	versionShort bool
+)
+
 func init() {
+	versionCmd.Flags().BoolVar(&versionJSON, "json", false, "Saída em JSON")
+	versionCmd.Flags().BoolVar(&versionShort, "short", false, "Mostra apenas a versão")
 	rootCmd.AddCommand(versionCmd)
 }
 
-// getVersion obtém a versão do binário
-func getVersion() string {
-	// Tentar ler da variável de ambiente (setada no build)
-	if v := os.Getenv("00CLI_VERSION"); v != "" {
-		return v
-	}
-
-	// Tentar executar o binário com --version
-	exe, err := os.Executable()
-	if err != nil {
-		return "v0.0.0"
-	}
+func runVersion(cmd *cobra.Command, args []string) error {
+	info := currentBuildInfo()
 
-	cmd := exec.Command(exe, "version")
-	output, err := cmd.Output()
-	if err != nil {
-		return "v0.0.0"
-	}
-
-	// Extrair versão da saída
-	lines := strings.Split(string(output), "\n")
-	for _, line := range lines {
-		if strings.Contains(line, "version") {
-			parts := strings.Fields(line)
-			for _, part := range parts {
-				if strings.HasPrefix(part, "v") {
-					return part
-				}
+	switch {
+	case versionJSON:
+		data, err := json.MarshalIndent(info, "", "  ")
+		if err != nil {
+			return fmt.Errorf("erro ao gerar JSON: %w", err)
+		}
+		fmt.Println(string(data))
+	case versionShort:
+		fmt.Println(info.Version)
+	default:
+		fmt.Printf("00cli version %s\n", info.Version)
+		if info.Commit != "" {
+			commit := info.Commit
+			if info.Dirty {
+				commit += " (modificado)"
 			}
+			fmt.Printf("   Commit: %s\n", commit)
+		}
+		if info.Date != "" {
+			fmt.Printf("   Build: %s\n", info.Date)
 		}
+		fmt.Printf("   Go: %s %s\n", info.GoVersion, info.Platform)
 	}
 
-	return "v0.0.0"
+	return nil
 }
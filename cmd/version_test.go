@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"runtime/debug"
+	"testing"
+)
+
+func TestResolveBuildInfo(t *testing.T) {
+	info := &debug.BuildInfo{
+		GoVersion: "go1.21.5",
+		Main:      debug.Module{Version: "v0.4.0"},
+		Settings: []debug.BuildSetting{
+			{Key: "vcs.revision", Value: "abc1234"},
+			{Key: "vcs.time", Value: "2024-01-15T10:00:00Z"},
+			{Key: "vcs.modified", Value: "true"},
+		},
+	}
+
+	// go install: tudo vem dos metadados do módulo
+	b := resolveBuildInfo("", "", "", info)
+	if b.Version != "v0.4.0" || b.Commit != "abc1234" || b.Date != "2024-01-15T10:00:00Z" || !b.Dirty || b.GoVersion != "go1.21.5" {
+		t.Errorf("fallback para ReadBuildInfo incorreto: %+v", b)
+	}
+
+	// -ldflags tem precedência
+	b = resolveBuildInfo("v1.0.0", "def5678", "2024-02-01T00:00:00Z", info)
+	if b.Version != "v1.0.0" || b.Commit != "def5678" || b.Date != "2024-02-01T00:00:00Z" || b.Dirty {
+		t.Errorf("valores do -ldflags não respeitados: %+v", b)
+	}
+
+	// Build local sem versão
+	info.Main.Version = "(devel)"
+	if b := resolveBuildInfo("", "", "", info); b.Version != defaultVersion {
+		t.Errorf("esperado %s para build sem versão, obtido %s", defaultVersion, b.Version)
+	}
+}
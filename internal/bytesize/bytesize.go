@@ -0,0 +1,21 @@
+// Package bytesize formata tamanhos de arquivos e transferências para a
+// saída do deploy e do updater.
+package bytesize
+
+import "fmt"
+
+// Format formata um tamanho em B, KB, MB ou GB
+func Format(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	value := float64(n)
+	for _, suffix := range []string{"KB", "MB", "GB"} {
+		value /= unit
+		if value < unit || suffix == "GB" {
+			return fmt.Sprintf("%.1f %s", value, suffix)
+		}
+	}
+	return ""
+}
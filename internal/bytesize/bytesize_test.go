@@ -0,0 +1,18 @@
+package bytesize
+
+import "testing"
+
+func TestFormat(t *testing.T) {
+	tests := map[int64]string{
+		0:               "0 B",
+		1023:            "1023 B",
+		1536:            "1.5 KB",
+		5 * 1024 * 1024: "5.0 MB",
+		3 << 40:         "3072.0 GB",
+	}
+	for n, want := range tests {
+		if got := Format(n); got != want {
+			t.Errorf("Format(%d) = %q, esperado %q", n, got, want)
+		}
+	}
+}
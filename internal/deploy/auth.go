@@ -0,0 +1,357 @@
+package deploy
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/tstest3213/00cli/internal/tty"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/term"
+)
+
+// SSHConfigNone desativa a leitura do ssh_config
+const SSHConfigNone = "none"
+
+// defaultIdentityFiles são as chaves tentadas quando nenhuma é configurada,
+// na mesma ordem do OpenSSH
+var defaultIdentityFiles = []string{"id_ed25519", "id_ecdsa", "id_rsa"}
+
+// signerCache guarda as chaves já decifradas, para não pedir a senha de
+// novo a cada conexão (deploy multi-host, releases, docker remoto)
+var signerCache = struct {
+	sync.Mutex
+	signers map[string]ssh.Signer
+}{signers: make(map[string]ssh.Signer)}
+
+// sshTarget é o destino de uma conexão depois de resolver o alias no ssh_config
+type sshTarget struct {
+	Alias          string
+	Host           string
+	Port           int
+	User           string
+	Identities     []identityFile
+	IdentitiesOnly bool
+	AgentSocket    string
+	ProxyJump      string
+}
+
+// identityFile é uma chave privada; chaves configuradas explicitamente
+// precisam existir, as do ssh_config e as padrão são ignoradas se ausentes
+type identityFile struct {
+	Path     string
+	Explicit bool
+}
+
+// resolveTarget aplica o ssh_config ao host configurado. Valores do
+// settings.json têm precedência sobre os do ssh_config.
+func (d *SSHDeployer) resolveTarget() (*sshTarget, error) {
+	file := d.SSHConfig
+	if file == "" {
+		file = DefaultSSHConfigFile()
+	}
+	hostConfig := &sshHostConfig{}
+	if file != SSHConfigNone {
+		var err error
+		if hostConfig, err = loadSSHConfig(file, d.Host); err != nil {
+			return nil, err
+		}
+	}
+
+	target := &sshTarget{
+		Alias:          d.Host,
+		Host:           d.Host,
+		Port:           d.Port,
+		User:           d.User,
+		IdentitiesOnly: hostConfig.IdentitiesOnly,
+		ProxyJump:      hostConfig.ProxyJump,
+	}
+	if hostConfig.HostName != "" {
+		target.Host = strings.ReplaceAll(hostConfig.HostName, "%h", d.Host)
+	}
+	if target.Port == 0 {
+		target.Port = hostConfig.Port
+	}
+	if target.Port == 0 {
+		target.Port = 22
+	}
+	if target.User == "" {
+		target.User = hostConfig.User
+	}
+	if target.User == "" {
+		if current, err := user.Current(); err == nil {
+			target.User = current.Username
+		}
+	}
+
+	if d.SSHKey != "" {
+		target.Identities = append(target.Identities, identityFile{Path: expandSSHPath(d.SSHKey, target.Host, target.User), Explicit: true})
+	}
+	for _, path := range d.IdentityFiles {
+		target.Identities = append(target.Identities, identityFile{Path: expandSSHPath(path, target.Host, target.User), Explicit: true})
+	}
+	for _, path := range hostConfig.IdentityFiles {
+		target.Identities = append(target.Identities, identityFile{Path: path})
+	}
+	if len(target.Identities) == 0 {
+		if home, err := os.UserHomeDir(); err == nil {
+			for _, name := range defaultIdentityFiles {
+				target.Identities = append(target.Identities, identityFile{Path: filepath.Join(home, ".ssh", name)})
+			}
+		}
+	}
+
+	switch agentSocket := hostConfig.IdentityAgent; agentSocket {
+	case SSHConfigNone:
+	case "", "SSH_AUTH_SOCK":
+		target.AgentSocket = os.Getenv("SSH_AUTH_SOCK")
+	default:
+		target.AgentSocket = os.ExpandEnv(agentSocket)
+	}
+
+	return target, nil
+}
+
+// authMethods monta os métodos de autenticação na ordem em que são tentados:
+// chaves (arquivos de identidade em ordem e depois as do agente), senha e
+// keyboard-interactive. A função retornada fecha a conexão com o agente e
+// deve ser chamada depois do handshake.
+func (d *SSHDeployer) authMethods(target *sshTarget) ([]ssh.AuthMethod, func(), error) {
+	closeAgent := func() {}
+	var agentSigners []ssh.Signer
+	if target.AgentSocket != "" {
+		conn, err := net.Dial("unix", target.AgentSocket)
+		if err != nil {
+			d.warnf("⚠️  Agente SSH indisponível (%s): %v\n", target.AgentSocket, err)
+		} else {
+			closeAgent = func() { conn.Close() }
+			if agentSigners, err = agent.NewClient(conn).Signers(); err != nil {
+				d.warnf("⚠️  Erro ao listar chaves do agente SSH: %v\n", err)
+			}
+		}
+	}
+
+	signers, err := d.identitySigners(target, agentSigners)
+	if err != nil {
+		closeAgent()
+		return nil, nil, err
+	}
+
+	var methods []ssh.AuthMethod
+	if len(signers) > 0 {
+		methods = append(methods, ssh.PublicKeys(signers...))
+	}
+	if d.Password != "" {
+		methods = append(methods, ssh.Password(d.Password))
+	}
+	if d.KeyboardInteractive != nil || d.Password != "" || tty.IsTerminal(os.Stdin) {
+		methods = append(methods, ssh.KeyboardInteractive(d.keyboardInteractive()))
+	}
+
+	if len(methods) == 0 {
+		closeAgent()
+		return nil, nil, fmt.Errorf("nenhuma forma de autenticação configurada (ssh_key, identity_files, agente SSH ou password)")
+	}
+	return methods, closeAgent, nil
+}
+
+// identitySigners carrega os arquivos de identidade em ordem. Uma chave
+// presente no agente (reconhecida pelo .pub ao lado) é usada pelo agente,
+// sem pedir senha. As demais chaves do agente vêm depois, exceto com
+// IdentitiesOnly.
+func (d *SSHDeployer) identitySigners(target *sshTarget, agentSigners []ssh.Signer) ([]ssh.Signer, error) {
+	var signers []ssh.Signer
+	used := make(map[string]bool)
+	add := func(signer ssh.Signer) {
+		key := string(signer.PublicKey().Marshal())
+		if !used[key] {
+			used[key] = true
+			signers = append(signers, signer)
+		}
+	}
+
+	for _, identity := range target.Identities {
+		if signer := agentSignerFor(identity.Path, agentSigners); signer != nil {
+			add(signer)
+			continue
+		}
+
+		signer, err := d.loadSigner(identity.Path)
+		switch {
+		case err == nil:
+			add(signer)
+		case errors.Is(err, os.ErrNotExist) && !identity.Explicit:
+		case errors.Is(err, errNotInteractive):
+			d.warnf("⚠️  Chave SSH %s ignorada: protegida por senha e sem terminal (defina 00CLI_SSH_PASSPHRASE)\n", identity.Path)
+		case identity.Explicit:
+			return nil, err
+		default:
+			d.warnf("⚠️  Chave SSH %s ignorada: %v\n", identity.Path, err)
+		}
+	}
+
+	if !target.IdentitiesOnly {
+		for _, signer := range agentSigners {
+			add(signer)
+		}
+	}
+	return signers, nil
+}
+
+// agentSignerFor retorna o signer do agente para a chave cujo .pub está ao
+// lado do arquivo de identidade
+func agentSignerFor(keyFile string, agentSigners []ssh.Signer) ssh.Signer {
+	if len(agentSigners) == 0 {
+		return nil
+	}
+	data, err := os.ReadFile(keyFile + ".pub")
+	if err != nil {
+		return nil
+	}
+	pub, _, _, _, err := ssh.ParseAuthorizedKey(data)
+	if err != nil {
+		return nil
+	}
+	for _, signer := range agentSigners {
+		if bytes.Equal(signer.PublicKey().Marshal(), pub.Marshal()) {
+			return signer
+		}
+	}
+	return nil
+}
+
+// loadSigner lê uma chave privada, pedindo a senha se ela for cifrada
+func (d *SSHDeployer) loadSigner(keyFile string) (ssh.Signer, error) {
+	signerCache.Lock()
+	defer signerCache.Unlock()
+
+	if signer, ok := signerCache.signers[keyFile]; ok {
+		return signer, nil
+	}
+
+	key, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler chave SSH: %w", err)
+	}
+
+	signer, err := ssh.ParsePrivateKey(key)
+	var missing *ssh.PassphraseMissingError
+	if errors.As(err, &missing) {
+		passphrase, perr := d.passphrase(keyFile)
+		if perr != nil {
+			return nil, perr
+		}
+		signer, err = ssh.ParsePrivateKeyWithPassphrase(key, passphrase)
+		if errors.Is(err, x509.IncorrectPasswordError) {
+			return nil, fmt.Errorf("senha incorreta para a chave SSH %s", keyFile)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao parsear chave SSH %s: %w", keyFile, err)
+	}
+
+	signerCache.signers[keyFile] = signer
+	return signer, nil
+}
+
+func (d *SSHDeployer) passphrase(keyFile string) ([]byte, error) {
+	if d.Passphrase != nil {
+		return d.Passphrase(keyFile)
+	}
+	return passphraseFromEnvOrTerminal(keyFile)
+}
+
+// passphraseFromEnvOrTerminal obtém a senha da chave de 00CLI_SSH_PASSPHRASE
+// ou, em um terminal, perguntando sem eco
+func passphraseFromEnvOrTerminal(keyFile string) ([]byte, error) {
+	if passphrase, ok := os.LookupEnv("00CLI_SSH_PASSPHRASE"); ok {
+		return []byte(passphrase), nil
+	}
+	return readSecret(fmt.Sprintf("🔑 Senha da chave %s: ", keyFile))
+}
+
+// keyboardInteractive responde aos desafios do servidor. Um único pedido
+// sem eco (a senha, no PAM) é respondido com password; os demais são
+// perguntados no terminal.
+func (d *SSHDeployer) keyboardInteractive() ssh.KeyboardInteractiveChallenge {
+	if d.KeyboardInteractive != nil {
+		return d.KeyboardInteractive
+	}
+
+	return func(name, instruction string, questions []string, echos []bool) ([]string, error) {
+		answers := make([]string, len(questions))
+		if len(questions) == 0 {
+			return answers, nil
+		}
+		if d.Password != "" && len(questions) == 1 && !echos[0] {
+			answers[0] = d.Password
+			return answers, nil
+		}
+		if !tty.IsTerminal(os.Stdin) {
+			return nil, errNotInteractive
+		}
+
+		if name != "" || instruction != "" {
+			promptMu.Lock()
+			for _, line := range []string{name, instruction} {
+				if line != "" {
+					fmt.Println(line)
+				}
+			}
+			promptMu.Unlock()
+		}
+
+		for i, question := range questions {
+			if !echos[i] {
+				answer, err := readSecret(question)
+				if err != nil {
+					return nil, err
+				}
+				answers[i] = string(answer)
+				continue
+			}
+
+			promptMu.Lock()
+			fmt.Print(question)
+			line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+			promptMu.Unlock()
+			if err != nil {
+				return nil, fmt.Errorf("erro ao ler resposta: %w", err)
+			}
+			answers[i] = strings.TrimRight(line, "\r\n")
+		}
+		return answers, nil
+	}
+}
+
+// readSecret pergunta no terminal sem exibir o que é digitado
+func readSecret(prompt string) ([]byte, error) {
+	if !tty.IsTerminal(os.Stdin) {
+		return nil, errNotInteractive
+	}
+
+	promptMu.Lock()
+	defer promptMu.Unlock()
+
+	fmt.Print(prompt)
+	secret, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Println()
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler senha: %w", err)
+	}
+	return secret, nil
+}
+
+// warnf escreve avisos de autenticação na saída de erro do deploy
+func (d *SSHDeployer) warnf(format string, args ...interface{}) {
+	fmt.Fprintf(d.stderr(), format, args...)
+}
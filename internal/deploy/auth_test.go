@@ -0,0 +1,359 @@
+package deploy
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/tstest3213/00cli/internal/tty"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+func TestLoadSSHConfig(t *testing.T) {
+	dir := t.TempDir()
+	included := filepath.Join(dir, "extra.conf")
+	if err := os.WriteFile(included, []byte("Host web\n  IdentityFile ~/.ssh/extra\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	config := filepath.Join(dir, "config")
+	content := `# comentário
+Include extra.conf
+
+Host web prod-*
+	HostName	10.0.0.5
+	User=deploy
+	Port 2222
+	IdentityFile "~/.ssh/id %h"
+
+Host !web *
+	User ninguem
+
+Host *
+	Port 22
+	IdentitiesOnly yes
+	IdentityFile ~/.ssh/id_ed25519
+`
+	if err := os.WriteFile(config, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	home, _ := os.UserHomeDir()
+	got, err := loadSSHConfig(config, "web")
+	if err != nil {
+		t.Fatalf("erro ao ler ssh_config: %v", err)
+	}
+	if got.HostName != "10.0.0.5" || got.User != "deploy" || got.Port != 2222 || !got.IdentitiesOnly {
+		t.Errorf("opções inesperadas: %+v", got)
+	}
+	want := []string{
+		filepath.Join(home, ".ssh", "extra"),
+		filepath.Join(home, ".ssh", "id 10.0.0.5"),
+		filepath.Join(home, ".ssh", "id_ed25519"),
+	}
+	if fmt.Sprint(got.IdentityFiles) != fmt.Sprint(want) {
+		t.Errorf("IdentityFile: esperado %v, obtido %v", want, got.IdentityFiles)
+	}
+
+	other, err := loadSSHConfig(config, "db")
+	if err != nil {
+		t.Fatalf("erro ao ler ssh_config: %v", err)
+	}
+	if other.HostName != "" || other.User != "ninguem" || other.Port != 22 {
+		t.Errorf("opções inesperadas para host sem alias: %+v", other)
+	}
+
+	missing, err := loadSSHConfig(filepath.Join(dir, "ausente"), "web")
+	if err != nil || missing.HostName != "" {
+		t.Errorf("ssh_config ausente deveria ser ignorado: %+v, %v", missing, err)
+	}
+}
+
+func TestLoadSSHConfigNestedInclude(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "conf.d"), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	// O Include dentro de conf.d/web é relativo ao diretório do config
+	// principal, não a conf.d
+	files := map[string]string{
+		"config":           "Include conf.d/web\n",
+		"conf.d/web":       "Include hosts/web\n",
+		"hosts/web":        "Host web\n  HostName 10.0.0.7\n",
+		"conf.d/hosts/web": "Host web\n  HostName 10.9.9.9\n",
+	}
+	for name, content := range files {
+		file := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := loadSSHConfig(filepath.Join(dir, "config"), "web")
+	if err != nil {
+		t.Fatalf("erro ao ler ssh_config: %v", err)
+	}
+	if got.HostName != "10.0.0.7" {
+		t.Errorf("Include aninhado resolvido fora do diretório do config: HostName %q", got.HostName)
+	}
+}
+
+func TestSSHDeployerResolvesAlias(t *testing.T) {
+	users := make(chan string, 1)
+	srv := newTestSSHServer(t, func(config *ssh.ServerConfig) {
+		config.PasswordCallback = func(c ssh.ConnMetadata, pass []byte) (*ssh.Permissions, error) {
+			users <- c.User()
+			return nil, nil
+		}
+	})
+
+	config := filepath.Join(t.TempDir(), "config")
+	content := fmt.Sprintf("Host meuservidor\n  HostName %s\n  Port %d\n  User ops\n", srv.Host, srv.Port)
+	if err := os.WriteFile(config, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	deployer := srv.Deployer()
+	deployer.Host = "meuservidor"
+	deployer.Port = 0
+	deployer.User = ""
+	deployer.SSHConfig = config
+
+	if err := deployer.Execute([]string{"true"}); err != nil {
+		t.Fatalf("erro ao conectar pelo alias: %v", err)
+	}
+	if gotUser := <-users; gotUser != "ops" {
+		t.Errorf("esperado usuário do ssh_config %q, obtido %q", "ops", gotUser)
+	}
+}
+
+func TestSSHDeployerIdentityFilesInOrder(t *testing.T) {
+	dir := t.TempDir()
+	wrongKey := writeTestKey(t, filepath.Join(dir, "id_wrong"), nil)
+	rightKey := writeTestKey(t, filepath.Join(dir, "id_right"), []byte("frase secreta"))
+
+	tried := &offeredKeys{}
+	srv := newTestSSHServer(t, acceptKey(rightKey.PublicKey(), tried))
+
+	deployer := srv.Deployer()
+	deployer.Password = ""
+	deployer.SSHKey = filepath.Join(dir, "id_wrong")
+	deployer.IdentityFiles = []string{filepath.Join(dir, "id_right")}
+
+	t.Setenv("00CLI_SSH_PASSPHRASE", "frase secreta")
+	if err := deployer.Execute([]string{"true"}); err != nil {
+		t.Fatalf("erro ao autenticar com a segunda chave: %v", err)
+	}
+
+	offered := tried.Keys()
+	if len(offered) < 2 || !bytes.Equal(offered[0], wrongKey.PublicKey().Marshal()) {
+		t.Errorf("chaves deveriam ser tentadas na ordem configurada (%d tentativas)", len(offered))
+	}
+}
+
+func TestSSHDeployerEncryptedKeyPassphrase(t *testing.T) {
+	dir := t.TempDir()
+	key := writeTestKey(t, filepath.Join(dir, "id_ed25519"), []byte("frase secreta"))
+	srv := newTestSSHServer(t, acceptKey(key.PublicKey(), nil))
+
+	deployer := srv.Deployer()
+	deployer.Password = ""
+	deployer.SSHKey = filepath.Join(dir, "id_ed25519")
+
+	// Senha errada é um erro, não uma chave ignorada
+	deployer.Passphrase = func(keyFile string) ([]byte, error) { return []byte("errada"), nil }
+	if err := deployer.Execute([]string{"true"}); err == nil {
+		t.Fatal("esperado erro com senha incorreta")
+	}
+
+	asked := 0
+	deployer.Passphrase = func(keyFile string) ([]byte, error) {
+		asked++
+		return []byte("frase secreta"), nil
+	}
+	for i := 0; i < 2; i++ {
+		if err := deployer.Execute([]string{"true"}); err != nil {
+			t.Fatalf("erro ao autenticar com chave cifrada: %v", err)
+		}
+		deployer.Close()
+	}
+	if asked != 1 {
+		t.Errorf("a senha da chave deveria ser pedida uma vez, foi pedida %d", asked)
+	}
+}
+
+func TestSSHDeployerAgent(t *testing.T) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := newTestSSHServer(t, acceptKey(signer.PublicKey(), nil))
+
+	keyring := agent.NewKeyring()
+	if err := keyring.Add(agent.AddedKey{PrivateKey: priv}); err != nil {
+		t.Fatal(err)
+	}
+	socket := filepath.Join(t.TempDir(), "agent.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Skipf("socket unix indisponível: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				agent.ServeAgent(keyring, conn)
+				conn.Close()
+			}()
+		}
+	}()
+	t.Setenv("SSH_AUTH_SOCK", socket)
+
+	deployer := srv.Deployer()
+	deployer.Password = ""
+	deployer.SSHConfig = SSHConfigNone
+	if err := deployer.Execute([]string{"true"}); err != nil {
+		t.Fatalf("erro ao autenticar pelo agente: %v", err)
+	}
+	deployer.Close()
+
+	// Com IdentitiesOnly, apenas as chaves configuradas são oferecidas
+	config := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(config, []byte("IdentitiesOnly yes\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	deployer.SSHConfig = config
+	if err := deployer.Execute([]string{"true"}); err == nil {
+		t.Error("IdentitiesOnly não deveria usar as chaves do agente")
+	}
+}
+
+func TestSSHDeployerKeyboardInteractive(t *testing.T) {
+	srv := newTestSSHServer(t, func(config *ssh.ServerConfig) {
+		config.PasswordCallback = nil
+		config.KeyboardInteractiveCallback = func(c ssh.ConnMetadata, client ssh.KeyboardInteractiveChallenge) (*ssh.Permissions, error) {
+			answers, err := client("", "", []string{"Password: "}, []bool{false})
+			if err != nil {
+				return nil, err
+			}
+			if len(answers) != 1 || answers[0] != "secret" {
+				return nil, errors.New("senha incorreta")
+			}
+
+			answers, err = client("", "Verificação em duas etapas", []string{"Código: "}, []bool{true})
+			if err != nil {
+				return nil, err
+			}
+			if len(answers) != 1 || answers[0] != "123456" {
+				return nil, errors.New("código incorreto")
+			}
+			return nil, nil
+		}
+	})
+
+	// O pedido de senha é respondido com password; o código vem do desafio configurado
+	deployer := srv.Deployer()
+	deployer.KeyboardInteractive = func(name, instruction string, questions []string, echos []bool) ([]string, error) {
+		if len(questions) == 1 && !echos[0] {
+			return []string{deployer.Password}, nil
+		}
+		return []string{"123456"}, nil
+	}
+	if err := deployer.Execute([]string{"true"}); err != nil {
+		t.Fatalf("erro na autenticação keyboard-interactive: %v", err)
+	}
+	deployer.Close()
+
+	// Sem terminal, o desafio padrão responde apenas o pedido de senha
+	if tty.IsTerminal(os.Stdin) {
+		t.Skip("stdin é um terminal")
+	}
+	deployer.KeyboardInteractive = nil
+	if err := deployer.Execute([]string{"true"}); err == nil {
+		t.Error("esperado erro sem terminal para responder o código")
+	}
+}
+
+// writeTestKey grava uma chave ed25519 (cifrada se passphrase não for nil)
+// e o .pub ao lado
+func writeTestKey(t *testing.T, path string, passphrase []byte) ssh.Signer {
+	t.Helper()
+
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var block *pem.Block
+	if passphrase != nil {
+		block, err = ssh.MarshalPrivateKeyWithPassphrase(priv, "", passphrase)
+	} else {
+		block, err = ssh.MarshalPrivateKey(priv, "")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, pem.EncodeToMemory(block), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	signer, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path+".pub", ssh.MarshalAuthorizedKey(signer.PublicKey()), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return signer
+}
+
+// offeredKeys registra as chaves oferecidas pelo cliente ao servidor
+type offeredKeys struct {
+	mu   sync.Mutex
+	keys [][]byte
+}
+
+func (o *offeredKeys) Keys() [][]byte {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return append([][]byte(nil), o.keys...)
+}
+
+// acceptKey configura o servidor para aceitar apenas a chave informada,
+// registrando as chaves oferecidas em tried (opcional)
+func acceptKey(key ssh.PublicKey, tried *offeredKeys) func(*ssh.ServerConfig) {
+	return func(config *ssh.ServerConfig) {
+		config.PasswordCallback = nil
+		config.PublicKeyCallback = func(c ssh.ConnMetadata, offered ssh.PublicKey) (*ssh.Permissions, error) {
+			if tried != nil {
+				tried.mu.Lock()
+				tried.keys = append(tried.keys, offered.Marshal())
+				tried.mu.Unlock()
+			}
+			if bytes.Equal(offered.Marshal(), key.Marshal()) {
+				return nil, nil
+			}
+			return nil, errors.New("chave não autorizada")
+		}
+	}
+}
@@ -0,0 +1,333 @@
+package deploy
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tstest3213/00cli/internal/bytesize"
+)
+
+// buildCacheKeep é a quantidade de artefatos mantidos no cache local
+const buildCacheKeep = 5
+
+// Build descreve a etapa de build local: os comandos rodam na raiz do
+// projeto, com as variáveis do deploy, e geram um artefato (diretório ou
+// .tar.gz) que é enviado e extraído no servidor antes dos comandos remotos
+type Build struct {
+	Commands    []string // executados em ordem no shell local (sh ou cmd)
+	Output      string   // diretório ou .tar.gz gerado, relativo a ProjectPath (vazio: sem artefato)
+	Target      string   // destino remoto; com Releases, relativo é dentro da release
+	ProjectPath string
+	CacheDir    string // artefatos por commit (vazio: sem cache)
+	Force       bool   // ignora o cache e refaz o build
+}
+
+// buildArtifact é o pacote gerado pelo build
+type buildArtifact struct {
+	Path string // arquivo .tar.gz local (vazio: build sem artefato)
+	Hash string // sha256 do pacote
+	Size int64
+	temp bool // fora do cache; removido no fim do deploy
+}
+
+// prepareArtifact executa o build local, ou reaproveita o artefato do mesmo
+// commit no cache
+func (d *SSHDeployer) prepareArtifact() (*buildArtifact, error) {
+	b := d.Build
+	key, cacheable := b.cacheKey(d.Environment)
+	if cacheable && !b.Force && b.Output != "" {
+		cached := filepath.Join(b.CacheDir, key+".tar.gz")
+		if _, err := os.Stat(cached); err == nil {
+			artifact, err := newBuildArtifact(cached, false)
+			if err != nil {
+				return nil, err
+			}
+			now := time.Now()
+			os.Chtimes(cached, now, now)
+			fmt.Fprintf(d.stdout(), "📦 Artefato em cache para o commit %s (%s)\n", key[:12], artifact.Hash[:12])
+			return artifact, nil
+		}
+	}
+	if !cacheable && d.Verbose && b.CacheDir != "" {
+		fmt.Fprintln(d.stdout(), "⚠️  Alterações não commitadas ou projeto fora do Git: build sem cache")
+	}
+
+	fmt.Fprintln(d.stdout(), "🔨 Executando build local...")
+	// Mesma execução dos estágios locais do pipeline
+	local := localStage{
+		Dir:    b.ProjectPath,
+		Env:    d.Environment,
+		Stdout: d.stdout(),
+		Stderr: d.stderr(),
+	}
+	if err := local.run(context.Background(), b.Commands); err != nil {
+		return nil, fmt.Errorf("erro no build: %w", err)
+	}
+
+	if b.Output == "" {
+		return &buildArtifact{}, nil
+	}
+	return b.pack(key, cacheable)
+}
+
+// pack empacota a saída do build. Com cache, o artefato é guardado com o
+// nome do commit e os mais antigos são removidos.
+func (b *Build) pack(key string, cacheable bool) (*buildArtifact, error) {
+	output := b.Output
+	if !filepath.IsAbs(output) {
+		output = filepath.Join(b.ProjectPath, output)
+	}
+	info, err := os.Stat(output)
+	if err != nil {
+		return nil, fmt.Errorf("artefato do build não encontrado: %s", b.Output)
+	}
+	if !info.IsDir() && !isTarGz(output) {
+		return nil, fmt.Errorf("artefato do build deve ser um diretório ou .tar.gz: %s", b.Output)
+	}
+
+	dir := b.CacheDir
+	if !cacheable || dir == "" {
+		dir = os.TempDir()
+	} else if err := ensureCacheDir(dir); err != nil {
+		return nil, err
+	}
+
+	tmp, err := os.CreateTemp(dir, ".build-*.tar.gz")
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criar artefato: %w", err)
+	}
+	if info.IsDir() {
+		err = packDir(output, tmp)
+	} else {
+		err = copyFile(output, tmp)
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return nil, fmt.Errorf("erro ao empacotar %s: %w", b.Output, err)
+	}
+
+	if !cacheable || b.CacheDir == "" {
+		return newBuildArtifact(tmp.Name(), true)
+	}
+
+	cached := filepath.Join(dir, key+".tar.gz")
+	if err := os.Rename(tmp.Name(), cached); err != nil {
+		os.Remove(tmp.Name())
+		return nil, fmt.Errorf("erro ao gravar artefato no cache: %w", err)
+	}
+	pruneBuildCache(dir, buildCacheKeep)
+	return newBuildArtifact(cached, false)
+}
+
+// cacheKey identifica o build pelo commit e pela configuração (comandos,
+// saída e variáveis). Sem Git ou com alterações não commitadas o build não é
+// reaproveitado.
+func (b *Build) cacheKey(env map[string]string) (string, bool) {
+	if b.CacheDir == "" {
+		return "", false
+	}
+
+	commit, err := gitOutput(b.ProjectPath, "rev-parse", "HEAD")
+	if err != nil || commit == "" {
+		return "", false
+	}
+	status, err := gitOutput(b.ProjectPath, "status", "--porcelain")
+	if err != nil || status != "" {
+		return "", false
+	}
+
+	h := sha256.New()
+	fmt.Fprintf(h, "output=%s\n", b.Output)
+	for _, cmd := range b.Commands {
+		fmt.Fprintf(h, "cmd=%s\n", cmd)
+	}
+	for _, line := range envList(env) {
+		fmt.Fprintf(h, "env=%s\n", line)
+	}
+	return commit + "-" + hex.EncodeToString(h.Sum(nil))[:8], true
+}
+
+// gitOutput executa um comando git no projeto e retorna a saída sem espaços
+func gitOutput(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	output, err := cmd.Output()
+	return strings.TrimSpace(string(output)), err
+}
+
+// localCommand executa cmd no shell do sistema, encerrando-o se ctx for cancelado
+func localCommand(ctx context.Context, cmd string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, "cmd", "/C", cmd)
+	}
+	return exec.CommandContext(ctx, "sh", "-c", cmd)
+}
+
+// ensureCacheDir cria o diretório de cache com um .gitignore próprio, para
+// que os artefatos não deixem o repositório com alterações
+func ensureCacheDir(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("erro ao criar cache de build: %w", err)
+	}
+	ignore := filepath.Join(dir, ".gitignore")
+	if _, err := os.Stat(ignore); os.IsNotExist(err) {
+		return os.WriteFile(ignore, []byte("*\n"), 0644)
+	}
+	return nil
+}
+
+// pruneBuildCache mantém apenas os keep artefatos usados mais recentemente
+func pruneBuildCache(dir string, keep int) {
+	matches, _ := filepath.Glob(filepath.Join(dir, "*.tar.gz"))
+	type entry struct {
+		path  string
+		mtime time.Time
+	}
+	var entries []entry
+	for _, file := range matches {
+		if info, err := os.Stat(file); err == nil {
+			entries = append(entries, entry{file, info.ModTime()})
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].mtime.After(entries[j].mtime) })
+	for i := keep; i < len(entries); i++ {
+		os.Remove(entries[i].path)
+	}
+}
+
+func newBuildArtifact(file string, temp bool) (*buildArtifact, error) {
+	sum, err := fileChecksum(file)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao calcular checksum do artefato: %w", err)
+	}
+	info, err := os.Stat(file)
+	if err != nil {
+		return nil, err
+	}
+	return &buildArtifact{Path: file, Hash: sum, Size: info.Size(), temp: temp}, nil
+}
+
+// isTarGz informa se o arquivo tem extensão de tar compactado com gzip
+func isTarGz(file string) bool {
+	return strings.HasSuffix(file, ".tar.gz") || strings.HasSuffix(file, ".tgz")
+}
+
+// packDir grava o conteúdo de dir como tar.gz, com caminhos relativos e
+// permissões e datas preservadas
+func packDir(dir string, w io.Writer) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	err := filepath.WalkDir(dir, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, file)
+		if err != nil || rel == "." {
+			return err
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		link := ""
+		if info.Mode()&fs.ModeSymlink != 0 {
+			if link, err = os.Readlink(file); err != nil {
+				return err
+			}
+		} else if !info.IsDir() && !info.Mode().IsRegular() {
+			return nil
+		}
+
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+		if info.IsDir() {
+			header.Name += "/"
+		}
+		header.Uid, header.Gid = 0, 0
+		header.Uname, header.Gname = "", ""
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		return copyFile(file, tw)
+	})
+	if err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// copyFile copia o conteúdo de um arquivo local para w
+func copyFile(file string, w io.Writer) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// hasArtifact informa se o build gerou um pacote a ser enviado
+func (d *SSHDeployer) hasArtifact() bool {
+	return d.artifact != nil && d.artifact.Path != ""
+}
+
+// uploadArtifact envia o artefato e o extrai em target no servidor
+func (d *SSHDeployer) uploadArtifact(client sessionOpener, target string) error {
+	a := d.artifact
+	fmt.Fprintf(d.stdout(), "📦 Enviando artefato %s (%s) para %s\n", a.Hash[:12], bytesize.Format(a.Size), target)
+
+	transfer, err := newFileTransfer(client)
+	if err != nil {
+		return err
+	}
+	defer transfer.Close()
+	transfer.Verify = true
+
+	remote := path.Join(target, ".00cli-artifact-"+a.Hash[:12]+".tar.gz")
+	if err := transfer.UploadFile(a.Path, remote, 0600); err != nil {
+		return err
+	}
+
+	script := fmt.Sprintf("cd %s && tar -xzpf %s; status=$?; rm -f %s; exit $status",
+		shellQuote(target), shellQuote(remote), shellQuote(remote))
+	if err := runRemote(client, script); err != nil {
+		return fmt.Errorf("erro ao extrair artefato no servidor: %w", err)
+	}
+	return nil
+}
+
+// discardArtifact remove o artefato temporário (fora do cache) do build
+func (d *SSHDeployer) discardArtifact() {
+	if d.artifact != nil && d.artifact.temp {
+		os.Remove(d.artifact.Path)
+	}
+	d.artifact = nil
+}
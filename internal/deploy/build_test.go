@@ -0,0 +1,228 @@
+package deploy
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newBuildProject cria um repositório Git com dist/ ignorado e um commit
+func newBuildProject(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git não disponível")
+	}
+
+	dir := t.TempDir()
+	files := map[string]string{
+		".gitignore": "dist/\n",
+		"app.txt":    "v1\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"add", "."},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "inicial"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, output)
+		}
+	}
+	return dir
+}
+
+// countingBuild gera dist/ a partir de app.txt e registra cada execução em builds.log
+func countingBuild(project string) *Build {
+	return &Build{
+		Commands: []string{
+			"echo build >> " + filepath.Join(filepath.Dir(project), "builds.log"),
+			"mkdir -p dist/bin && cp app.txt dist/ && echo \"$APP_ENV\" > dist/env.txt",
+			"printf '#!/bin/sh\\n' > dist/bin/start && chmod 755 dist/bin/start",
+		},
+		Output:      "dist",
+		ProjectPath: project,
+		CacheDir:    filepath.Join(project, ".00cli", "cache", "builds"),
+	}
+}
+
+func buildCount(t *testing.T, project string) int {
+	t.Helper()
+	data, _ := os.ReadFile(filepath.Join(filepath.Dir(project), "builds.log"))
+	return strings.Count(string(data), "build")
+}
+
+func TestSSHDeployerBuildReleases(t *testing.T) {
+	srv := newTestSSHServer(t)
+	project := newBuildProject(t)
+	base := filepath.Join(t.TempDir(), "app")
+
+	deployer := srv.Deployer()
+	deployer.Stdout = &bytes.Buffer{}
+	deployer.Environment = map[string]string{"APP_ENV": "production"}
+	deployer.Build = countingBuild(project)
+	deployer.Releases = &Releases{Path: base}
+	defer deployer.Close()
+
+	deployer.Releases.Name = "r1"
+	if err := deployer.Execute([]string{"test -x bin/start"}); err != nil {
+		t.Fatalf("erro no deploy r1: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(base, "current", "env.txt"))
+	if err != nil || string(data) != "production\n" {
+		t.Errorf("artefato não extraído na release ou sem as variáveis do deploy: %q, %v", data, err)
+	}
+	if n := buildCount(t, project); n != 1 {
+		t.Fatalf("esperado 1 build, obtidos %d", n)
+	}
+
+	// Mesmo commit: o artefato em cache é reaproveitado
+	deployer.Releases.Name = "r2"
+	if err := deployer.Execute(nil); err != nil {
+		t.Fatalf("erro no deploy r2: %v", err)
+	}
+	if n := buildCount(t, project); n != 1 {
+		t.Errorf("build refeito para o mesmo commit (%d builds)", n)
+	}
+	if _, err := os.Stat(filepath.Join(base, "releases", "r2", "app.txt")); err != nil {
+		t.Errorf("artefato em cache não enviado: %v", err)
+	}
+	if entries, _ := filepath.Glob(filepath.Join(base, "releases", "r2", ".00cli-artifact-*")); len(entries) != 0 {
+		t.Errorf("pacote não removido após a extração: %v", entries)
+	}
+
+	// Force ignora o cache
+	deployer.Build.Force = true
+	deployer.Releases.Name = "r3"
+	if err := deployer.Execute(nil); err != nil {
+		t.Fatalf("erro no deploy r3: %v", err)
+	}
+	if n := buildCount(t, project); n != 2 {
+		t.Errorf("esperado novo build com Force, obtidos %d", n)
+	}
+	deployer.Build.Force = false
+
+	// Alterações não commitadas: build sem cache, com artefato temporário
+	if err := os.WriteFile(filepath.Join(project, "app.txt"), []byte("v2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	deployer.Releases.Name = "r4"
+	if err := deployer.Execute(nil); err != nil {
+		t.Fatalf("erro no deploy r4: %v", err)
+	}
+	if n := buildCount(t, project); n != 3 {
+		t.Errorf("esperado novo build com alterações locais, obtidos %d", n)
+	}
+	if data, _ := os.ReadFile(filepath.Join(base, "current", "app.txt")); string(data) != "v2\n" {
+		t.Errorf("release r4 com artefato antigo: %q", data)
+	}
+	cached, _ := filepath.Glob(filepath.Join(project, ".00cli", "cache", "builds", "*.tar.gz"))
+	if len(cached) != 1 {
+		t.Errorf("esperado apenas o artefato do commit no cache: %v", cached)
+	}
+}
+
+func TestSSHDeployerBuildFailure(t *testing.T) {
+	srv := newTestSSHServer(t)
+	project := newBuildProject(t)
+
+	deployer := srv.Deployer()
+	deployer.Stdout = &bytes.Buffer{}
+	deployer.Stderr = &bytes.Buffer{}
+	deployer.Build = &Build{Commands: []string{"exit 3"}, Output: "dist", Target: t.TempDir(), ProjectPath: project}
+	defer deployer.Close()
+
+	if err := deployer.Execute([]string{"true"}); err == nil || !strings.Contains(err.Error(), "exit 3") {
+		t.Errorf("esperado erro do build, obtido %v", err)
+	}
+	if n := srv.Connections(); n != 0 {
+		t.Errorf("o servidor não deveria ser acessado após falha no build (%d conexões)", n)
+	}
+}
+
+func TestMultiHostBuildOnce(t *testing.T) {
+	srv1 := newTestSSHServer(t)
+	srv2 := newTestSSHServer(t)
+	project := newBuildProject(t)
+	target := t.TempDir()
+
+	base := srv1.Deployer()
+	base.Build = countingBuild(project)
+	base.Build.Target = filepath.Join(target, "public")
+	// Os dois servidores de teste compartilham o disco: um host por vez
+	out := &bytes.Buffer{}
+	multi := &MultiHostDeployer{
+		Base:        base,
+		Hosts:       []HostTarget{srv1.Target("web1"), srv2.Target("web2")},
+		Concurrency: 1,
+		Stdout:      out,
+		Stderr:      out,
+	}
+
+	if err := multi.Execute([]string{"test -f " + filepath.Join(target, "public", "app.txt")}); err != nil {
+		t.Fatalf("erro no deploy multi-host: %v\n%s", err, out)
+	}
+	if n := buildCount(t, project); n != 1 {
+		t.Errorf("esperado um único build para os dois hosts, obtidos %d", n)
+	}
+	if srv2.Connections() != 1 {
+		t.Error("segundo host não recebeu o deploy")
+	}
+}
+
+func TestPackDir(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "bin"), 0755)
+	os.WriteFile(filepath.Join(dir, "index.html"), []byte("<html>"), 0644)
+	os.WriteFile(filepath.Join(dir, "bin", "start"), []byte("#!/bin/sh"), 0755)
+	os.Chmod(filepath.Join(dir, "bin", "start"), 0755)
+	os.Symlink("index.html", filepath.Join(dir, "home.html"))
+
+	var buf bytes.Buffer
+	if err := packDir(dir, &buf); err != nil {
+		t.Fatal(err)
+	}
+
+	gz, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gz)
+	got := map[string]*tar.Header{}
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		got[header.Name] = header
+	}
+
+	if h := got["bin/"]; h == nil || h.Typeflag != tar.TypeDir {
+		t.Errorf("diretório bin/ ausente: %v", got)
+	}
+	if h := got["bin/start"]; h == nil || os.FileMode(h.Mode).Perm() != 0755 || h.Size != 9 {
+		t.Errorf("permissão ou tamanho de bin/start incorretos: %+v", h)
+	}
+	if h := got["home.html"]; h == nil || h.Typeflag != tar.TypeSymlink || h.Linkname != "index.html" {
+		t.Errorf("link simbólico não preservado: %+v", h)
+	}
+	for name := range got {
+		if strings.HasPrefix(name, "/") || strings.Contains(name, "..") {
+			t.Errorf("caminho inseguro no pacote: %s", name)
+		}
+	}
+}
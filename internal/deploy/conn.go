@@ -0,0 +1,183 @@
+package deploy
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	// DefaultKeepAlive é o intervalo padrão entre keepalives da conexão SSH
+	DefaultKeepAlive = 30 * time.Second
+
+	// keepAliveMaxMissed keepalives sem resposta derrubam a conexão, como o
+	// ServerAliveCountMax do OpenSSH
+	keepAliveMaxMissed = 3
+
+	// reconnectAttempts limita as tentativas de reconectar após uma queda
+	reconnectAttempts = 3
+)
+
+// reconnectBackoff é a espera antes da primeira nova tentativa de conexão
+// (dobra a cada falha); variável para os testes
+var reconnectBackoff = time.Second
+
+// sessionOpener abre sessões SSH; implementado por *ssh.Client e por sshConn
+type sessionOpener interface {
+	NewSession() (*ssh.Session, error)
+}
+
+// sshConn é a conexão SSH compartilhada pelos passos de um deploy (comandos,
+// uploads, releases) em um host. A conexão é aberta no primeiro uso, mantida
+// com keepalives e reaberta se cair; um comando em andamento no momento da
+// queda falha, mas os passos seguintes usam a nova conexão.
+type sshConn struct {
+	dial      func() (*ssh.Client, error)
+	keepAlive time.Duration
+	warnf     func(format string, args ...interface{})
+
+	mu     sync.Mutex
+	client *ssh.Client
+	dials  int
+}
+
+// NewSession abre uma sessão na conexão atual, reconectando se ela caiu
+func (c *sshConn) NewSession() (*ssh.Session, error) {
+	client, err := c.Client()
+	if err != nil {
+		return nil, err
+	}
+	session, err := client.NewSession()
+	if err == nil {
+		return session, nil
+	}
+
+	// A queda pode ainda não ter sido notada: descartar a conexão e tentar
+	// de novo em uma nova
+	c.discard(client)
+	if client, err = c.Client(); err != nil {
+		return nil, err
+	}
+	session, err = client.NewSession()
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criar sessão SSH: %w", err)
+	}
+	return session, nil
+}
+
+// Client retorna o cliente conectado. A primeira conexão falha de imediato;
+// reconexões são tentadas até reconnectAttempts vezes.
+func (c *sshConn) Client() (*ssh.Client, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.client != nil {
+		return c.client, nil
+	}
+
+	attempts := 1
+	if c.dials > 0 {
+		attempts = reconnectAttempts
+		c.warnf("🔌 Conexão SSH perdida, reconectando...\n")
+	}
+
+	backoff := reconnectBackoff
+	for attempt := 1; ; attempt++ {
+		client, err := c.dial()
+		if err == nil {
+			c.client = client
+			c.dials++
+			go c.watch(client)
+			return client, nil
+		}
+		if attempt == attempts {
+			if c.dials > 0 {
+				return nil, fmt.Errorf("erro ao reconectar após %d tentativas: %w", attempts, err)
+			}
+			return nil, err
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// watch envia keepalives enquanto a conexão estiver aberta e a descarta
+// quando ela termina, para que o próximo uso reconecte
+func (c *sshConn) watch(client *ssh.Client) {
+	done := make(chan struct{})
+	go func() {
+		client.Wait()
+		close(done)
+		c.discard(client)
+	}()
+
+	if c.keepAlive <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(c.keepAlive)
+	defer ticker.Stop()
+
+	missed := 0
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+
+		if sendKeepAlive(client, c.keepAlive) {
+			missed = 0
+			continue
+		}
+		missed++
+		if missed >= keepAliveMaxMissed {
+			c.warnf("⚠️  Servidor SSH não responde a %d keepalives, encerrando a conexão\n", missed)
+			client.Close()
+			return
+		}
+	}
+}
+
+// sendKeepAlive envia um keepalive@openssh.com; qualquer resposta (mesmo
+// uma recusa) indica que o servidor está vivo
+func sendKeepAlive(client *ssh.Client, timeout time.Duration) bool {
+	reply := make(chan error, 1)
+	go func() {
+		_, _, err := client.SendRequest("keepalive@openssh.com", true, nil)
+		reply <- err
+	}()
+
+	select {
+	case err := <-reply:
+		return err == nil
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// discard esquece o cliente, se ainda for o atual, e o fecha
+func (c *sshConn) discard(client *ssh.Client) {
+	c.mu.Lock()
+	if c.client == client {
+		c.client = nil
+	}
+	c.mu.Unlock()
+	client.Close()
+}
+
+// Close encerra a conexão; um novo uso abre outra
+func (c *sshConn) Close() error {
+	c.mu.Lock()
+	client := c.client
+	c.client = nil
+	c.dials = 0
+	c.mu.Unlock()
+
+	if client == nil {
+		return nil
+	}
+	return client.Close()
+}
@@ -0,0 +1,72 @@
+package deploy
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSSHDeployerReusesConnection(t *testing.T) {
+	srv := newTestSSHServer(t)
+	local := t.TempDir()
+	for _, name := range []string{"a.conf", "b.conf", "c.conf"} {
+		if err := os.WriteFile(filepath.Join(local, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	remote := t.TempDir()
+
+	deployer := srv.Deployer()
+	deployer.Provision = &Provision{LocalPath: local, Target: filepath.Join(remote, "provision")}
+	defer deployer.Close()
+
+	if err := deployer.Execute([]string{"true", "echo ok"}); err != nil {
+		t.Fatalf("erro no deploy: %v", err)
+	}
+	if err := deployer.UploadFile(filepath.Join(local, "a.conf"), filepath.Join(remote, "extra.conf")); err != nil {
+		t.Fatalf("erro no upload: %v", err)
+	}
+
+	if n := srv.Connections(); n != 1 {
+		t.Errorf("esperada 1 conexão para deploy, provision e upload, obtidas %d", n)
+	}
+}
+
+func TestSSHDeployerReconnects(t *testing.T) {
+	srv := newTestSSHServer(t)
+	reconnectBackoff = 10 * time.Millisecond
+	t.Cleanup(func() { reconnectBackoff = time.Second })
+
+	deployer := srv.Deployer()
+	deployer.Stderr = &bytes.Buffer{}
+	defer deployer.Close()
+
+	if err := deployer.Execute([]string{"true"}); err != nil {
+		t.Fatalf("erro no primeiro deploy: %v", err)
+	}
+
+	srv.DropConnections()
+	if err := deployer.Execute([]string{"true"}); err != nil {
+		t.Fatalf("conexão não foi restabelecida: %v", err)
+	}
+	if n := srv.Connections(); n != 2 {
+		t.Errorf("esperadas 2 conexões após a queda, obtidas %d", n)
+	}
+}
+
+func TestSSHDeployerKeepAlive(t *testing.T) {
+	srv := newTestSSHServer(t)
+
+	deployer := srv.Deployer()
+	deployer.KeepAlive = 10 * time.Millisecond
+	defer deployer.Close()
+
+	if err := deployer.Execute([]string{"sleep 0.2"}); err != nil {
+		t.Fatalf("erro no deploy: %v", err)
+	}
+	if srv.KeepAlives() == 0 {
+		t.Error("nenhum keepalive enviado durante o comando longo")
+	}
+}
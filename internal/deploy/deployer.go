@@ -2,6 +2,7 @@ package deploy
 
 import (
 	"fmt"
+	"time"
 )
 
 // Deployer interface para diferentes tipos de deploy
@@ -49,10 +50,143 @@ func createSSHDeployer(config interface{}) (Deployer, error) {
 	if password, ok := cfg["password"].(string); ok {
 		deployer.Password = password
 	}
+	if mode, ok := cfg["session_mode"].(string); ok {
+		deployer.SessionMode = mode
+	}
+	if shell, ok := cfg["shell"].(string); ok {
+		deployer.Shell = shell
+	}
+	if hostKey, ok := cfg["host_key"].(string); ok {
+		deployer.HostKey = hostKey
+	}
+	if knownHosts, ok := cfg["known_hosts"].([]string); ok {
+		deployer.KnownHosts = knownHosts
+	}
+	if identityFiles, ok := cfg["identity_files"].([]string); ok {
+		deployer.IdentityFiles = identityFiles
+	}
+	if sshConfig, ok := cfg["ssh_config"].(string); ok {
+		deployer.SSHConfig = sshConfig
+	}
+	if jump, ok := cfg["jump"].([]JumpHost); ok {
+		deployer.Jump = jump
+	}
+	if keepAlive, ok := cfg["keepalive"].(string); ok && keepAlive != "" {
+		interval, err := time.ParseDuration(keepAlive)
+		if err != nil {
+			return nil, fmt.Errorf("keepalive inválido: %s (ex: \"30s\")", keepAlive)
+		}
+		deployer.KeepAlive = interval
+	}
+	if env, ok := cfg["environment"].(map[string]string); ok {
+		deployer.Environment = env
+	}
+	if verbose, ok := cfg["verbose"].(bool); ok {
+		deployer.Verbose = verbose
+	}
+	if provisionPath, ok := cfg["provision_path"].(string); ok && provisionPath != "" {
+		deployer.Provision = &Provision{LocalPath: provisionPath}
+		if files, ok := cfg["provision_files"].([]string); ok {
+			deployer.Provision.Files = files
+		}
+		if target, ok := cfg["provision_target"].(string); ok {
+			deployer.Provision.Target = target
+		}
+	}
+	if syncPath, ok := cfg["sync_path"].(string); ok && syncPath != "" {
+		deployer.Sync = &Sync{LocalPath: syncPath}
+		if target, ok := cfg["sync_target"].(string); ok {
+			deployer.Sync.Target = target
+		}
+		if del, ok := cfg["sync_delete"].(bool); ok {
+			deployer.Sync.Delete = del
+		}
+		if exclude, ok := cfg["sync_exclude"].([]string); ok {
+			deployer.Sync.Exclude = exclude
+		}
+		if ignoreFile, ok := cfg["sync_ignore_file"].(string); ok {
+			deployer.Sync.IgnoreFile = ignoreFile
+		}
+	}
+	if commands, ok := cfg["build_commands"].([]string); ok && len(commands) > 0 {
+		deployer.Build = &Build{Commands: commands}
+		if projectPath, ok := cfg["project_path"].(string); ok {
+			deployer.Build.ProjectPath = projectPath
+		}
+		if output, ok := cfg["build_output"].(string); ok {
+			deployer.Build.Output = output
+		}
+		if target, ok := cfg["build_target"].(string); ok {
+			deployer.Build.Target = target
+		}
+		if cacheDir, ok := cfg["build_cache_dir"].(string); ok {
+			deployer.Build.CacheDir = cacheDir
+		}
+		if force, ok := cfg["build_force"].(bool); ok {
+			deployer.Build.Force = force
+		}
+	}
+	if projectPath, ok := cfg["project_path"].(string); ok {
+		deployer.ProjectPath = projectPath
+	}
+	if stages, ok := cfg["stages"].(Pipeline); ok {
+		if err := stages.Validate(); err != nil {
+			return nil, err
+		}
+		deployer.Pipeline = stages
+	}
+	if releasesPath, ok := cfg["releases_path"].(string); ok && releasesPath != "" {
+		deployer.Releases = &Releases{Path: releasesPath}
+		if name, ok := cfg["release_name"].(string); ok {
+			deployer.Releases.Name = name
+		}
+		if keep, ok := cfg["releases_keep"].(int); ok {
+			deployer.Releases.Keep = keep
+		}
+		if shared, ok := cfg["releases_shared"].([]string); ok {
+			deployer.Releases.Shared = shared
+		}
+		if restart, ok := cfg["releases_restart"].([]string); ok {
+			deployer.Releases.Restart = restart
+		}
+	}
+
+	switch deployer.SessionMode {
+	case "", SessionPersistent, SessionIsolated:
+	default:
+		return nil, fmt.Errorf("session_mode inválido: %s (use %q ou %q)", deployer.SessionMode, SessionPersistent, SessionIsolated)
+	}
+
+	// Com uma lista de hosts, o deploy é executado em cada um deles
+	if hosts, ok := cfg["hosts"].([]HostTarget); ok && len(hosts) > 0 {
+		multi := &MultiHostDeployer{Base: deployer, Hosts: hosts}
+		if roles, ok := cfg["roles"].([]string); ok {
+			multi.Roles = roles
+		}
+		if concurrency, ok := cfg["concurrency"].(int); ok {
+			multi.Concurrency = concurrency
+		}
+		if rolling, ok := cfg["rolling"].(bool); ok {
+			multi.Rolling = rolling
+		}
+		if batchSize, ok := cfg["batch_size"].(int); ok {
+			multi.BatchSize = batchSize
+		}
+		return multi, nil
+	}
 
 	return deployer, nil
 }
 
+// dockerRemoteKeys são as chaves da configuração SSH repassadas ao docker
+// remoto: a conexão com o servidor e o provision, enviado junto do compose
+var dockerRemoteKeys = []string{
+	"host", "port", "user", "ssh_key", "password", "host_key", "known_hosts",
+	"identity_files", "ssh_config", "jump", "keepalive", "session_mode",
+	"shell", "environment", "verbose", "hosts",
+	"provision_path", "provision_files", "provision_target",
+}
+
 func createDockerDeployer(config interface{}) (Deployer, error) {
 	cfg, ok := config.(ConfigMap)
 	if !ok {
@@ -72,6 +206,43 @@ func createDockerDeployer(config interface{}) (Deployer, error) {
 	} else {
 		deployer.Environment = make(map[string]string)
 	}
+	if projectName, ok := cfg["project_name"].(string); ok {
+		deployer.ProjectName = projectName
+	}
+	if profiles, ok := cfg["profiles"].([]string); ok {
+		deployer.Profiles = profiles
+	}
+	if envFile, ok := cfg["env_file"].(string); ok {
+		deployer.EnvFile = envFile
+	}
+	if stages, ok := cfg["stages"].(Pipeline); ok {
+		if err := stages.Validate(); err != nil {
+			return nil, err
+		}
+		deployer.Pipeline = stages
+	}
+	if remote, ok := cfg["remote"].(bool); ok && remote {
+		// Estágios e seções do deploy ssh (sync, build, releases) não são
+		// repassados ao SSHDeployer
+		connection := ConfigMap{}
+		for _, key := range dockerRemoteKeys {
+			if value, ok := cfg[key]; ok {
+				connection[key] = value
+			}
+		}
+		sshDeployer, err := createSSHDeployer(connection)
+		if err != nil {
+			return nil, err
+		}
+		remoteDeployer, ok := sshDeployer.(*SSHDeployer)
+		if !ok {
+			return nil, fmt.Errorf("docker remoto não suporta múltiplos hosts")
+		}
+		deployer.Remote = remoteDeployer
+		if remoteDir, ok := cfg["remote_dir"].(string); ok {
+			deployer.RemoteDir = remoteDir
+		}
+	}
 
 	return deployer, nil
 }
@@ -96,6 +267,15 @@ func createGitDeployer(config interface{}) (Deployer, error) {
 	if commands, ok := cfg["commands"].([]string); ok {
 		deployer.Commands = commands
 	}
+	if stages, ok := cfg["stages"].(Pipeline); ok {
+		if err := stages.Validate(); err != nil {
+			return nil, err
+		}
+		deployer.Pipeline = stages
+	}
+	if env, ok := cfg["environment"].(map[string]string); ok {
+		deployer.Environment = env
+	}
 
 	return deployer, nil
 }
@@ -1,6 +1,7 @@
 package deploy
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -69,45 +70,67 @@ func TestNewDeployer(t *testing.T) {
 	}
 }
 
-func TestParseCommand(t *testing.T) {
+func TestNewDockerDeployerRemote(t *testing.T) {
+	deployer, err := NewDeployer("docker", ConfigMap{
+		"project_path":   "/tmp/test",
+		"remote":         true,
+		"host":           "example.com",
+		"user":           "deploy",
+		"identity_files": []string{"/tmp/id_deploy"},
+		"stages":         Pipeline{StagePreDeploy: {Commands: []string{"true"}}},
+		"provision_path": "/tmp/test/provision",
+		"sync_path":      "/tmp/test/public",
+	})
+	if err != nil {
+		t.Fatalf("erro ao criar deployer: %v", err)
+	}
+
+	// O SSHDeployer do docker remoto recebe só a conexão e o provision
+	remote := deployer.(*DockerDeployer).Remote
+	if remote.Host != "example.com" || remote.User != "deploy" || len(remote.IdentityFiles) != 1 || remote.Provision == nil {
+		t.Errorf("dados de conexão não repassados: %+v", remote)
+	}
+	if remote.Pipeline != nil || remote.Sync != nil || remote.ProjectPath != "" {
+		t.Errorf("seções do deploy ssh não deveriam ser repassadas: %+v", remote)
+	}
+}
+
+func TestMaskEnv(t *testing.T) {
+	env := map[string]string{
+		"NODE_ENV":     "production",
+		"DATABASE_URL": "postgresql://user:senha@db/app",
+	}
+
+	masked := MaskEnv(env)
+	if masked != "DATABASE_URL=****, NODE_ENV=****" {
+		t.Errorf("formato inesperado: %q", masked)
+	}
+	for _, value := range env {
+		if strings.Contains(masked, value) {
+			t.Errorf("valor %q não deveria aparecer em %q", value, masked)
+		}
+	}
+}
+
+func TestInsertComposeFlags(t *testing.T) {
+	flags := []string{"-f", "compose.yml", "-p", "app"}
+
 	tests := []struct {
 		name     string
 		cmd      string
-		expected []string
+		expected string
 	}{
-		{
-			name:     "Comando simples",
-			cmd:      "git pull",
-			expected: []string{"git", "pull"},
-		},
-		{
-			name:     "Comando com aspas",
-			cmd:      `docker run -d --name "my container"`,
-			expected: []string{"docker", "run", "-d", "--name", "my container"},
-		},
-		{
-			name:     "Comando vazio",
-			cmd:      "",
-			expected: []string{},
-		},
-		{
-			name:     "Comando com múltiplos espaços",
-			cmd:      "git   pull   origin",
-			expected: []string{"git", "pull", "origin"},
-		},
+		{"docker-compose", "docker-compose up -d", "docker-compose -f compose.yml -p app up -d"},
+		{"docker compose", "docker compose pull", "docker compose -f compose.yml -p app pull"},
+		{"outro comando", "docker ps", "docker ps"},
+		{"com shell", "docker-compose up -d && docker ps", "docker-compose -f compose.yml -p app up -d && docker ps"},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := parseCommand(tt.cmd)
-			if len(result) != len(tt.expected) {
-				t.Errorf("esperado %d partes, obtido %d", len(tt.expected), len(result))
-				return
-			}
-			for i, expected := range tt.expected {
-				if result[i] != expected {
-					t.Errorf("parte %d: esperado '%s', obtido '%s'", i, expected, result[i])
-				}
+			result := insertComposeFlagsString(tt.cmd, strings.Join(flags, " "))
+			if result != tt.expected {
+				t.Errorf("esperado '%s', obtido '%s'", tt.expected, result)
 			}
 		})
 	}
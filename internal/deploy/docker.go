@@ -1,9 +1,10 @@
 package deploy
 
 import (
+	"context"
 	"fmt"
 	"os"
-	"os/exec"
+	"path"
 	"path/filepath"
 	"strings"
 )
@@ -13,12 +14,26 @@ type DockerDeployer struct {
 	ComposeFile string
 	ProjectPath string
 	Environment map[string]string
+	ProjectName string   // nome do projeto compose (-p)
+	Profiles    []string // profiles ativados (--profile)
+	EnvFile     string   // arquivo de variáveis do compose (--env-file)
+	Pipeline    Pipeline // estágios do deploy (opcional; commands equivale ao estágio build)
+
+	// Remote, se definido, executa o compose no servidor via SSH em vez da
+	// máquina local; o compose file (e o env file) são enviados para RemoteDir
+	Remote    *SSHDeployer
+	RemoteDir string
 }
 
-// Execute executa deploy Docker
+// Execute executa o pipeline de deploy Docker; commands são os comandos do
+// estágio build (padrão: down, pull e up do compose)
 func (d *DockerDeployer) Execute(commands []string) error {
+	if err := validateEnv(d.Environment); err != nil {
+		return err
+	}
+
 	// Se não houver comandos específicos, usar comandos padrão do Docker Compose
-	if len(commands) == 0 {
+	if len(commands) == 0 && len(d.Pipeline[StageBuild].Commands) == 0 {
 		commands = []string{
 			"docker-compose down",
 			"docker-compose pull",
@@ -26,8 +41,76 @@ func (d *DockerDeployer) Execute(commands []string) error {
 		}
 	}
 
-	// Procurar docker-compose.yml
+	composeFile, err := d.findComposeFile()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("📦 Usando docker-compose: %s\n", composeFile)
+
+	envFile := d.EnvFile
+	if envFile != "" && !filepath.IsAbs(envFile) {
+		envFile = filepath.Join(d.ProjectPath, envFile)
+	}
+
+	// As opções globais entram nos comandos do compose de commands; os
+	// estágios rodam como escritos. No servidor, os arquivos ficam em RemoteDir.
+	flags := d.composeFlags(composeFile, envFile)
+	if d.Remote != nil {
+		remoteEnvFile := ""
+		if envFile != "" {
+			remoteEnvFile = filepath.Base(envFile)
+		}
+		flags = d.composeFlags(filepath.Base(composeFile), remoteEnvFile)
+	}
+	composeCommands := make([]string, len(commands))
+	for i, cmd := range commands {
+		composeCommands[i] = insertComposeFlagsString(cmd, quoteAll(flags))
+	}
+
+	run, err := newPipelineRun(d.Pipeline, composeCommands, os.Stdout)
+	if err != nil {
+		return err
+	}
+
+	run.DefaultTarget = TargetLocal
+	run.Runners[TargetLocal] = func(ctx context.Context, name string, stage Stage) error {
+		commands, err := stage.localCommands()
+		if err != nil {
+			return err
+		}
+		local := localStage{
+			Dir:    localStageDir(d.ProjectPath, stage.Dir),
+			Env:    d.Environment,
+			Stdout: os.Stdout,
+			Stderr: os.Stderr,
+		}
+		return local.run(ctx, commands)
+	}
+
+	if d.Remote != nil {
+		d.prepareRemote(run, composeFile, envFile)
+	}
+
+	return run.Run()
+}
+
+// Close encerra a conexão com o servidor remoto, se houver
+func (d *DockerDeployer) Close() error {
+	if d.Remote == nil {
+		return nil
+	}
+	return d.Remote.Close()
+}
+
+// findComposeFile localiza o docker-compose.yml configurado ou padrão
+func (d *DockerDeployer) findComposeFile() (string, error) {
 	composeFile := d.ComposeFile
+	if composeFile != "" && !filepath.IsAbs(composeFile) {
+		composeFile = filepath.Join(d.ProjectPath, composeFile)
+	}
+
+	// Procurar docker-compose.yml
 	if composeFile == "" {
 		composeFile = filepath.Join(d.ProjectPath, "docker-compose.yml")
 		if _, err := os.Stat(composeFile); os.IsNotExist(err) {
@@ -38,64 +121,105 @@ func (d *DockerDeployer) Execute(commands []string) error {
 
 	// Verificar se docker-compose existe
 	if _, err := os.Stat(composeFile); os.IsNotExist(err) {
-		return fmt.Errorf("arquivo docker-compose.yml não encontrado em %s", d.ProjectPath)
+		if d.ComposeFile != "" {
+			return "", fmt.Errorf("arquivo compose não encontrado: %s", composeFile)
+		}
+		return "", fmt.Errorf("arquivo docker-compose.yml não encontrado em %s", d.ProjectPath)
 	}
 
-	fmt.Printf("📦 Usando docker-compose: %s\n", composeFile)
-
-	// Executar comandos
-	for i, cmd := range commands {
-		fmt.Printf("  [%d/%d] Executando: %s\n", i+1, len(commands), cmd)
+	return composeFile, nil
+}
 
-		// Parsear comando
-		parts := parseCommand(cmd)
-		if len(parts) == 0 {
-			continue
-		}
+// composeFlags monta as opções globais do compose
+func (d *DockerDeployer) composeFlags(composeFile, envFile string) []string {
+	flags := []string{"-f", composeFile}
+	if d.ProjectName != "" {
+		flags = append(flags, "-p", d.ProjectName)
+	}
+	for _, profile := range d.Profiles {
+		flags = append(flags, "--profile", profile)
+	}
+	if envFile != "" {
+		flags = append(flags, "--env-file", envFile)
+	}
+	return flags
+}
 
-		// Criar comando
-		command := exec.Command(parts[0], parts[1:]...)
-		command.Dir = d.ProjectPath
-		command.Stdout = os.Stdout
-		command.Stderr = os.Stderr
+// prepareRemote configura o pipeline para executar os estágios no servidor:
+// o estágio upload envia o compose file (e o env file) e os comandos rodam
+// em RemoteDir
+func (d *DockerDeployer) prepareRemote(run *pipelineRun, composeFile, envFile string) {
+	remoteDir := d.RemoteDir
+	if remoteDir == "" {
+		remoteDir = filepath.Base(d.ProjectPath)
+	}
 
-		// Adicionar variáveis de ambiente
-		command.Env = os.Environ()
-		for k, v := range d.Environment {
-			command.Env = append(command.Env, fmt.Sprintf("%s=%s", k, v))
-		}
+	remote := d.Remote
+	if remote.Environment == nil {
+		remote.Environment = d.Environment
+	}
+	client := remote.connection()
 
-		// Executar
-		if err := command.Run(); err != nil {
-			return fmt.Errorf("erro ao executar '%s': %w", cmd, err)
-		}
+	run.DefaultTarget = TargetRemote
+	run.Actions[StageUpload] = func() error {
+		return d.uploadCompose(client, remoteDir, composeFile, envFile)
 	}
 
-	return nil
+	run.Runners[TargetRemote] = func(ctx context.Context, name string, stage Stage) error {
+		prelude := "cd " + shellQuote(remoteStageDir(remoteDir, stage.Dir))
+		return remote.runRemoteStage(ctx, client, prelude, stage.Commands, stage.Scripts)
+	}
 }
 
-// parseCommand divide uma string de comando em partes
-func parseCommand(cmd string) []string {
-	var parts []string
-	var current strings.Builder
-	inQuotes := false
-
-	for _, char := range cmd {
-		if char == '"' || char == '\'' {
-			inQuotes = !inQuotes
-		} else if char == ' ' && !inQuotes {
-			if current.Len() > 0 {
-				parts = append(parts, current.String())
-				current.Reset()
-			}
-		} else {
-			current.WriteRune(char)
-		}
+// uploadCompose envia o compose file e o env file para remoteDir
+func (d *DockerDeployer) uploadCompose(client *sshConn, remoteDir, composeFile, envFile string) error {
+	remote := d.Remote
+	if _, err := client.Client(); err != nil {
+		return err
+	}
+
+	if err := remote.syncProvision(client); err != nil {
+		return err
 	}
 
-	if current.Len() > 0 {
-		parts = append(parts, current.String())
+	if err := runRemote(client, "mkdir -p "+shellQuote(remoteDir)); err != nil {
+		return fmt.Errorf("erro ao criar diretório remoto %s: %w", remoteDir, err)
 	}
 
-	return parts
+	uploads := []string{composeFile}
+	if envFile != "" {
+		uploads = append(uploads, envFile)
+	}
+	transfer, err := newFileTransfer(client)
+	if err != nil {
+		return err
+	}
+	defer transfer.Close()
+	transfer.Verify = true
+
+	for _, local := range uploads {
+		info, err := os.Stat(local)
+		if err != nil {
+			return fmt.Errorf("erro ao ler %s: %w", local, err)
+		}
+		remotePath := path.Join(remoteDir, filepath.Base(local))
+		fmt.Printf("⬆️  Enviando %s para %s@%s:%s\n", filepath.Base(local), remote.User, remote.Host, remotePath)
+		if err := transfer.UploadFile(local, remotePath, info.Mode().Perm()); err != nil {
+			return fmt.Errorf("erro ao enviar %s: %w", filepath.Base(local), err)
+		}
+	}
+	return nil
+}
+
+// insertComposeFlagsString insere as opções globais (flags já escapadas)
+// logo após "docker-compose" ou "docker compose", preservando o restante do
+// comando, executado pelo shell
+func insertComposeFlagsString(cmd, flags string) string {
+	trimmed := strings.TrimLeft(cmd, " ")
+	for _, prefix := range []string{"docker-compose", "docker compose"} {
+		if trimmed == prefix || strings.HasPrefix(trimmed, prefix+" ") {
+			return prefix + " " + flags + trimmed[len(prefix):]
+		}
+	}
+	return cmd
 }
@@ -0,0 +1,82 @@
+package deploy
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// validateEnv verifica se os nomes das variáveis podem ser usados em um shell
+func validateEnv(env map[string]string) error {
+	for name := range env {
+		if !envNamePattern.MatchString(name) {
+			return fmt.Errorf("nome de variável de ambiente inválido: %q", name)
+		}
+	}
+	return nil
+}
+
+// sortedEnvNames retorna os nomes das variáveis em ordem alfabética
+func sortedEnvNames(env map[string]string) []string {
+	names := make([]string, 0, len(env))
+	for name := range env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// MaskEnv formata as variáveis para exibição, ocultando os valores
+func MaskEnv(env map[string]string) string {
+	parts := make([]string, 0, len(env))
+	for _, name := range sortedEnvNames(env) {
+		parts = append(parts, name+"=****")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// envList converte o mapa para o formato NOME=valor usado por exec.Cmd
+func envList(env map[string]string) []string {
+	list := make([]string, 0, len(env))
+	for _, name := range sortedEnvNames(env) {
+		list = append(list, name+"="+env[name])
+	}
+	return list
+}
+
+// commandEnv retorna o ambiente do processo atual acrescido das variáveis
+func commandEnv(env map[string]string) []string {
+	return append(os.Environ(), envList(env)...)
+}
+
+// shellQuote protege um valor com aspas simples para uso em shell POSIX
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// exportPrefix gera os comandos export equivalentes às variáveis
+func exportPrefix(env map[string]string) string {
+	var b strings.Builder
+	for _, name := range sortedEnvNames(env) {
+		fmt.Fprintf(&b, "export %s=%s; ", name, shellQuote(env[name]))
+	}
+	return b.String()
+}
+
+// setSessionEnv tenta definir as variáveis na sessão via SetEnv. Retorna
+// false se o servidor recusar alguma delas (AcceptEnv do sshd), caso em que
+// o chamador deve exportá-las no próprio comando.
+func setSessionEnv(session *ssh.Session, env map[string]string) bool {
+	for _, name := range sortedEnvNames(env) {
+		if err := session.Setenv(name, env[name]); err != nil {
+			return false
+		}
+	}
+	return true
+}
@@ -1,6 +1,7 @@
 package deploy
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
@@ -13,14 +14,48 @@ type GitDeployer struct {
 	Branch      string
 	ProjectPath string
 	Commands    []string
+	Environment map[string]string
+	Pipeline    Pipeline // estágios do deploy (opcional; Commands equivale ao estágio build)
 }
 
-// Execute executa deploy via Git
+// Execute executa o pipeline de deploy via Git: o estágio upload clona ou
+// atualiza o repositório e os comandos rodam localmente no projeto
 func (d *GitDeployer) Execute(commands []string) error {
+	if err := validateEnv(d.Environment); err != nil {
+		return err
+	}
+
 	// Usar comandos fornecidos se não houver comandos configurados
 	if len(commands) > 0 {
 		d.Commands = commands
 	}
+
+	run, err := newPipelineRun(d.Pipeline, d.Commands, os.Stdout)
+	if err != nil {
+		return err
+	}
+
+	run.DefaultTarget = TargetLocal
+	run.Actions[StageUpload] = d.checkout
+	run.Runners[TargetLocal] = func(ctx context.Context, name string, stage Stage) error {
+		commands, err := stage.localCommands()
+		if err != nil {
+			return err
+		}
+		local := localStage{
+			Dir:    localStageDir(d.ProjectPath, stage.Dir),
+			Env:    d.Environment,
+			Stdout: os.Stdout,
+			Stderr: os.Stderr,
+		}
+		return local.run(ctx, commands)
+	}
+
+	return run.Run()
+}
+
+// checkout usa o repositório local ou clona/atualiza Repository
+func (d *GitDeployer) checkout() error {
 	// Se não houver repositório configurado, assumir que já está em um repo Git
 	if d.Repository == "" {
 		// Verificar se é um repositório Git
@@ -30,35 +65,11 @@ func (d *GitDeployer) Execute(commands []string) error {
 		}
 
 		fmt.Println("📦 Usando repositório Git local")
-	} else {
-		// Clonar ou atualizar repositório
-		if err := d.cloneOrUpdate(); err != nil {
-			return err
-		}
-	}
-
-	// Executar comandos pós-deploy
-	if len(d.Commands) > 0 {
-		for i, cmd := range d.Commands {
-			fmt.Printf("  [%d/%d] Executando: %s\n", i+1, len(d.Commands), cmd)
-
-			parts := parseCommand(cmd)
-			if len(parts) == 0 {
-				continue
-			}
-
-			command := exec.Command(parts[0], parts[1:]...)
-			command.Dir = d.ProjectPath
-			command.Stdout = os.Stdout
-			command.Stderr = os.Stderr
-
-			if err := command.Run(); err != nil {
-				return fmt.Errorf("erro ao executar '%s': %w", cmd, err)
-			}
-		}
+		return nil
 	}
 
-	return nil
+	// Clonar ou atualizar repositório
+	return d.cloneOrUpdate()
 }
 
 func (d *GitDeployer) cloneOrUpdate() error {
@@ -74,6 +85,7 @@ func (d *GitDeployer) cloneOrUpdate() error {
 		}
 
 		cmd := exec.Command("git", "clone", "-b", branch, d.Repository, d.ProjectPath)
+		cmd.Env = commandEnv(d.Environment)
 		cmd.Stdout = os.Stdout
 		cmd.Stderr = os.Stderr
 
@@ -87,6 +99,7 @@ func (d *GitDeployer) cloneOrUpdate() error {
 		// Pull
 		cmd := exec.Command("git", "pull")
 		cmd.Dir = d.ProjectPath
+		cmd.Env = commandEnv(d.Environment)
 		cmd.Stdout = os.Stdout
 		cmd.Stderr = os.Stderr
 
@@ -98,6 +111,7 @@ func (d *GitDeployer) cloneOrUpdate() error {
 		if d.Branch != "" {
 			cmd = exec.Command("git", "checkout", d.Branch)
 			cmd.Dir = d.ProjectPath
+			cmd.Env = commandEnv(d.Environment)
 			cmd.Stdout = os.Stdout
 			cmd.Stderr = os.Stderr
 
@@ -0,0 +1,259 @@
+package deploy
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// DefaultKnownHostsFile retorna o caminho do known_hosts do usuário (~/.ssh/known_hosts)
+func DefaultKnownHostsFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".ssh", "known_hosts")
+}
+
+// hostKeyVerifier valida a chave do servidor contra uma chave fixada
+// (server.host_key) ou contra arquivos known_hosts, perguntando ao usuário
+// na primeira conexão (trust-on-first-use).
+type hostKeyVerifier struct {
+	// Pin é a chave esperada, no formato "SHA256:<fingerprint>" ou
+	// "<tipo> <base64>" (formato authorized_keys)
+	Pin string
+	// Files são os arquivos known_hosts consultados; novas chaves aceitas
+	// são gravadas no primeiro da lista
+	Files []string
+	// Confirm pergunta ao usuário se a chave desconhecida deve ser aceita
+	Confirm func(question string) (bool, error)
+	// Out recebe as mensagens exibidas ao usuário
+	Out io.Writer
+}
+
+func (d *SSHDeployer) hostKeyVerifier() *hostKeyVerifier {
+	files := d.KnownHosts
+	if len(files) == 0 {
+		if def := DefaultKnownHostsFile(); def != "" {
+			files = []string{def}
+		}
+	}
+
+	confirm := d.ConfirmHostKey
+	if confirm == nil {
+		confirm = confirmOnTerminal
+	}
+
+	return &hostKeyVerifier{
+		Pin:     d.HostKey,
+		Files:   files,
+		Confirm: confirm,
+		Out:     d.stdout(),
+	}
+}
+
+// Callback retorna o ssh.HostKeyCallback que aplica a verificação
+func (v *hostKeyVerifier) Callback() ssh.HostKeyCallback {
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		if v.Pin != "" {
+			return v.checkPin(hostname, key)
+		}
+		return v.checkKnownHosts(hostname, remote, key)
+	}
+}
+
+// Algorithms retorna os algoritmos de chave já conhecidos para o host, para
+// que o servidor apresente a mesma chave registrada no known_hosts.
+func (v *hostKeyVerifier) Algorithms(addr string) []string {
+	if v.Pin != "" {
+		if key, err := parsePinnedKey(v.Pin); err == nil {
+			return keyAlgorithms(key.Type())
+		}
+		return nil
+	}
+
+	callback, err := v.knownHostsCallback()
+	if err != nil || callback == nil {
+		return nil
+	}
+
+	// Consultar com uma chave que não existe para obter as chaves conhecidas
+	err = callback(addr, &net.TCPAddr{}, probeKey{})
+	var keyErr *knownhosts.KeyError
+	if !errors.As(err, &keyErr) {
+		return nil
+	}
+
+	var algorithms []string
+	seen := make(map[string]bool)
+	for _, known := range keyErr.Want {
+		for _, algo := range keyAlgorithms(known.Key.Type()) {
+			if !seen[algo] {
+				seen[algo] = true
+				algorithms = append(algorithms, algo)
+			}
+		}
+	}
+	return algorithms
+}
+
+func (v *hostKeyVerifier) checkPin(hostname string, key ssh.PublicKey) error {
+	got := ssh.FingerprintSHA256(key)
+
+	if strings.HasPrefix(v.Pin, "SHA256:") {
+		if v.Pin == got {
+			return nil
+		}
+		return fmt.Errorf("chave do host %s não confere com server.host_key\n   esperada: %s\n   recebida: %s (%s)", hostname, v.Pin, got, key.Type())
+	}
+
+	pinned, err := parsePinnedKey(v.Pin)
+	if err != nil {
+		return fmt.Errorf("server.host_key inválido: %w", err)
+	}
+	if string(pinned.Marshal()) == string(key.Marshal()) {
+		return nil
+	}
+	return fmt.Errorf("chave do host %s não confere com server.host_key\n   esperada: %s (%s)\n   recebida: %s (%s)",
+		hostname, ssh.FingerprintSHA256(pinned), pinned.Type(), got, key.Type())
+}
+
+func (v *hostKeyVerifier) checkKnownHosts(hostname string, remote net.Addr, key ssh.PublicKey) error {
+	callback, err := v.knownHostsCallback()
+	if err != nil {
+		return err
+	}
+
+	if callback != nil {
+		err = callback(hostname, remote, key)
+		if err == nil {
+			return nil
+		}
+
+		var revoked *knownhosts.RevokedError
+		if errors.As(err, &revoked) {
+			return fmt.Errorf("chave do host %s foi revogada (%s:%d)", hostname, revoked.Revoked.Filename, revoked.Revoked.Line)
+		}
+
+		var keyErr *knownhosts.KeyError
+		if !errors.As(err, &keyErr) {
+			return err
+		}
+		if len(keyErr.Want) > 0 {
+			return mismatchError(hostname, key, keyErr.Want)
+		}
+	}
+
+	return v.trustOnFirstUse(hostname, remote, key)
+}
+
+// trustOnFirstUse pergunta ao usuário se deve confiar em um host desconhecido
+// e, se aceito, grava a chave no known_hosts.
+func (v *hostKeyVerifier) trustOnFirstUse(hostname string, remote net.Addr, key ssh.PublicKey) error {
+	fingerprint := ssh.FingerprintSHA256(key)
+
+	fmt.Fprintf(v.Out, "⚠️  A autenticidade do host '%s' não pôde ser verificada.\n", hostname)
+	fmt.Fprintf(v.Out, "   Chave %s: %s\n", key.Type(), fingerprint)
+
+	ok, err := v.Confirm("   Deseja confiar neste host e continuar?")
+	if err != nil {
+		if errors.Is(err, errNotInteractive) {
+			return fmt.Errorf("host %s desconhecido (chave %s %s); adicione-o ao known_hosts ou configure server.host_key", hostname, key.Type(), fingerprint)
+		}
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("chave do host %s rejeitada pelo usuário", hostname)
+	}
+
+	if len(v.Files) == 0 {
+		return nil
+	}
+
+	if err := appendKnownHost(v.Files[0], hostname, remote, key); err != nil {
+		return fmt.Errorf("erro ao gravar known_hosts: %w", err)
+	}
+	fmt.Fprintf(v.Out, "   ✅ Host adicionado a %s\n", v.Files[0])
+
+	return nil
+}
+
+// knownHostsCallback cria o callback do pacote knownhosts com os arquivos
+// existentes; retorna nil se nenhum arquivo existir.
+func (v *hostKeyVerifier) knownHostsCallback() (ssh.HostKeyCallback, error) {
+	var files []string
+	for _, file := range v.Files {
+		if _, err := os.Stat(file); err == nil {
+			files = append(files, file)
+		}
+	}
+	if len(files) == 0 {
+		return nil, nil
+	}
+
+	callback, err := knownhosts.New(files...)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler known_hosts: %w", err)
+	}
+	return callback, nil
+}
+
+func mismatchError(hostname string, key ssh.PublicKey, want []knownhosts.KnownKey) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "chave do host %s mudou! Possível ataque man-in-the-middle", hostname)
+	for _, known := range want {
+		fmt.Fprintf(&b, "\n   esperada: %s (%s) em %s:%d", ssh.FingerprintSHA256(known.Key), known.Key.Type(), known.Filename, known.Line)
+	}
+	fmt.Fprintf(&b, "\n   recebida: %s (%s)", ssh.FingerprintSHA256(key), key.Type())
+	return errors.New(b.String())
+}
+
+func appendKnownHost(file, hostname string, remote net.Addr, key ssh.PublicKey) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		return err
+	}
+
+	addresses := []string{knownhosts.Normalize(hostname)}
+	if remote != nil {
+		if addr := knownhosts.Normalize(remote.String()); addr != addresses[0] {
+			addresses = append(addresses, addr)
+		}
+	}
+
+	f, err := os.OpenFile(file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = fmt.Fprintln(f, knownhosts.Line(addresses, key))
+	return err
+}
+
+// parsePinnedKey interpreta uma chave no formato authorized_keys
+func parsePinnedKey(pin string) (ssh.PublicKey, error) {
+	key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(pin))
+	return key, err
+}
+
+// keyAlgorithms retorna os algoritmos de assinatura aceitos para um tipo de chave
+func keyAlgorithms(keyType string) []string {
+	if keyType == ssh.KeyAlgoRSA {
+		return []string{ssh.KeyAlgoRSASHA512, ssh.KeyAlgoRSASHA256, ssh.KeyAlgoRSA}
+	}
+	return []string{keyType}
+}
+
+// probeKey é uma chave que nunca consta no known_hosts
+type probeKey struct{}
+
+func (probeKey) Type() string                                 { return "00cli-probe" }
+func (probeKey) Marshal() []byte                              { return []byte("00cli-probe") }
+func (probeKey) Verify(data []byte, sig *ssh.Signature) error { return errors.New("chave de sondagem") }
@@ -0,0 +1,142 @@
+package deploy
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+)
+
+// IgnoreFile é o arquivo da raiz do projeto com os padrões ignorados pelo sync
+const IgnoreFile = ".00cliignore"
+
+// ignoreRule é um padrão no estilo .gitignore
+type ignoreRule struct {
+	segments []string // partes do padrão; "**" casa qualquer quantidade de níveis
+	anchored bool     // padrões com "/" casam a partir da raiz do diretório
+	dirOnly  bool     // padrões terminados em "/" casam apenas diretórios
+	negate   bool     // padrões iniciados com "!" voltam a incluir o caminho
+}
+
+// ignoreMatcher decide quais caminhos relativos ficam fora do sync. Como no
+// .gitignore, o último padrão que casa prevalece e o conteúdo de um
+// diretório ignorado também é ignorado.
+type ignoreMatcher struct {
+	rules []ignoreRule
+}
+
+// loadIgnoreFile lê os padrões de um arquivo .00cliignore; linhas vazias e
+// comentários (#) são descartados. Um arquivo inexistente não tem padrões.
+func loadIgnoreFile(file string) ([]string, error) {
+	f, err := os.Open(file)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler %s: %w", file, err)
+	}
+	defer f.Close()
+
+	var patterns []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("erro ao ler %s: %w", file, err)
+	}
+	return patterns, nil
+}
+
+// newIgnoreMatcher compila os padrões, rejeitando globs inválidos
+func newIgnoreMatcher(patterns []string) (*ignoreMatcher, error) {
+	m := &ignoreMatcher{}
+	for _, pattern := range patterns {
+		rule := ignoreRule{}
+		p := strings.TrimSpace(pattern)
+		if strings.HasPrefix(p, "!") {
+			rule.negate = true
+			p = p[1:]
+		}
+		if strings.HasSuffix(p, "/") {
+			rule.dirOnly = true
+			p = strings.TrimRight(p, "/")
+		}
+		if strings.Contains(p, "/") {
+			rule.anchored = true
+			p = strings.TrimLeft(p, "/")
+		}
+		if p == "" {
+			return nil, fmt.Errorf("padrão de exclusão inválido: %q", pattern)
+		}
+
+		rule.segments = strings.Split(p, "/")
+		for _, segment := range rule.segments {
+			if _, err := path.Match(segment, ""); err != nil {
+				return nil, fmt.Errorf("padrão de exclusão inválido: %q", pattern)
+			}
+		}
+		m.rules = append(m.rules, rule)
+	}
+	return m, nil
+}
+
+// Match informa se o caminho relativo (separado por "/") é ignorado
+func (m *ignoreMatcher) Match(rel string, isDir bool) bool {
+	if m == nil || len(m.rules) == 0 {
+		return false
+	}
+
+	parts := strings.Split(rel, "/")
+	for i := 1; i < len(parts); i++ {
+		if m.matchPath(parts[:i], true) {
+			return true
+		}
+	}
+	return m.matchPath(parts, isDir)
+}
+
+func (m *ignoreMatcher) matchPath(parts []string, isDir bool) bool {
+	ignored := false
+	for _, rule := range m.rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		pattern := rule.segments
+		if !rule.anchored {
+			pattern = append([]string{"**"}, pattern...)
+		}
+		if matchSegments(pattern, parts) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+// matchSegments casa o caminho parte a parte com path.Match; "**" consome
+// zero ou mais partes
+func matchSegments(pattern, parts []string) bool {
+	if len(pattern) == 0 {
+		return len(parts) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(parts); i++ {
+			if matchSegments(pattern[1:], parts[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(parts) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pattern[0], parts[0]); !ok {
+		return false
+	}
+	return matchSegments(pattern[1:], parts[1:])
+}
@@ -0,0 +1,130 @@
+package deploy
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// JumpHost é um servidor intermediário (bastion) pelo qual a conexão com o
+// servidor de deploy é tunelada. Campos vazios são resolvidos pelo
+// ssh_config e pelas chaves padrão, como em um host comum.
+type JumpHost struct {
+	Host     string
+	Port     int
+	User     string
+	SSHKey   string
+	Password string
+	HostKey  string // chave fixada do bastion
+}
+
+// jumpHosts retorna os bastions da conexão: Jump, se configurado, ou o
+// ProxyJump do ssh_config
+func (d *SSHDeployer) jumpHosts(target *sshTarget) ([]JumpHost, error) {
+	if len(d.Jump) > 0 {
+		return d.Jump, nil
+	}
+	if target.ProxyJump == "" || target.ProxyJump == SSHConfigNone {
+		return nil, nil
+	}
+	return ParseProxyJump(target.ProxyJump)
+}
+
+// jumpDeployer cria o deployer usado para autenticar em um bastion. A
+// verificação de chave, o ssh_config, as chaves adicionais e o
+// keyboard-interactive são os mesmos do servidor de deploy.
+func (d *SSHDeployer) jumpDeployer(jump JumpHost) *SSHDeployer {
+	return &SSHDeployer{
+		Host:           jump.Host,
+		Port:           jump.Port,
+		User:           jump.User,
+		SSHKey:         jump.SSHKey,
+		Password:       jump.Password,
+		HostKey:        jump.HostKey,
+		KnownHosts:     d.KnownHosts,
+		ConfirmHostKey: d.ConfirmHostKey,
+		SSHConfig:      d.SSHConfig,
+		Passphrase:     d.Passphrase,
+		IdentityFiles:  d.IdentityFiles,
+		Verbose:        d.Verbose,
+		Stdout:         d.Stdout,
+		Stderr:         d.Stderr,
+
+		KeyboardInteractive: d.KeyboardInteractive,
+	}
+}
+
+// ParseProxyJump interpreta a sintaxe do ProxyJump do OpenSSH:
+// "[usuário@]host[:porta]" separados por vírgula, na ordem dos saltos
+func ParseProxyJump(spec string) ([]JumpHost, error) {
+	var jumps []JumpHost
+	for _, hop := range strings.Split(spec, ",") {
+		hop = strings.TrimPrefix(strings.TrimSpace(hop), "ssh://")
+		if hop == "" {
+			return nil, fmt.Errorf("ProxyJump inválido: %q", spec)
+		}
+
+		var jump JumpHost
+		if at := strings.LastIndex(hop, "@"); at >= 0 {
+			jump.User, hop = hop[:at], hop[at+1:]
+		}
+
+		jump.Host = hop
+		if strings.HasPrefix(hop, "[") || strings.Count(hop, ":") == 1 {
+			host, port, err := net.SplitHostPort(hop)
+			if err != nil {
+				return nil, fmt.Errorf("ProxyJump inválido %q: %w", hop, err)
+			}
+			jump.Host = host
+			if jump.Port, err = strconv.Atoi(port); err != nil {
+				return nil, fmt.Errorf("ProxyJump inválido %q: porta %s", hop, port)
+			}
+		}
+		if jump.Host == "" {
+			return nil, fmt.Errorf("ProxyJump inválido: %q", spec)
+		}
+		jumps = append(jumps, jump)
+	}
+	return jumps, nil
+}
+
+// dialVia abre a conexão SSH com addr por um túnel aberto no cliente via.
+// O cliente retornado fecha via quando a conexão termina; em caso de erro,
+// via é fechado imediatamente.
+func dialVia(via *ssh.Client, addr string, config *ssh.ClientConfig) (*ssh.Client, error) {
+	conn, err := via.Dial("tcp", addr)
+	if err != nil {
+		via.Close()
+		return nil, fmt.Errorf("erro ao abrir túnel até %s: %w", addr, err)
+	}
+
+	// O túnel é um canal SSH, que não aceita SetDeadline: o handshake é
+	// limitado por um timer que fecha a conexão
+	var timer *time.Timer
+	if config.Timeout > 0 {
+		timer = time.AfterFunc(config.Timeout, func() { conn.Close() })
+	}
+	c, chans, reqs, err := ssh.NewClientConn(conn, addr, config)
+	if timer != nil && !timer.Stop() {
+		if err == nil {
+			c.Close()
+		}
+		err = fmt.Errorf("tempo limite de %s excedido no handshake", config.Timeout)
+	}
+	if err != nil {
+		conn.Close()
+		via.Close()
+		return nil, fmt.Errorf("erro ao conectar via SSH: %w", err)
+	}
+
+	client := ssh.NewClient(c, chans, reqs)
+	go func() {
+		client.Wait()
+		via.Close()
+	}()
+	return client, nil
+}
@@ -0,0 +1,221 @@
+package deploy
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func TestParseProxyJump(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    []JumpHost
+		wantErr bool
+	}{
+		{spec: "bastion", want: []JumpHost{{Host: "bastion"}}},
+		{spec: "ops@bastion:2222", want: []JumpHost{{Host: "bastion", Port: 2222, User: "ops"}}},
+		{
+			spec: "a@um.exemplo.com, ssh://b@[2001:db8::1]:22",
+			want: []JumpHost{{Host: "um.exemplo.com", User: "a"}, {Host: "2001:db8::1", Port: 22, User: "b"}},
+		},
+		{spec: "bastion:porta", wantErr: true},
+		{spec: "bastion,,outro", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := ParseProxyJump(tt.spec)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("esperado erro, obtido %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("erro inesperado: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("esperado %+v, obtido %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSSHDeployerJumpChain(t *testing.T) {
+	target := newTestSSHServer(t)
+	first := newTestSSHServer(t)
+	second := newTestSSHServer(t)
+
+	jumpTo := func(srv *testSSHServer) JumpHost {
+		return JumpHost{
+			Host:     srv.Host,
+			Port:     srv.Port,
+			User:     "jump",
+			Password: "bastion",
+			HostKey:  ssh.FingerprintSHA256(srv.HostKey.PublicKey()),
+		}
+	}
+
+	deployer := target.Deployer()
+	deployer.Jump = []JumpHost{jumpTo(first), jumpTo(second)}
+	defer deployer.Close()
+
+	if err := deployer.Execute([]string{"echo via bastion"}); err != nil {
+		t.Fatalf("erro no deploy pelo bastion: %v", err)
+	}
+
+	local := filepath.Join(t.TempDir(), "app.conf")
+	if err := os.WriteFile(local, []byte("ok\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	remote := filepath.Join(t.TempDir(), "app.conf")
+	if err := deployer.UploadFile(local, remote); err != nil {
+		t.Fatalf("erro no upload pelo bastion: %v", err)
+	}
+	if data, err := os.ReadFile(remote); err != nil || string(data) != "ok\n" {
+		t.Errorf("arquivo não enviado pelo bastion: %q, %v", data, err)
+	}
+
+	// Comandos e upload compartilham a mesma conexão pelo túnel
+	if got := first.Forwards(); !reflect.DeepEqual(got, []string{second.Addr}) {
+		t.Errorf("primeiro bastion deveria tunelar até o segundo: %v", got)
+	}
+	if got := second.Forwards(); !reflect.DeepEqual(got, []string{target.Addr}) {
+		t.Errorf("segundo bastion deveria tunelar até o servidor: %v", got)
+	}
+	if len(first.Commands()) != 0 || len(second.Commands()) != 0 {
+		t.Error("comandos não deveriam ser executados nos bastions")
+	}
+}
+
+func TestSSHDeployerProxyJumpFromSSHConfig(t *testing.T) {
+	dir := t.TempDir()
+	key := writeTestKey(t, filepath.Join(dir, "bastion_key"), nil)
+	target := newTestSSHServer(t)
+	bastion := newTestSSHServer(t, acceptKey(key.PublicKey(), nil))
+
+	// O bastion é resolvido pelo próprio ssh_config, com a chave dele
+	config := filepath.Join(dir, "config")
+	content := fmt.Sprintf(`Host producao
+  HostName %s
+  Port %d
+  ProxyJump ops@bastion
+
+Host bastion
+  HostName %s
+  Port %d
+  IdentityFile %s
+`, target.Host, target.Port, bastion.Host, bastion.Port, filepath.Join(dir, "bastion_key"))
+	if err := os.WriteFile(config, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	asked := 0
+	deployer := target.Deployer()
+	deployer.Host = "producao"
+	deployer.Port = 0
+	deployer.SSHConfig = config
+	deployer.KnownHosts = []string{filepath.Join(t.TempDir(), "known_hosts")}
+	deployer.ConfirmHostKey = func(question string) (bool, error) {
+		asked++
+		return true, nil
+	}
+
+	if err := deployer.Execute([]string{"true"}); err != nil {
+		t.Fatalf("erro no deploy com ProxyJump: %v", err)
+	}
+	if got := bastion.Forwards(); !reflect.DeepEqual(got, []string{target.Addr}) {
+		t.Errorf("bastion deveria tunelar até o servidor: %v", got)
+	}
+	if asked != 1 {
+		t.Errorf("a chave do bastion deveria ser confirmada uma vez, obtidas %d", asked)
+	}
+}
+
+func TestSSHDeployerJumpAuth(t *testing.T) {
+	dir := t.TempDir()
+	key := writeTestKey(t, filepath.Join(dir, "id_extra"), nil)
+	target := newTestSSHServer(t)
+
+	// Os bastions aceitam apenas a chave adicional ou keyboard-interactive
+	byKey := newTestSSHServer(t, acceptKey(key.PublicKey(), nil))
+	byChallenge := newTestSSHServer(t, func(config *ssh.ServerConfig) {
+		config.PasswordCallback = nil
+		config.KeyboardInteractiveCallback = func(c ssh.ConnMetadata, client ssh.KeyboardInteractiveChallenge) (*ssh.Permissions, error) {
+			answers, err := client("", "", []string{"Código: "}, []bool{true})
+			if err != nil || len(answers) != 1 || answers[0] != "123456" {
+				return nil, errors.New("código incorreto")
+			}
+			return nil, nil
+		}
+	})
+
+	deployer := target.Deployer()
+	deployer.IdentityFiles = []string{filepath.Join(dir, "id_extra")}
+	deployer.KeyboardInteractive = func(name, instruction string, questions []string, echos []bool) ([]string, error) {
+		return []string{"123456"}, nil
+	}
+	for _, srv := range []*testSSHServer{byKey, byChallenge} {
+		deployer.Jump = append(deployer.Jump, JumpHost{
+			Host:    srv.Host,
+			Port:    srv.Port,
+			User:    "jump",
+			HostKey: ssh.FingerprintSHA256(srv.HostKey.PublicKey()),
+		})
+	}
+	defer deployer.Close()
+
+	if err := deployer.Execute([]string{"true"}); err != nil {
+		t.Fatalf("bastions deveriam usar IdentityFiles e KeyboardInteractive: %v", err)
+	}
+}
+
+func TestDialViaHandshakeTimeout(t *testing.T) {
+	bastion := newTestSSHServer(t)
+
+	// Destino que aceita a conexão TCP, mas nunca responde ao handshake
+	silent, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer silent.Close()
+	go func() {
+		for {
+			conn, err := silent.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	via, err := ssh.Dial("tcp", bastion.Addr, &ssh.ClientConfig{
+		User:            "jump",
+		Auth:            []ssh.AuthMethod{ssh.Password("bastion")},
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	_, err = dialVia(via, silent.Addr().String(), &ssh.ClientConfig{
+		User:            "deploy",
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		Timeout:         200 * time.Millisecond,
+	})
+	if err == nil || !strings.Contains(err.Error(), "tempo limite") {
+		t.Fatalf("esperado erro de tempo limite, obtido %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("handshake não interrompido no tempo limite (%s)", elapsed)
+	}
+}
@@ -0,0 +1,356 @@
+package deploy
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HostTarget é um dos servidores de um deploy multi-host; campos vazios
+// herdam a configuração do servidor principal
+type HostTarget struct {
+	Name    string // nome exibido na saída (padrão: Host)
+	Host    string
+	Port    int
+	User    string
+	HostKey string   // chave fixada deste servidor
+	Roles   []string // papéis do servidor (ex: web, worker)
+}
+
+// MultiHostDeployer executa o mesmo deploy SSH em vários servidores
+type MultiHostDeployer struct {
+	Base  *SSHDeployer // configuração comum a todos os hosts
+	Hosts []HostTarget
+	Roles []string // executa apenas nos hosts com algum destes papéis (vazio: todos)
+
+	Concurrency int  // máximo de hosts em paralelo (padrão: todos do lote)
+	Rolling     bool // executa em lotes de BatchSize e para no primeiro lote com falha
+	BatchSize   int  // tamanho do lote no modo rolling (padrão: 1)
+
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// hostResult é o resultado do deploy em um host
+type hostResult struct {
+	Name    string
+	Err     error
+	Skipped bool
+}
+
+// HostDeployers retorna um SSHDeployer por host selecionado
+func (m *MultiHostDeployer) HostDeployers() ([]*SSHDeployer, error) {
+	var deployers []*SSHDeployer
+	for _, target := range m.Hosts {
+		if !hasAnyRole(target.Roles, m.Roles) {
+			continue
+		}
+
+		d := *m.Base
+		d.conn = nil
+		if target.Host != "" {
+			d.Host = target.Host
+		}
+		if target.Port != 0 {
+			d.Port = target.Port
+		}
+		if target.User != "" {
+			d.User = target.User
+		}
+		if target.HostKey != "" {
+			d.HostKey = target.HostKey
+		}
+		d.Name = target.Name
+		if d.Name == "" {
+			d.Name = d.Host
+		}
+		if d.Releases != nil {
+			releases := *d.Releases
+			d.Releases = &releases
+		}
+		deployers = append(deployers, &d)
+	}
+
+	if len(deployers) == 0 {
+		if len(m.Roles) > 0 {
+			return nil, fmt.Errorf("nenhum host com os papéis: %s", strings.Join(m.Roles, ", "))
+		}
+		return nil, fmt.Errorf("nenhum host configurado")
+	}
+	return deployers, nil
+}
+
+// Execute executa os comandos em todos os hosts selecionados
+func (m *MultiHostDeployer) Execute(commands []string) error {
+	// O build local é feito uma vez e o artefato é enviado a todos os hosts
+	if m.Base.Build != nil && m.Base.artifact == nil {
+		base := *m.Base
+		base.Stdout, base.Stderr = m.stdout(), m.stderr()
+		artifact, err := base.prepareArtifact()
+		if err != nil {
+			return err
+		}
+		m.Base.artifact = artifact
+		defer m.Base.discardArtifact()
+	}
+
+	deployers, err := m.HostDeployers()
+	if err != nil {
+		return err
+	}
+
+	// Todos os hosts recebem a mesma release, para que o rollback seja uniforme
+	if m.Base.Releases != nil && m.Base.Releases.Name == "" {
+		name := time.Now().UTC().Format("20060102150405")
+		for _, d := range deployers {
+			d.Releases.Name = name
+		}
+	}
+
+	var mu sync.Mutex
+	width := 0
+	for _, d := range deployers {
+		if len(d.Name) > width {
+			width = len(d.Name)
+		}
+	}
+
+	results := make([]hostResult, len(deployers))
+	writers := make([]*prefixWriter, 0, 2*len(deployers))
+	for i, d := range deployers {
+		results[i] = hostResult{Name: d.Name, Skipped: true}
+
+		prefix := fmt.Sprintf("[%-*s] ", width, d.Name)
+		stdout := &prefixWriter{mu: &mu, w: m.stdout(), prefix: prefix}
+		stderr := &prefixWriter{mu: &mu, w: m.stderr(), prefix: prefix}
+		d.Stdout, d.Stderr = stdout, stderr
+		writers = append(writers, stdout, stderr)
+	}
+
+	batches := m.batches(len(deployers))
+	fmt.Fprintf(m.stdout(), "🖥️  Deploy em %d host(s)", len(deployers))
+	if m.Rolling {
+		fmt.Fprintf(m.stdout(), " em %d lote(s)", len(batches))
+	}
+	fmt.Fprintln(m.stdout())
+
+	stopped := false
+	for n, batch := range batches {
+		if m.Rolling {
+			names := make([]string, len(batch))
+			for i, idx := range batch {
+				names[i] = deployers[idx].Name
+			}
+			mu.Lock()
+			fmt.Fprintf(m.stdout(), "📦 Lote %d/%d: %s\n", n+1, len(batches), strings.Join(names, ", "))
+			mu.Unlock()
+		}
+
+		m.runBatch(deployers, batch, commands, results)
+		for _, w := range writers {
+			w.Flush()
+		}
+
+		if m.Rolling && batchFailed(results, batch) {
+			stopped = n+1 < len(batches)
+			break
+		}
+	}
+
+	return m.summarize("deploy", results, stopped)
+}
+
+// Rollback faz o rollback em todos os hosts selecionados e retorna a release
+// ativada em cada um. O destino é conferido em todos os servidores antes da
+// primeira troca, para que uma release ausente em um host não deixe os
+// servidores em releases diferentes; uma falha durante a troca não
+// interrompe os demais hosts e aparece no resumo.
+func (m *MultiHostDeployer) Rollback(release string) ([]string, error) {
+	deployers, err := m.HostDeployers()
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range deployers {
+		d.Stdout, d.Stderr = m.stdout(), m.stderr()
+		defer d.Close()
+	}
+
+	targets := make([]string, len(deployers))
+	var invalid []string
+	for i, d := range deployers {
+		if targets[i], err = d.RollbackTarget(release); err != nil {
+			invalid = append(invalid, fmt.Sprintf("%s: %v", d.Name, err))
+		}
+	}
+	if len(invalid) > 0 {
+		return nil, fmt.Errorf("rollback não iniciado, nenhum host foi alterado:\n   %s", strings.Join(invalid, "\n   "))
+	}
+
+	results := make([]hostResult, len(deployers))
+	for i, d := range deployers {
+		fmt.Fprintf(m.stdout(), "🖥️  %s\n", d.Name)
+		_, err := d.Rollback(targets[i])
+		results[i] = hostResult{Name: d.Name, Err: err}
+	}
+	return targets, m.summarize("rollback", results, false)
+}
+
+// runBatch executa o deploy nos hosts do lote, respeitando Concurrency
+func (m *MultiHostDeployer) runBatch(deployers []*SSHDeployer, batch []int, commands []string, results []hostResult) {
+	limit := m.Concurrency
+	if limit <= 0 || limit > len(batch) {
+		limit = len(batch)
+	}
+
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	for _, idx := range batch {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(idx int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			defer deployers[idx].Close()
+
+			err := deployers[idx].Execute(commands)
+			results[idx] = hostResult{Name: deployers[idx].Name, Err: err}
+		}(idx)
+	}
+	wg.Wait()
+}
+
+// batches divide os índices dos hosts em lotes: um único lote no modo
+// paralelo ou lotes de BatchSize no modo rolling
+func (m *MultiHostDeployer) batches(total int) [][]int {
+	size := total
+	if m.Rolling {
+		size = m.BatchSize
+		if size <= 0 {
+			size = 1
+		}
+	}
+
+	var batches [][]int
+	for start := 0; start < total; start += size {
+		end := start + size
+		if end > total {
+			end = total
+		}
+		batch := make([]int, 0, end-start)
+		for i := start; i < end; i++ {
+			batch = append(batch, i)
+		}
+		batches = append(batches, batch)
+	}
+	return batches
+}
+
+// summarize exibe o resultado de cada host e retorna erro se algum falhou;
+// action nomeia a operação na mensagem de erro
+func (m *MultiHostDeployer) summarize(action string, results []hostResult, stopped bool) error {
+	out := m.stdout()
+	failed := 0
+
+	fmt.Fprintln(out, "\n📋 Resumo por host:")
+	for _, r := range results {
+		switch {
+		case r.Skipped:
+			fmt.Fprintf(out, "   ⏭️  %s: não executado\n", r.Name)
+		case r.Err != nil:
+			failed++
+			fmt.Fprintf(out, "   ❌ %s: %v\n", r.Name, r.Err)
+		default:
+			fmt.Fprintf(out, "   ✅ %s\n", r.Name)
+		}
+	}
+
+	if failed == 0 {
+		return nil
+	}
+	if stopped {
+		return fmt.Errorf("%s falhou em %d de %d host(s); lotes seguintes não executados", action, failed, len(results))
+	}
+	return fmt.Errorf("%s falhou em %d de %d host(s)", action, failed, len(results))
+}
+
+func (m *MultiHostDeployer) stdout() io.Writer {
+	if m.Stdout != nil {
+		return m.Stdout
+	}
+	return os.Stdout
+}
+
+func (m *MultiHostDeployer) stderr() io.Writer {
+	if m.Stderr != nil {
+		return m.Stderr
+	}
+	return os.Stderr
+}
+
+func batchFailed(results []hostResult, batch []int) bool {
+	for _, idx := range batch {
+		if results[idx].Err != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// hasAnyRole informa se o host tem algum dos papéis pedidos; sem filtro,
+// todos os hosts são selecionados
+func hasAnyRole(hostRoles, wanted []string) bool {
+	if len(wanted) == 0 {
+		return true
+	}
+	for _, want := range wanted {
+		for _, role := range hostRoles {
+			if role == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// prefixWriter prefixa cada linha com o nome do host. As linhas só são
+// escritas quando completas, e o mutex compartilhado evita que saídas de
+// hosts diferentes se misturem na mesma linha.
+type prefixWriter struct {
+	mu     *sync.Mutex
+	w      io.Writer
+	prefix string
+	buf    []byte
+}
+
+func (p *prefixWriter) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.buf = append(p.buf, b...)
+	for {
+		i := bytes.IndexByte(p.buf, '\n')
+		if i < 0 {
+			break
+		}
+		if _, err := fmt.Fprintf(p.w, "%s%s\n", p.prefix, p.buf[:i]); err != nil {
+			return 0, err
+		}
+		p.buf = p.buf[i+1:]
+	}
+	return len(b), nil
+}
+
+// Flush escreve a última linha incompleta, se houver
+func (p *prefixWriter) Flush() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.buf) > 0 {
+		fmt.Fprintf(p.w, "%s%s\n", p.prefix, p.buf)
+		p.buf = nil
+	}
+}
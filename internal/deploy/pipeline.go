@@ -0,0 +1,264 @@
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Estágios do pipeline de deploy
+const (
+	StagePreDeploy  = "pre_deploy"
+	StageUpload     = "upload"
+	StageBuild      = "build"
+	StageMigrate    = "migrate"
+	StageSwitch     = "switch"
+	StagePostDeploy = "post_deploy"
+	StageOnFailure  = "on_failure"
+	StageOnSuccess  = "on_success"
+)
+
+// Onde os comandos de um estágio são executados
+const (
+	TargetLocal  = "local"
+	TargetRemote = "remote"
+)
+
+// stageOrder é a sequência principal do pipeline; on_failure e on_success
+// rodam depois, conforme o resultado
+var stageOrder = []string{StagePreDeploy, StageUpload, StageBuild, StageMigrate, StageSwitch, StagePostDeploy}
+
+// localStopDelay é a espera pela saída de um comando local após o timeout;
+// variável para os testes
+var localStopDelay = 2 * time.Second
+
+// Stage são os comandos de um estágio do pipeline
+type Stage struct {
+	Commands        []string
+	Scripts         []Script      // executados depois de Commands
+	Dir             string        // diretório de trabalho; relativo, parte do diretório padrão do estágio
+	Target          string        // TargetLocal ou TargetRemote (padrão: conforme o tipo de deploy)
+	Timeout         time.Duration // limite para o estágio inteiro (zero: sem limite)
+	ContinueOnError bool          // uma falha apenas gera um aviso e o deploy segue
+}
+
+// Pipeline associa cada estágio aos seus comandos. A lista commands do
+// deploy equivale ao estágio build.
+type Pipeline map[string]Stage
+
+// StageNames retorna os estágios aceitos, na ordem de execução
+func StageNames() []string {
+	return append(append([]string{}, stageOrder...), StageOnFailure, StageOnSuccess)
+}
+
+// Validate verifica os nomes e targets dos estágios
+func (p Pipeline) Validate() error {
+	for _, name := range sortedKeys(p) {
+		if !isStageName(name) {
+			return fmt.Errorf("estágio desconhecido: %s (use %s)", name, strings.Join(StageNames(), ", "))
+		}
+		stage := p[name]
+		switch stage.Target {
+		case "", TargetLocal, TargetRemote:
+		default:
+			return fmt.Errorf("stages.%s.target inválido: %s (use %q ou %q)", name, stage.Target, TargetLocal, TargetRemote)
+		}
+		if stage.Timeout < 0 {
+			return fmt.Errorf("stages.%s.timeout inválido: %s", name, stage.Timeout)
+		}
+		for _, script := range stage.Scripts {
+			if err := script.validate(); err != nil {
+				return fmt.Errorf("stages.%s: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// withCommands retorna uma cópia do pipeline com a lista commands no estágio
+// build, que não pode ter comandos próprios ao mesmo tempo
+func (p Pipeline) withCommands(commands []string) (Pipeline, error) {
+	stages := make(Pipeline, len(p)+1)
+	for name, stage := range p {
+		stages[name] = stage
+	}
+	if len(commands) == 0 {
+		return stages, nil
+	}
+	build := stages[StageBuild]
+	if len(build.Commands) > 0 {
+		return nil, fmt.Errorf("use commands ou stages.build, não ambos")
+	}
+	build.Commands = commands
+	stages[StageBuild] = build
+	return stages, nil
+}
+
+// stageRunner executa os comandos de um estágio; ctx é cancelado quando o
+// timeout do estágio expira
+type stageRunner func(ctx context.Context, name string, stage Stage) error
+
+// pipelineRun é a execução do pipeline por um deployer, que fornece as ações
+// próprias de cada estágio (envio de arquivos, troca de release...) e os
+// runners dos targets que suporta
+type pipelineRun struct {
+	Stages        Pipeline
+	Actions       map[string]func() error // executadas antes dos comandos do estágio
+	Runners       map[string]stageRunner
+	DefaultTarget string
+	// OnFailure desfaz o que o deployer deixou incompleto, antes do estágio
+	// on_failure, e pode complementar o erro
+	OnFailure func(err error) error
+	Stdout    io.Writer
+
+	announce bool // exibe o nome dos estágios (pipeline configurado)
+}
+
+// newPipelineRun monta a execução do pipeline com a lista commands no
+// estágio build
+func newPipelineRun(stages Pipeline, commands []string, stdout io.Writer) (*pipelineRun, error) {
+	if err := stages.Validate(); err != nil {
+		return nil, err
+	}
+	merged, err := stages.withCommands(commands)
+	if err != nil {
+		return nil, err
+	}
+	return &pipelineRun{
+		Stages:   merged,
+		Actions:  map[string]func() error{},
+		Runners:  map[string]stageRunner{},
+		Stdout:   stdout,
+		announce: len(stages) > 0,
+	}, nil
+}
+
+// Run executa os estágios em ordem. Na primeira falha o deploy é
+// interrompido e on_failure é executado; se tudo passar, on_success.
+func (p *pipelineRun) Run() error {
+	for _, name := range stageOrder {
+		if err := p.runStage(name); err != nil {
+			return p.fail(err)
+		}
+	}
+	if err := p.runStage(StageOnSuccess); err != nil {
+		return fmt.Errorf("deploy concluído, mas o estágio %s falhou: %w", StageOnSuccess, err)
+	}
+	return nil
+}
+
+func (p *pipelineRun) fail(err error) error {
+	if p.OnFailure != nil {
+		err = p.OnFailure(err)
+	}
+	if hookErr := p.runStage(StageOnFailure); hookErr != nil {
+		fmt.Fprintf(p.Stdout, "⚠️  Erro no estágio %s: %v\n", StageOnFailure, hookErr)
+	}
+	return err
+}
+
+// runStage executa a ação do deployer e depois os comandos do estágio
+func (p *pipelineRun) runStage(name string) error {
+	if action := p.Actions[name]; action != nil {
+		if err := action(); err != nil {
+			return err
+		}
+	}
+
+	stage := p.Stages[name]
+	if len(stage.Commands) == 0 && len(stage.Scripts) == 0 {
+		return nil
+	}
+	target := stage.Target
+	if target == "" {
+		target = p.DefaultTarget
+	}
+	run := p.Runners[target]
+	if run == nil {
+		return fmt.Errorf("estágio %s: target %s não disponível neste tipo de deploy", name, target)
+	}
+	if p.announce {
+		fmt.Fprintf(p.Stdout, "▶️  Estágio %s (%s)\n", name, target)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if stage.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), stage.Timeout)
+	}
+	err := run(ctx, name, stage)
+	timedOut := ctx.Err() == context.DeadlineExceeded
+	cancel()
+
+	if err != nil && timedOut {
+		err = fmt.Errorf("estágio %s excedeu o tempo limite de %s: %w", name, stage.Timeout, err)
+	}
+	if err != nil && stage.ContinueOnError {
+		fmt.Fprintf(p.Stdout, "⚠️  Estágio %s falhou, continuando (continue_on_error): %v\n", name, err)
+		return nil
+	}
+	return err
+}
+
+// localStage descreve a execução de comandos na máquina local
+type localStage struct {
+	Dir    string
+	Env    map[string]string
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// run executa os comandos em ordem pelo shell do sistema; o comando em
+// andamento é encerrado se ctx for cancelado
+func (l localStage) run(ctx context.Context, commands []string) error {
+	for i, cmd := range commands {
+		fmt.Fprintf(l.Stdout, "  [%d/%d] Executando: %s\n", i+1, len(commands), cmd)
+
+		command := localCommand(ctx, cmd)
+		command.Dir = l.Dir
+		command.Env = commandEnv(l.Env)
+		command.Stdout = l.Stdout
+		command.Stderr = l.Stderr
+		command.WaitDelay = localStopDelay
+
+		if err := command.Run(); err != nil {
+			return fmt.Errorf("erro ao executar '%s': %w", cmd, err)
+		}
+	}
+	return nil
+}
+
+// localStageDir resolve o diretório local de um estágio a partir da raiz do projeto
+func localStageDir(root, dir string) string {
+	if dir == "" {
+		return root
+	}
+	if filepath.IsAbs(dir) {
+		return dir
+	}
+	return filepath.Join(root, dir)
+}
+
+// remoteStageDir resolve o diretório remoto de um estágio a partir do
+// diretório padrão (vazio: home do usuário)
+func remoteStageDir(base, dir string) string {
+	if dir == "" {
+		return base
+	}
+	if path.IsAbs(dir) || base == "" {
+		return dir
+	}
+	return path.Join(base, dir)
+}
+
+func isStageName(name string) bool {
+	for _, stage := range StageNames() {
+		if stage == name {
+			return true
+		}
+	}
+	return false
+}
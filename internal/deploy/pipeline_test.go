@@ -0,0 +1,174 @@
+package deploy
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPipelineValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		pipeline Pipeline
+		wantErr  string
+	}{
+		{"vazio", nil, ""},
+		{"válido", Pipeline{StageMigrate: {Commands: []string{"true"}, Target: TargetRemote}, StageOnFailure: {}}, ""},
+		{"estágio desconhecido", Pipeline{"deploy": {}}, "estágio desconhecido: deploy"},
+		{"target inválido", Pipeline{StageBuild: {Target: "cluster"}}, "stages.build.target inválido"},
+		{"timeout negativo", Pipeline{StageBuild: {Timeout: -time.Second}}, "stages.build.timeout inválido"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.pipeline.Validate()
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("erro inesperado: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("esperado erro %q, obtido %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	stages := Pipeline{StageBuild: {Commands: []string{"make"}}}
+	if _, err := stages.withCommands([]string{"true"}); err == nil {
+		t.Error("esperado erro com commands e stages.build ao mesmo tempo")
+	}
+	merged, err := Pipeline{StageMigrate: {Commands: []string{"migrate"}}}.withCommands([]string{"make"})
+	if err != nil || strings.Join(merged[StageBuild].Commands, ",") != "make" {
+		t.Errorf("commands deveria virar o estágio build: %v, %v", merged, err)
+	}
+}
+
+func TestSSHDeployerPipeline(t *testing.T) {
+	srv := newTestSSHServer(t)
+	base := filepath.Join(t.TempDir(), "app")
+	project := t.TempDir()
+	logFile := filepath.Join(t.TempDir(), "pipeline.log")
+	record := func(label string) string {
+		return "echo " + label + ":$(basename \"$PWD\") >> " + logFile
+	}
+
+	out := &bytes.Buffer{}
+	deployer := srv.Deployer()
+	deployer.Stdout = out
+	deployer.ProjectPath = project
+	deployer.Releases = &Releases{Path: base}
+	deployer.Pipeline = Pipeline{
+		StagePreDeploy:  {Commands: []string{"touch pre_deploy.ok"}, Target: TargetLocal},
+		StageMigrate:    {Commands: []string{record("migrate")}, Dir: "db"},
+		StageSwitch:     {Commands: []string{record("switch")}},
+		StagePostDeploy: {Commands: []string{"false"}, ContinueOnError: true},
+		StageOnFailure:  {Commands: []string{"echo on_failure >> " + logFile}},
+		StageOnSuccess:  {Commands: []string{"echo on_success >> " + logFile}, Target: TargetLocal},
+	}
+	defer deployer.Close()
+
+	deployer.Releases.Name = "r1"
+	if err := deployer.Execute([]string{"mkdir db", record("build")}); err != nil {
+		t.Fatalf("erro no deploy r1: %v\n%s", err, out)
+	}
+	data, _ := os.ReadFile(logFile)
+	if got, want := string(data), "build:r1\nmigrate:db\nswitch:current\non_success\n"; got != want {
+		t.Errorf("estágios esperados %q, obtidos %q", want, got)
+	}
+	if _, err := os.Stat(filepath.Join(project, "pre_deploy.ok")); err != nil {
+		t.Errorf("pre_deploy local não executado na raiz do projeto: %v", err)
+	}
+	for _, want := range []string{"▶️  Estágio migrate (remote)", "Estágio post_deploy falhou, continuando"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("saída sem %q:\n%s", want, out)
+		}
+	}
+
+	// Falha antes da troca: release descartada, current inalterado e
+	// on_failure executado no lugar de on_success
+	os.Remove(logFile)
+	migrate := deployer.Pipeline[StageMigrate]
+	migrate.Commands = []string{"exit 7"}
+	deployer.Pipeline[StageMigrate] = migrate
+	deployer.Releases.Name = "r2"
+	err := deployer.Execute([]string{"mkdir db"})
+	if err == nil || !strings.Contains(err.Error(), "'exit 7'") || !strings.Contains(err.Error(), "release r2 descartada") {
+		t.Fatalf("esperado erro do migrate com a release descartada, obtido %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(base, "releases", "r2")); !os.IsNotExist(err) {
+		t.Error("release r2 com falha deveria ter sido removida")
+	}
+	if target, _ := os.Readlink(filepath.Join(base, "current")); target != filepath.Join("releases", "r1") {
+		t.Errorf("current alterado após falha: %s", target)
+	}
+	if data, _ := os.ReadFile(logFile); string(data) != "on_failure\n" {
+		t.Errorf("esperado apenas on_failure após a falha, obtido %q", data)
+	}
+
+	deployer.Pipeline[StageBuild] = Stage{Commands: []string{"true"}}
+	if err := deployer.Execute([]string{"true"}); err == nil || !strings.Contains(err.Error(), "não ambos") {
+		t.Errorf("esperado erro com commands e stages.build ao mesmo tempo, obtido %v", err)
+	}
+}
+
+func TestPipelineTimeout(t *testing.T) {
+	defer func(delay time.Duration) { localStopDelay = delay }(localStopDelay)
+	localStopDelay = 100 * time.Millisecond
+
+	for _, target := range []string{TargetLocal, TargetRemote} {
+		t.Run(target, func(t *testing.T) {
+			srv := newTestSSHServer(t)
+			marker := filepath.Join(t.TempDir(), "failed")
+
+			deployer := srv.Deployer()
+			deployer.Stdout = &bytes.Buffer{}
+			deployer.Stderr = &bytes.Buffer{}
+			deployer.ProjectPath = t.TempDir()
+			deployer.Pipeline = Pipeline{
+				StageMigrate:   {Commands: []string{"sleep 3"}, Target: target, Timeout: 200 * time.Millisecond},
+				StageOnFailure: {Commands: []string{"touch " + marker}},
+			}
+			defer deployer.Close()
+
+			start := time.Now()
+			err := deployer.Execute(nil)
+			if err == nil || !strings.Contains(err.Error(), "excedeu o tempo limite de 200ms") {
+				t.Fatalf("esperado erro de timeout, obtido %v", err)
+			}
+			if elapsed := time.Since(start); elapsed > 2*time.Second {
+				t.Errorf("estágio não interrompido no timeout (%s)", elapsed)
+			}
+			// on_failure roda no servidor, mesmo após a conexão ser encerrada
+			if _, err := os.Stat(marker); err != nil {
+				t.Errorf("on_failure não executado após o timeout: %v", err)
+			}
+		})
+	}
+}
+
+func TestGitDeployerLocalStageShell(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// Estágios locais rodam pelo shell, com variáveis e operadores
+	deployer := &GitDeployer{
+		ProjectPath: dir,
+		Environment: map[string]string{"APP_ENV": "production"},
+		Pipeline: Pipeline{
+			StagePostDeploy: {Commands: []string{`test -d .git && echo "$APP_ENV" > env.txt`}},
+		},
+	}
+	if err := deployer.Execute(nil); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "env.txt"))
+	if err != nil {
+		t.Fatalf("comando não executado pelo shell: %v", err)
+	}
+	if strings.TrimSpace(string(data)) != "production" {
+		t.Errorf("variável não expandida: %q", data)
+	}
+}
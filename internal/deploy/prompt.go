@@ -0,0 +1,41 @@
+package deploy
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/tstest3213/00cli/internal/tty"
+)
+
+// promptMu serializa perguntas feitas por deploys executados em paralelo
+var promptMu sync.Mutex
+
+// errNotInteractive indica que não há terminal para perguntar ao usuário
+var errNotInteractive = errors.New("terminal não interativo")
+
+// confirmOnTerminal faz uma pergunta sim/não no terminal
+func confirmOnTerminal(question string) (bool, error) {
+	if !tty.IsTerminal(os.Stdin) {
+		return false, errNotInteractive
+	}
+
+	promptMu.Lock()
+	defer promptMu.Unlock()
+
+	fmt.Printf("%s (sim/não): ", question)
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false, fmt.Errorf("erro ao ler resposta: %w", err)
+	}
+
+	switch strings.ToLower(strings.TrimSpace(line)) {
+	case "s", "sim", "y", "yes":
+		return true, nil
+	default:
+		return false, nil
+	}
+}
@@ -0,0 +1,284 @@
+package deploy
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Provision descreve os arquivos de provisionamento enviados ao servidor
+// antes dos comandos de deploy
+type Provision struct {
+	LocalPath string   // diretório local (ex: <projeto>/provision)
+	Files     []string // arquivos relativos a LocalPath; vazio envia o diretório inteiro
+	Target    string   // diretório remoto de destino
+}
+
+// provisionFile é um arquivo local a ser sincronizado
+type provisionFile struct {
+	RelPath  string // caminho relativo com separador "/"
+	Local    string
+	Mode     fs.FileMode
+	Checksum string
+}
+
+// collect lista os arquivos a enviar e calcula seus checksums
+func (p *Provision) collect() ([]provisionFile, error) {
+	var rels []string
+
+	if len(p.Files) > 0 {
+		for _, file := range p.Files {
+			rel := filepath.Clean(file)
+			if filepath.IsAbs(rel) || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				return nil, fmt.Errorf("arquivo de provision fora do diretório: %s", file)
+			}
+			rels = append(rels, rel)
+		}
+	} else {
+		err := filepath.WalkDir(p.LocalPath, func(file string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !entry.Type().IsRegular() {
+				return nil
+			}
+			rel, err := filepath.Rel(p.LocalPath, file)
+			if err != nil {
+				return err
+			}
+			rels = append(rels, rel)
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("erro ao listar diretório de provision: %w", err)
+		}
+	}
+
+	files := make([]provisionFile, 0, len(rels))
+	for _, rel := range rels {
+		local := filepath.Join(p.LocalPath, rel)
+		info, err := os.Stat(local)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler arquivo de provision: %w", err)
+		}
+		if !info.Mode().IsRegular() {
+			return nil, fmt.Errorf("arquivo de provision não é um arquivo regular: %s", rel)
+		}
+
+		sum, err := fileChecksum(local)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao calcular checksum de %s: %w", rel, err)
+		}
+
+		files = append(files, provisionFile{
+			RelPath:  filepath.ToSlash(rel),
+			Local:    local,
+			Mode:     info.Mode().Perm(),
+			Checksum: sum,
+		})
+	}
+
+	return files, nil
+}
+
+// syncProvision envia os arquivos de provision que mudaram para o servidor
+func (d *SSHDeployer) syncProvision(client sessionOpener) error {
+	p := d.Provision
+	if p == nil || p.LocalPath == "" {
+		return nil
+	}
+	if _, err := os.Stat(p.LocalPath); os.IsNotExist(err) {
+		if len(p.Files) > 0 {
+			return fmt.Errorf("diretório de provision não encontrado: %s", p.LocalPath)
+		}
+		return nil
+	}
+
+	files, err := p.collect()
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return nil
+	}
+	if p.Target == "" {
+		fmt.Fprintln(d.stdout(), "⚠️  provision.target não configurado; arquivos de provision não foram enviados")
+		return nil
+	}
+
+	fmt.Fprintf(d.stdout(), "📁 Sincronizando provision em %s...\n", p.Target)
+
+	remote, err := remoteChecksums(client, p.Target, files)
+	if err != nil {
+		return err
+	}
+
+	var changed []provisionFile
+	for _, f := range files {
+		if remote[f.RelPath] != f.Checksum {
+			changed = append(changed, f)
+		}
+	}
+
+	if len(changed) > 0 {
+		dirs := map[string]bool{p.Target: true}
+		for _, f := range changed {
+			dirs[path.Join(p.Target, path.Dir(f.RelPath))] = true
+		}
+		if err := runRemote(client, "mkdir -p "+quoteAll(sortedKeys(dirs))); err != nil {
+			return fmt.Errorf("erro ao criar diretórios remotos: %w", err)
+		}
+
+		if err := d.uploadProvision(client, changed); err != nil {
+			return err
+		}
+	}
+
+	// Aplicar as permissões locais, inclusive nos arquivos que já existiam
+	if err := runRemote(client, chmodScript(p.Target, files)); err != nil {
+		return fmt.Errorf("erro ao ajustar permissões remotas: %w", err)
+	}
+
+	fmt.Fprintf(d.stdout(), "  ✅ %d enviado(s), %d sem alterações\n", len(changed), len(files)-len(changed))
+	return nil
+}
+
+// uploadProvision envia os arquivos alterados em uma única sessão de
+// transferência e confere os checksums remotos em lote
+func (d *SSHDeployer) uploadProvision(client sessionOpener, files []provisionFile) error {
+	p := d.Provision
+	transfer, err := newFileTransfer(client)
+	if err != nil {
+		return err
+	}
+	defer transfer.Close()
+
+	for _, f := range files {
+		fmt.Fprintf(d.stdout(), "  ⬆️  %s\n", f.RelPath)
+		if err := transfer.UploadFile(f.Local, path.Join(p.Target, f.RelPath), f.Mode); err != nil {
+			return fmt.Errorf("erro ao enviar %s: %w", f.RelPath, err)
+		}
+	}
+
+	// Servidores sem sha256sum/shasum não retornam checksums e não são conferidos
+	remote, err := remoteChecksums(client, p.Target, files)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if sum, ok := remote[f.RelPath]; ok && sum != f.Checksum {
+			return fmt.Errorf("checksum de %s divergente após o upload", f.RelPath)
+		}
+	}
+	return nil
+}
+
+// remoteChecksums obtém o sha256 dos arquivos já existentes no servidor
+func remoteChecksums(client sessionOpener, target string, files []provisionFile) (map[string]string, error) {
+	names := make([]string, len(files))
+	for i, f := range files {
+		names[i] = f.RelPath
+	}
+
+	script := fmt.Sprintf(
+		`cd %s 2>/dev/null || exit 0; for f in %s; do [ -f "$f" ] && { sha256sum "$f" 2>/dev/null || shasum -a 256 "$f"; }; done; true`,
+		shellQuote(target), quoteAll(names))
+
+	output, err := runRemoteOutput(client, script)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao obter checksums remotos: %w", err)
+	}
+
+	sums := make(map[string]string)
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		sum, name, ok := strings.Cut(scanner.Text(), "  ")
+		if ok {
+			sums[name] = sum
+		}
+	}
+	return sums, nil
+}
+
+// chmodScript gera os comandos chmod agrupados por permissão
+func chmodScript(target string, files []provisionFile) string {
+	byMode := make(map[string][]string)
+	for _, f := range files {
+		mode := fmt.Sprintf("%04o", f.Mode)
+		byMode[mode] = append(byMode[mode], path.Join(target, f.RelPath))
+	}
+
+	var cmds []string
+	for _, mode := range sortedKeys(byMode) {
+		cmds = append(cmds, "chmod "+mode+" "+quoteAll(byMode[mode]))
+	}
+	return strings.Join(cmds, " && ")
+}
+
+// runRemote executa um comando auxiliar no servidor, sem exibir a saída
+func runRemote(client sessionOpener, cmd string) error {
+	_, err := runRemoteOutput(client, cmd)
+	return err
+}
+
+// runRemoteOutput executa um comando auxiliar no servidor e retorna o stdout
+func runRemoteOutput(client sessionOpener, cmd string) (string, error) {
+	session, err := client.NewSession()
+	if err != nil {
+		return "", fmt.Errorf("erro ao criar sessão: %w", err)
+	}
+	defer session.Close()
+
+	var stderr strings.Builder
+	session.Stderr = &stderr
+
+	output, err := session.Output(cmd)
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", err
+	}
+	return string(output), nil
+}
+
+// fileChecksum calcula o sha256 de um arquivo local
+func fileChecksum(file string) (string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// quoteAll protege e junta uma lista de argumentos de shell
+func quoteAll(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = shellQuote(arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
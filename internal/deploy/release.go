@@ -0,0 +1,339 @@
+package deploy
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+)
+
+const defaultKeepReleases = 5
+
+// Releases configura o layout de releases no servidor:
+//
+//	<Path>/releases/<nome>/  uma pasta por deploy
+//	<Path>/shared/           arquivos persistentes ligados em cada release
+//	<Path>/current           symlink para a release ativa
+type Releases struct {
+	Path    string   // diretório base no servidor (ex: /var/www/app)
+	Name    string   // nome da release (padrão: timestamp UTC); a ordem das releases é a dos nomes
+	Keep    int      // quantidade de releases mantidas (padrão: 5)
+	Shared  []string // caminhos relativos em shared/; terminados em "/" são diretórios
+	Restart []string // comandos executados em current/ após a troca e no rollback
+}
+
+func (r *Releases) releasesDir() string { return path.Join(r.Path, "releases") }
+func (r *Releases) sharedDir() string   { return path.Join(r.Path, "shared") }
+func (r *Releases) currentLink() string { return path.Join(r.Path, "current") }
+
+func (r *Releases) keep() int {
+	if r.Keep > 0 {
+		return r.Keep
+	}
+	return defaultKeepReleases
+}
+
+// inRelease informa se um destino remoto fica dentro da pasta de cada
+// release: com Releases configurado, destinos relativos são da release
+func (d *SSHDeployer) inRelease(target string) bool {
+	return d.Releases != nil && !path.IsAbs(target)
+}
+
+// releaseSubdir valida um destino relativo à release (vazio: a própria release)
+func releaseSubdir(option, target string) (string, error) {
+	rel := path.Clean(target)
+	if rel == ".." || strings.HasPrefix(rel, "../") {
+		return "", fmt.Errorf("%s fora da release: %s", option, target)
+	}
+	return rel, nil
+}
+
+// validateReleaseName impede nomes que escapem do diretório releases/
+func validateReleaseName(name string) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, "/\\") {
+		return fmt.Errorf("nome de release inválido: %q", name)
+	}
+	return nil
+}
+
+// releaseRun é a release criada por um deploy. Até a troca de current ela
+// pode ser descartada sem afetar a release ativa.
+type releaseRun struct {
+	name        string
+	dir         string
+	buildTarget string // destino do artefato dentro da release (vazio: fora dela)
+	syncTarget  string // destino do sync dentro da release (vazio: fora dela)
+	created     bool
+	switched    bool
+}
+
+// newReleaseRun valida a configuração da release antes de qualquer acesso ao
+// servidor
+func (d *SSHDeployer) newReleaseRun() (*releaseRun, error) {
+	r := d.Releases
+	if r.Path == "" {
+		return nil, fmt.Errorf("releases.path não configurado")
+	}
+
+	name := r.Name
+	if name == "" {
+		name = time.Now().UTC().Format("20060102150405")
+	}
+	if err := validateReleaseName(name); err != nil {
+		return nil, err
+	}
+	release := &releaseRun{name: name, dir: path.Join(r.releasesDir(), name)}
+
+	var err error
+	if d.Build != nil && d.Build.Output != "" && d.inRelease(d.Build.Target) {
+		if release.buildTarget, err = releaseSubdir("build.target", d.Build.Target); err != nil {
+			return nil, err
+		}
+	}
+	if d.syncIntoRelease() {
+		if release.syncTarget, err = releaseSubdir("sync.target", d.Sync.Target); err != nil {
+			return nil, err
+		}
+	}
+	return release, nil
+}
+
+// createRelease cria a pasta da release com os caminhos compartilhados, o
+// artefato do build e os arquivos do sync
+func (d *SSHDeployer) createRelease(client sessionOpener, release *releaseRun) error {
+	fmt.Fprintf(d.stdout(), "📂 Criando release %s em %s\n", release.name, d.Releases.releasesDir())
+
+	exists, err := runRemoteOutput(client, fmt.Sprintf("[ -e %s ] && echo yes; true", shellQuote(release.dir)))
+	if err != nil {
+		return fmt.Errorf("erro ao verificar release: %w", err)
+	}
+	if strings.TrimSpace(exists) == "yes" {
+		return fmt.Errorf("release %s já existe no servidor", release.name)
+	}
+	release.created = true
+
+	if d.syncIntoRelease() {
+		// Partir dos arquivos da release atual, para enviar só as diferenças
+		if err := runRemote(client, d.seedReleaseScript(release.dir)); err != nil {
+			return fmt.Errorf("erro ao copiar a release atual: %w", err)
+		}
+	}
+
+	if err := runRemote(client, d.prepareReleaseScript(release.dir)); err != nil {
+		return fmt.Errorf("erro ao preparar release: %w", err)
+	}
+
+	if d.hasArtifact() && d.inRelease(d.Build.Target) {
+		if err := d.uploadArtifact(client, path.Join(release.dir, release.buildTarget)); err != nil {
+			return err
+		}
+	}
+	if d.syncIntoRelease() {
+		if _, err := d.syncFiles(client, path.Join(release.dir, release.syncTarget)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// releaseExports exporta as variáveis da release para os comandos remotos
+func (d *SSHDeployer) releaseExports(release *releaseRun) string {
+	return fmt.Sprintf("export RELEASE_ID=%s RELEASE_DIR=%s SHARED_DIR=%s",
+		shellQuote(release.name), shellQuote(release.dir), shellQuote(d.Releases.sharedDir()))
+}
+
+// activateRelease troca o symlink current para a release e executa os
+// comandos de reinício
+func (d *SSHDeployer) activateRelease(client sessionOpener, release *releaseRun) error {
+	if err := d.switchRelease(client, release.name); err != nil {
+		return err
+	}
+	release.switched = true
+	fmt.Fprintf(d.stdout(), "🔗 current -> releases/%s\n", release.name)
+
+	return d.runRestartHooks(client)
+}
+
+// discardRelease remove a release incompleta após uma falha antes da troca;
+// current continua apontando para a anterior
+func (d *SSHDeployer) discardRelease(client sessionOpener, release *releaseRun, err error) error {
+	if !release.created || release.switched {
+		return err
+	}
+	if rmErr := runRemote(client, "rm -rf "+shellQuote(release.dir)); rmErr != nil && d.Verbose {
+		fmt.Fprintf(d.stdout(), "⚠️  Não foi possível remover a release incompleta: %v\n", rmErr)
+	}
+	return fmt.Errorf("%w (release %s descartada, current inalterado)", err, release.name)
+}
+
+// prepareReleaseScript cria a pasta da release e liga os caminhos compartilhados
+func (d *SSHDeployer) prepareReleaseScript(releaseDir string) string {
+	r := d.Releases
+	cmds := []string{
+		"mkdir -p " + quoteAll([]string{releaseDir, r.sharedDir()}),
+	}
+
+	for _, entry := range r.Shared {
+		isDir := strings.HasSuffix(entry, "/")
+		rel := strings.Trim(path.Clean("/"+entry), "/")
+		if rel == "" {
+			continue
+		}
+
+		shared := path.Join(r.sharedDir(), rel)
+		link := path.Join(releaseDir, rel)
+
+		if isDir {
+			cmds = append(cmds, "mkdir -p "+shellQuote(shared))
+		} else {
+			cmds = append(cmds, fmt.Sprintf("mkdir -p %s && touch %s", shellQuote(path.Dir(shared)), shellQuote(shared)))
+		}
+		cmds = append(cmds, fmt.Sprintf("mkdir -p %s && rm -rf %s && ln -s %s %s",
+			shellQuote(path.Dir(link)), shellQuote(link), shellQuote(shared), shellQuote(link)))
+	}
+
+	return strings.Join(cmds, " && ")
+}
+
+// switchRelease aponta current para a release de forma atômica (symlink
+// temporário seguido de rename)
+func (d *SSHDeployer) switchRelease(client sessionOpener, name string) error {
+	r := d.Releases
+	tmpLink := r.currentLink() + ".tmp"
+	script := fmt.Sprintf("ln -sfn %s %s && mv -Tf %s %s",
+		shellQuote(path.Join("releases", name)), shellQuote(tmpLink), shellQuote(tmpLink), shellQuote(r.currentLink()))
+
+	if err := runRemote(client, script); err != nil {
+		return fmt.Errorf("erro ao trocar symlink current: %w", err)
+	}
+	return nil
+}
+
+// runRestartHooks executa os comandos de reinício dentro de current/
+func (d *SSHDeployer) runRestartHooks(client sessionOpener) error {
+	r := d.Releases
+	if len(r.Restart) == 0 {
+		return nil
+	}
+
+	fmt.Fprintln(d.stdout(), "🔄 Executando comandos de reinício...")
+	prelude := "cd " + shellQuote(r.currentLink())
+	if err := d.runCommands(client, prelude, r.Restart); err != nil {
+		return fmt.Errorf("erro ao reiniciar aplicação: %w", err)
+	}
+	return nil
+}
+
+// sortReleasesCmd lista as releases da mais recente para a mais antiga pelo
+// nome (o timestamp padrão ordena cronologicamente), e não pela data de
+// modificação, que muda ao escrever na pasta ou ao copiar com cp -p
+const sortReleasesCmd = "ls -1 | LC_ALL=C sort -r"
+
+// cleanupReleases remove as releases mais antigas além do limite configurado,
+// preservando sempre a release ativa
+func (d *SSHDeployer) cleanupReleases(client sessionOpener) error {
+	r := d.Releases
+	script := fmt.Sprintf(
+		`cd %s && current=$(basename "$(readlink %s)") && %s | tail -n +%d | while read -r rel; do [ "$rel" != "$current" ] && rm -rf -- "$rel"; done; true`,
+		shellQuote(r.releasesDir()), shellQuote(r.currentLink()), sortReleasesCmd, r.keep()+1)
+	return runRemote(client, script)
+}
+
+// ListReleases retorna as releases do servidor (mais recente primeiro) e o
+// nome da release ativa
+func (d *SSHDeployer) ListReleases() ([]string, string, error) {
+	if d.Releases == nil || d.Releases.Path == "" {
+		return nil, "", fmt.Errorf("releases.path não configurado")
+	}
+
+	return d.listReleases(d.connection())
+}
+
+func (d *SSHDeployer) listReleases(client sessionOpener) ([]string, string, error) {
+	r := d.Releases
+	script := fmt.Sprintf(`readlink %s; echo; cd %s 2>/dev/null && %s; true`,
+		shellQuote(r.currentLink()), shellQuote(r.releasesDir()), sortReleasesCmd)
+
+	output, err := runRemoteOutput(client, script)
+	if err != nil {
+		return nil, "", fmt.Errorf("erro ao listar releases: %w", err)
+	}
+
+	lines := strings.Split(output, "\n")
+	current := path.Base(strings.TrimSpace(lines[0]))
+	if strings.TrimSpace(lines[0]) == "" {
+		current = ""
+	}
+
+	var releases []string
+	for _, line := range lines[1:] {
+		if line = strings.TrimSpace(line); line != "" {
+			releases = append(releases, line)
+		}
+	}
+	return releases, current, nil
+}
+
+// Rollback aponta current para a release informada ou, se vazia, para a
+// release anterior à ativa, e executa novamente os comandos de reinício.
+// Retorna o nome da release ativada.
+func (d *SSHDeployer) Rollback(release string) (string, error) {
+	target, err := d.RollbackTarget(release)
+	if err != nil {
+		return "", err
+	}
+
+	client := d.connection()
+	if err := d.switchRelease(client, target); err != nil {
+		return "", err
+	}
+	fmt.Fprintf(d.stdout(), "🔗 current -> releases/%s\n", target)
+
+	if err := d.runRestartHooks(client); err != nil {
+		return target, err
+	}
+
+	return target, nil
+}
+
+// RollbackTarget retorna a release que Rollback ativaria, sem alterar o
+// servidor
+func (d *SSHDeployer) RollbackTarget(release string) (string, error) {
+	if d.Releases == nil || d.Releases.Path == "" {
+		return "", fmt.Errorf("releases.path não configurado")
+	}
+
+	releases, current, err := d.listReleases(d.connection())
+	if err != nil {
+		return "", err
+	}
+	return rollbackTarget(releases, current, release)
+}
+
+// rollbackTarget escolhe a release de destino do rollback
+func rollbackTarget(releases []string, current, requested string) (string, error) {
+	if requested != "" {
+		if err := validateReleaseName(requested); err != nil {
+			return "", err
+		}
+		for _, rel := range releases {
+			if rel == requested {
+				if rel == current {
+					return "", fmt.Errorf("release %s já está ativa", rel)
+				}
+				return rel, nil
+			}
+		}
+		return "", fmt.Errorf("release %s não encontrada no servidor", requested)
+	}
+
+	for i, rel := range releases {
+		if rel == current {
+			if i+1 < len(releases) {
+				return releases[i+1], nil
+			}
+			return "", fmt.Errorf("não há release anterior a %s", current)
+		}
+	}
+	return "", fmt.Errorf("release ativa não encontrada (current: %q)", current)
+}
@@ -0,0 +1,312 @@
+package deploy
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// scpUpload envia um arquivo pelo protocolo SCP (scp -t), conferindo a
+// confirmação do servidor a cada etapa. O arquivo é gravado com um nome
+// temporário e renomeado no fim.
+func (t *fileTransfer) scpUpload(src io.Reader, info os.FileInfo, remotePath string, mode os.FileMode) error {
+	dir := path.Dir(remotePath)
+	if err := runRemote(t.client, "mkdir -p "+shellQuote(dir)); err != nil {
+		return fmt.Errorf("erro ao criar diretório remoto: %w", err)
+	}
+
+	tmp := tempName(remotePath)
+	if err := t.scpSend(src, info, tmp, mode); err != nil {
+		runRemote(t.client, "rm -f "+shellQuote(tmp))
+		return err
+	}
+
+	// Conferir o tamanho gravado antes de substituir o destino
+	script := fmt.Sprintf(`[ "$(wc -c < %[1]s)" -eq %[2]d ] && mv -f %[1]s %[3]s || { rm -f %[1]s; exit 1; }`,
+		shellQuote(tmp), info.Size(), shellQuote(remotePath))
+	if err := runRemote(t.client, script); err != nil {
+		return fmt.Errorf("tamanho remoto divergente ou erro ao renomear: %w", err)
+	}
+	return nil
+}
+
+func (t *fileTransfer) scpSend(src io.Reader, info os.FileInfo, remotePath string, mode os.FileMode) error {
+	session, err := t.client.NewSession()
+	if err != nil {
+		return fmt.Errorf("erro ao criar sessão: %w", err)
+	}
+	defer session.Close()
+
+	stdin, err := session.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("erro ao abrir stdin da sessão: %w", err)
+	}
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("erro ao abrir stdout da sessão: %w", err)
+	}
+	var stderr bytes.Buffer
+	session.Stderr = &stderr
+
+	if err := session.Start("scp -p -t " + shellQuote(remotePath)); err != nil {
+		return fmt.Errorf("erro ao iniciar scp: %w", err)
+	}
+
+	acks := bufio.NewReader(stdout)
+	err = scpAck(acks)
+	if err == nil {
+		mtime := info.ModTime().Unix()
+		err = scpCommand(stdin, acks, fmt.Sprintf("T%d 0 %d 0\n", mtime, mtime))
+	}
+	if err == nil {
+		err = scpCommand(stdin, acks, fmt.Sprintf("C%04o %d %s\n", mode, info.Size(), path.Base(remotePath)))
+	}
+	if err == nil {
+		var n int64
+		if n, err = io.Copy(stdin, src); err == nil && n != info.Size() {
+			err = fmt.Errorf("arquivo local mudou durante o envio (%d de %d bytes)", n, info.Size())
+		}
+	}
+	if err == nil {
+		err = scpCommand(stdin, acks, "\x00")
+	}
+	stdin.Close()
+
+	if waitErr := session.Wait(); err == nil && waitErr != nil {
+		err = waitErr
+	}
+	if err != nil && stderr.Len() > 0 {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return err
+}
+
+// scpCommand envia uma linha do protocolo e aguarda a confirmação
+func scpCommand(w io.Writer, acks *bufio.Reader, line string) error {
+	if _, err := io.WriteString(w, line); err != nil {
+		return err
+	}
+	return scpAck(acks)
+}
+
+// scpAck lê a resposta do outro lado: 0 é sucesso; 1 (aviso) e 2 (erro)
+// vêm acompanhados de uma mensagem
+func scpAck(r *bufio.Reader) error {
+	code, err := r.ReadByte()
+	if err != nil {
+		return fmt.Errorf("scp sem resposta do servidor: %w", err)
+	}
+	if code == 0 {
+		return nil
+	}
+	message, _ := r.ReadString('\n')
+	return fmt.Errorf("scp: %s", strings.TrimSpace(message))
+}
+
+// scpDownload baixa um arquivo ou diretório com scp -f, preservando
+// permissões e datas
+func (t *fileTransfer) scpDownload(remotePath, localPath string) error {
+	session, err := t.client.NewSession()
+	if err != nil {
+		return fmt.Errorf("erro ao criar sessão: %w", err)
+	}
+	defer session.Close()
+
+	stdin, err := session.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("erro ao abrir stdin da sessão: %w", err)
+	}
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("erro ao abrir stdout da sessão: %w", err)
+	}
+	var stderr bytes.Buffer
+	session.Stderr = &stderr
+
+	if err := session.Start("scp -p -r -f " + shellQuote(remotePath)); err != nil {
+		return fmt.Errorf("erro ao iniciar scp: %w", err)
+	}
+
+	err = scpReceive(bufio.NewReader(stdout), stdin, localPath)
+	stdin.Close()
+	if waitErr := session.Wait(); err == nil && waitErr != nil {
+		err = waitErr
+	}
+	if err != nil {
+		if stderr.Len() > 0 {
+			err = fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+		}
+		return fmt.Errorf("erro ao baixar %s: %w", remotePath, err)
+	}
+	return nil
+}
+
+// scpReceive processa as mensagens de um scp -f: T (datas), C (arquivo),
+// D (entra em diretório) e E (sai do diretório). O primeiro item recebido
+// é gravado em localPath.
+func scpReceive(r *bufio.Reader, w io.Writer, localPath string) error {
+	ack := func() error {
+		_, err := w.Write([]byte{0})
+		return err
+	}
+	if err := ack(); err != nil {
+		return err
+	}
+
+	type dirEntry struct {
+		path  string
+		mode  os.FileMode
+		mtime time.Time
+	}
+	var dirs []dirEntry
+	var mtime time.Time
+	received := false
+
+	target := func(name string) string {
+		if len(dirs) == 0 {
+			return localPath
+		}
+		return filepath.Join(dirs[len(dirs)-1].path, name)
+	}
+
+	for {
+		kind, err := r.ReadByte()
+		if err == io.EOF {
+			if !received {
+				return errors.New("nenhum arquivo recebido")
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		line = strings.TrimSuffix(line, "\n")
+
+		switch kind {
+		case 1, 2:
+			return fmt.Errorf("scp: %s", line)
+		case 'T':
+			fields := strings.Fields(line)
+			if len(fields) != 4 {
+				return fmt.Errorf("scp: mensagem inválida T%s", line)
+			}
+			seconds, err := strconv.ParseInt(fields[0], 10, 64)
+			if err != nil {
+				return fmt.Errorf("scp: mensagem inválida T%s", line)
+			}
+			mtime = time.Unix(seconds, 0)
+			if err := ack(); err != nil {
+				return err
+			}
+		case 'C', 'D':
+			mode, size, name, err := parseSCPHeader(line)
+			if err != nil {
+				return err
+			}
+			dest := target(name)
+			if err := ack(); err != nil {
+				return err
+			}
+
+			if kind == 'D' {
+				if err := os.MkdirAll(dest, mode|0700); err != nil {
+					return err
+				}
+				dirs = append(dirs, dirEntry{path: dest, mode: mode, mtime: mtime})
+			} else {
+				if err := writeLocalFile(dest, io.LimitReader(r, size), size, mode, mtime); err != nil {
+					return err
+				}
+				// O arquivo termina com a confirmação do servidor
+				if err := scpAck(r); err != nil {
+					return err
+				}
+				if err := ack(); err != nil {
+					return err
+				}
+			}
+			received = true
+			mtime = time.Time{}
+		case 'E':
+			if len(dirs) == 0 {
+				return errors.New("scp: fim de diretório inesperado")
+			}
+			dir := dirs[len(dirs)-1]
+			dirs = dirs[:len(dirs)-1]
+			if err := os.Chmod(dir.path, dir.mode); err != nil {
+				return err
+			}
+			if !dir.mtime.IsZero() {
+				os.Chtimes(dir.path, dir.mtime, dir.mtime)
+			}
+			if err := ack(); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("scp: mensagem desconhecida %q", string(kind)+line)
+		}
+	}
+}
+
+// parseSCPHeader interpreta "<modo> <tamanho> <nome>" de uma mensagem C ou D
+func parseSCPHeader(line string) (os.FileMode, int64, string, error) {
+	fields := strings.SplitN(line, " ", 3)
+	if len(fields) != 3 {
+		return 0, 0, "", fmt.Errorf("scp: cabeçalho inválido %q", line)
+	}
+	mode, err := strconv.ParseUint(fields[0], 8, 32)
+	if err != nil {
+		return 0, 0, "", fmt.Errorf("scp: modo inválido %q", fields[0])
+	}
+	size, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil || size < 0 {
+		return 0, 0, "", fmt.Errorf("scp: tamanho inválido %q", fields[1])
+	}
+	name := fields[2]
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, "/\\") {
+		return 0, 0, "", fmt.Errorf("scp: nome inseguro %q", name)
+	}
+	return os.FileMode(mode).Perm(), size, name, nil
+}
+
+// writeLocalFile grava exatamente size bytes em um arquivo temporário ao
+// lado do destino e o renomeia, aplicando permissão e data de modificação
+func writeLocalFile(dest string, src io.Reader, size int64, mode os.FileMode, mtime time.Time) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(dest), "."+filepath.Base(dest)+".00cli-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	n, err := io.CopyN(tmp, src, size)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("erro ao gravar %s (%d de %d bytes): %w", dest, n, size, err)
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return err
+	}
+	if !mtime.IsZero() {
+		if err := os.Chtimes(tmp.Name(), mtime, mtime); err != nil {
+			return err
+		}
+	}
+	return os.Rename(tmp.Name(), dest)
+}
@@ -0,0 +1,128 @@
+package deploy
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Script é um arquivo local executado como passo de um estágio: no servidor,
+// é enviado a um diretório temporário, executado e removido em seguida
+type Script struct {
+	Path        string // arquivo local
+	Interpreter string // ex: "bash -eu", "python3" (vazio: shebang do arquivo ou /bin/sh)
+}
+
+// validate verifica se o script existe e é um arquivo
+func (s Script) validate() error {
+	info, err := os.Stat(s.Path)
+	if err != nil {
+		return fmt.Errorf("script não encontrado: %s", s.Path)
+	}
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("script não é um arquivo: %s", s.Path)
+	}
+	return nil
+}
+
+// command monta a chamada do script em file: pelo Interpreter configurado,
+// pelo shebang (o arquivo é executado diretamente) ou por /bin/sh
+func (s Script) command(file string) (string, error) {
+	if s.Interpreter != "" {
+		return s.Interpreter + " " + shellQuote(file), nil
+	}
+	shebang, err := hasShebang(s.Path)
+	if err != nil {
+		return "", fmt.Errorf("erro ao ler script %s: %w", s.Path, err)
+	}
+	if shebang {
+		return shellQuote(file), nil
+	}
+	return "/bin/sh " + shellQuote(file), nil
+}
+
+// hasShebang informa se o arquivo começa com "#!"
+func hasShebang(file string) (bool, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	prefix, err := bufio.NewReader(f).Peek(2)
+	if err != nil && len(prefix) < 2 {
+		return false, nil
+	}
+	return string(prefix) == "#!", nil
+}
+
+// localCommands retorna os comandos do estágio seguidos das chamadas dos
+// scripts, executados no lugar, sem envio
+func (s Stage) localCommands() ([]string, error) {
+	commands := append([]string{}, s.Commands...)
+	for _, script := range s.Scripts {
+		file, err := filepath.Abs(script.Path)
+		if err != nil {
+			return nil, err
+		}
+		cmd, err := script.command(file)
+		if err != nil {
+			return nil, err
+		}
+		if script.Interpreter == "" && !isExecutable(file) {
+			// Sem permissão de execução, o shebang não é usado localmente
+			cmd = "/bin/sh " + shellQuote(file)
+		}
+		commands = append(commands, cmd)
+	}
+	return commands, nil
+}
+
+func isExecutable(file string) bool {
+	info, err := os.Stat(file)
+	return err == nil && info.Mode().Perm()&0111 != 0
+}
+
+// runScripts envia os scripts para um diretório temporário no servidor,
+// executa-os em ordem como os comandos (mesmo prelude, variáveis e modo de
+// sessão) e remove o diretório ao final, mesmo se algum falhar
+func (d *SSHDeployer) runScripts(client sessionOpener, prelude string, scripts []Script) error {
+	output, err := runRemoteOutput(client, "mktemp -d 2>/dev/null || mktemp -d -t 00cli")
+	if err != nil {
+		return fmt.Errorf("erro ao criar diretório temporário para scripts: %w", err)
+	}
+	dir := strings.TrimSpace(output)
+	if dir == "" {
+		return fmt.Errorf("erro ao criar diretório temporário para scripts")
+	}
+	defer func() {
+		if err := runRemote(client, "rm -rf "+shellQuote(dir)); err != nil && d.Verbose {
+			fmt.Fprintf(d.stdout(), "⚠️  Não foi possível remover os scripts temporários: %v\n", err)
+		}
+	}()
+
+	transfer, err := newFileTransfer(client)
+	if err != nil {
+		return err
+	}
+	defer transfer.Close()
+	transfer.Verify = true
+
+	fmt.Fprintf(d.stdout(), "📜 Enviando %d script(s) para %s\n", len(scripts), dir)
+	commands := make([]string, len(scripts))
+	for i, script := range scripts {
+		// O prefixo evita colisão entre scripts de mesmo nome
+		remote := path.Join(dir, fmt.Sprintf("%02d-%s", i+1, filepath.Base(script.Path)))
+		if err := transfer.UploadFile(script.Path, remote, 0700); err != nil {
+			return fmt.Errorf("erro ao enviar script %s: %w", script.Path, err)
+		}
+		if commands[i], err = script.command(remote); err != nil {
+			return err
+		}
+	}
+
+	return d.runCommands(client, prelude, commands)
+}
@@ -0,0 +1,120 @@
+package deploy
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestSSHDeployerScripts(t *testing.T) {
+	srv := newTestSSHServer(t)
+	local := t.TempDir()
+	work := filepath.Join(t.TempDir(), "work")
+	logFile := filepath.Join(t.TempDir(), "scripts.log")
+	if err := os.MkdirAll(work, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	write := func(name, content string) string {
+		t.Helper()
+		file := filepath.Join(local, name)
+		os.MkdirAll(filepath.Dir(file), 0755)
+		// Sem permissão de execução local: o servidor recebe o script executável
+		if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return file
+	}
+	deployScript := write("deploy.sh", "#!/bin/sh\necho \"deploy:$APP_ENV:$(basename \"$PWD\")\" >> "+logFile+"\n")
+	sameName := write("sub/deploy.sh", "echo sub >> "+logFile+"\n")
+	strict := write("strict.sh", "false\necho depois >> "+logFile+"\n")
+
+	out := &bytes.Buffer{}
+	deployer := srv.Deployer()
+	deployer.Stdout = out
+	deployer.Environment = map[string]string{"APP_ENV": "production"}
+	deployer.Pipeline = Pipeline{
+		StageBuild: {Dir: work, Scripts: []Script{
+			{Path: deployScript},
+			{Path: sameName},
+			{Path: strict},
+		}},
+	}
+	defer deployer.Close()
+
+	if err := deployer.Execute([]string{"echo commands >> " + logFile}); err != nil {
+		t.Fatalf("erro no deploy: %v\n%s", err, out)
+	}
+	data, _ := os.ReadFile(logFile)
+	if got, want := string(data), "commands\ndeploy:production:work\nsub\ndepois\n"; got != want {
+		t.Errorf("execução esperada %q, obtida %q", want, got)
+	}
+
+	tempDir := regexp.MustCompile(`script\(s\) para (\S+)`).FindStringSubmatch(out.String())
+	if tempDir == nil {
+		t.Fatalf("diretório temporário não informado na saída:\n%s", out)
+	}
+	if _, err := os.Stat(tempDir[1]); !os.IsNotExist(err) {
+		t.Errorf("scripts temporários não removidos: %s", tempDir[1])
+	}
+
+	// Com interpretador configurado, o código de saída interrompe o deploy e
+	// os scripts seguintes não rodam; a limpeza acontece mesmo assim
+	os.Remove(logFile)
+	out.Reset()
+	deployer.Pipeline[StageBuild] = Stage{Scripts: []Script{
+		{Path: strict, Interpreter: "/bin/sh -e"},
+		{Path: deployScript},
+	}}
+	err := deployer.Execute(nil)
+	if err == nil || !strings.Contains(err.Error(), "strict.sh") {
+		t.Fatalf("esperado erro do script com sh -e, obtido %v", err)
+	}
+	if data, _ := os.ReadFile(logFile); len(data) != 0 {
+		t.Errorf("scripts executados após a falha: %q", data)
+	}
+	if tempDir := regexp.MustCompile(`script\(s\) para (\S+)`).FindStringSubmatch(out.String()); tempDir != nil {
+		if _, err := os.Stat(tempDir[1]); !os.IsNotExist(err) {
+			t.Errorf("scripts temporários não removidos após a falha: %s", tempDir[1])
+		}
+	}
+
+	deployer.Pipeline[StageBuild] = Stage{Scripts: []Script{{Path: filepath.Join(local, "inexistente.sh")}}}
+	if err := deployer.Execute(nil); err == nil || !strings.Contains(err.Error(), "script não encontrado") {
+		t.Errorf("esperado erro para script inexistente, obtido %v", err)
+	}
+}
+
+func TestStageLocalCommands(t *testing.T) {
+	dir := t.TempDir()
+	withShebang := filepath.Join(dir, "run.sh")
+	plain := filepath.Join(dir, "plain.sh")
+	os.WriteFile(withShebang, []byte("#!/bin/bash\necho ok\n"), 0755)
+	os.Chmod(withShebang, 0755)
+	os.WriteFile(plain, []byte("echo ok\n"), 0644)
+
+	stage := Stage{
+		Commands: []string{"make"},
+		Scripts: []Script{
+			{Path: withShebang},
+			{Path: plain},
+			{Path: plain, Interpreter: "bash -eu"},
+		},
+	}
+	commands, err := stage.localCommands()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"make",
+		shellQuote(withShebang),
+		"/bin/sh " + shellQuote(plain),
+		"bash -eu " + shellQuote(plain),
+	}
+	if strings.Join(commands, "\n") != strings.Join(want, "\n") {
+		t.Errorf("comandos esperados %q, obtidos %q", want, commands)
+	}
+}
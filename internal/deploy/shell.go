@@ -0,0 +1,302 @@
+package deploy
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	// SessionPersistent executa todos os comandos em um único shell remoto
+	SessionPersistent = "persistent"
+	// SessionIsolated executa cada comando em uma sessão SSH separada
+	SessionIsolated = "isolated"
+
+	defaultRemoteShell = "/bin/sh"
+)
+
+// stepResult representa o fim de um passo executado no shell remoto
+type stepResult struct {
+	Step     int
+	ExitCode int
+}
+
+// remoteShell mantém um shell remoto aberto durante todo o deploy, de forma
+// que diretório atual, variáveis exportadas e arquivos carregados com
+// "source" persistam entre os comandos.
+type remoteShell struct {
+	session *ssh.Session
+	stdin   io.WriteCloser
+	results chan stepResult
+	done    chan error
+	exited  bool
+	exitErr error
+	marker  string
+	step    int
+
+	// envExported indica que o servidor recusou SetEnv e as variáveis
+	// foram exportadas no início do shell
+	envExported bool
+}
+
+// startRemoteShell inicia o shell remoto em uma nova sessão do cliente, com
+// as variáveis de ambiente informadas
+func startRemoteShell(client sessionOpener, shell string, env map[string]string, stdout, stderr io.Writer) (*remoteShell, error) {
+	if shell == "" {
+		shell = defaultRemoteShell
+	}
+
+	token := make([]byte, 8)
+	if _, err := rand.Read(token); err != nil {
+		return nil, fmt.Errorf("erro ao gerar marcador de sessão: %w", err)
+	}
+
+	session, err := client.NewSession()
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criar sessão SSH: %w", err)
+	}
+
+	rs := &remoteShell{
+		session: session,
+		results: make(chan stepResult, 1),
+		done:    make(chan error, 1),
+		marker:  "__00CLI_STEP_" + hex.EncodeToString(token) + ":",
+	}
+
+	stdin, err := session.StdinPipe()
+	if err != nil {
+		session.Close()
+		return nil, fmt.Errorf("erro ao abrir stdin da sessão: %w", err)
+	}
+	rs.stdin = stdin
+
+	session.Stdout = newMarkerWriter(stdout, rs.marker, rs.results)
+	session.Stderr = stderr
+
+	rs.envExported = !setSessionEnv(session, env)
+
+	if err := session.Start(shell + " -s"); err != nil {
+		session.Close()
+		return nil, fmt.Errorf("erro ao iniciar shell remoto '%s': %w", shell, err)
+	}
+
+	if rs.envExported {
+		if _, err := io.WriteString(stdin, exportPrefix(env)+"\n"); err != nil {
+			session.Close()
+			return nil, fmt.Errorf("erro ao exportar variáveis de ambiente: %w", err)
+		}
+	}
+
+	go func() {
+		rs.done <- session.Wait()
+	}()
+
+	return rs, nil
+}
+
+// Run executa um comando no shell remoto e retorna seu código de saída.
+// O stdin do comando é redirecionado para /dev/null para que ele não consuma
+// os próximos passos enviados ao shell.
+func (rs *remoteShell) Run(cmd string) (int, error) {
+	rs.step++
+	script := fmt.Sprintf("{\n%s\n} </dev/null\nprintf '\\n%s%%d:%%d\\n' %d $?\n", cmd, rs.marker, rs.step)
+	if _, err := io.WriteString(rs.stdin, script); err != nil {
+		return -1, fmt.Errorf("erro ao enviar comando ao shell remoto: %w", err)
+	}
+
+	select {
+	case res := <-rs.results:
+		return rs.checkStep(res)
+	case err := <-rs.done:
+		rs.exited, rs.exitErr = true, err
+		// O stdout já foi consumido quando Wait retorna; o marcador pode ter
+		// chegado junto com o fim do shell.
+		select {
+		case res := <-rs.results:
+			return rs.checkStep(res)
+		default:
+		}
+		if err == nil {
+			return -1, fmt.Errorf("shell remoto encerrado antes do fim do passo %d", rs.step)
+		}
+		return -1, fmt.Errorf("shell remoto encerrado antes do fim do passo %d: %w", rs.step, err)
+	}
+}
+
+func (rs *remoteShell) checkStep(res stepResult) (int, error) {
+	if res.Step != rs.step {
+		return -1, fmt.Errorf("resposta fora de ordem do shell remoto (passo %d, esperado %d)", res.Step, rs.step)
+	}
+	return res.ExitCode, nil
+}
+
+// Close encerra o shell remoto e aguarda o fim da sessão
+func (rs *remoteShell) Close() error {
+	if !rs.exited {
+		io.WriteString(rs.stdin, "exit 0\n")
+		rs.stdin.Close()
+		rs.exitErr = <-rs.done
+		rs.exited = true
+	}
+	if mw, ok := rs.session.Stdout.(*markerWriter); ok {
+		mw.Flush()
+	}
+	rs.session.Close()
+	return rs.exitErr
+}
+
+// markerWriter repassa a saída do shell remoto removendo as linhas de
+// marcação de fim de passo e publicando os códigos de saída encontrados.
+type markerWriter struct {
+	out     io.Writer
+	marker  []byte
+	results chan<- stepResult
+	buf     []byte
+}
+
+func newMarkerWriter(out io.Writer, marker string, results chan<- stepResult) *markerWriter {
+	if out == nil {
+		out = io.Discard
+	}
+	return &markerWriter{
+		out:     out,
+		marker:  []byte("\n" + marker),
+		results: results,
+	}
+}
+
+func (w *markerWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+
+	for {
+		idx := bytes.Index(w.buf, w.marker)
+		if idx < 0 {
+			break
+		}
+
+		rest := w.buf[idx+len(w.marker):]
+		end := bytes.IndexByte(rest, '\n')
+		if end < 0 {
+			// Marcador incompleto: aguardar o restante da linha
+			if err := w.emit(w.buf[:idx]); err != nil {
+				return 0, err
+			}
+			w.buf = append([]byte(nil), w.buf[idx:]...)
+			return len(p), nil
+		}
+
+		if err := w.emit(w.buf[:idx]); err != nil {
+			return 0, err
+		}
+		if res, ok := parseStepMarker(string(rest[:end])); ok {
+			w.results <- res
+		}
+		w.buf = rest[end+1:]
+	}
+
+	// Reter apenas o trecho final que pode ser o início de um marcador
+	keep := partialPrefixLen(w.buf, w.marker)
+	if err := w.emit(w.buf[:len(w.buf)-keep]); err != nil {
+		return 0, err
+	}
+	w.buf = append([]byte(nil), w.buf[len(w.buf)-keep:]...)
+
+	return len(p), nil
+}
+
+// emit repassa a saída ao destino. Escritas vazias são omitidas: depois de
+// um marcador o destino volta a ser usado pelo deploy para exibir o próximo
+// passo.
+func (w *markerWriter) emit(b []byte) error {
+	if len(b) == 0 {
+		return nil
+	}
+	_, err := w.out.Write(b)
+	return err
+}
+
+// Flush escreve a saída retida à espera de um possível marcador
+func (w *markerWriter) Flush() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+	_, err := w.out.Write(w.buf)
+	w.buf = nil
+	return err
+}
+
+// parseStepMarker interpreta o conteúdo "<passo>:<código>" de um marcador
+func parseStepMarker(s string) (stepResult, bool) {
+	stepStr, codeStr, ok := strings.Cut(strings.TrimSpace(s), ":")
+	if !ok {
+		return stepResult{}, false
+	}
+	step, err := strconv.Atoi(stepStr)
+	if err != nil {
+		return stepResult{}, false
+	}
+	code, err := strconv.Atoi(codeStr)
+	if err != nil {
+		return stepResult{}, false
+	}
+	return stepResult{Step: step, ExitCode: code}, true
+}
+
+// partialPrefixLen retorna o tamanho do maior sufixo de buf que é prefixo de marker
+func partialPrefixLen(buf, marker []byte) int {
+	max := len(marker) - 1
+	if max > len(buf) {
+		max = len(buf)
+	}
+	for n := max; n > 0; n-- {
+		if bytes.Equal(buf[len(buf)-n:], marker[:n]) {
+			return n
+		}
+	}
+	return 0
+}
+
+// runPersistent executa os comandos em um único shell remoto, parando no
+// primeiro passo que falhar.
+func (d *SSHDeployer) runPersistent(client sessionOpener, prelude string, commands []string) error {
+	shell, err := startRemoteShell(client, d.Shell, d.Environment, d.stdout(), d.stderr())
+	if err != nil {
+		return err
+	}
+	if shell.envExported && d.Verbose {
+		fmt.Fprintln(d.stdout(), "  ⚠️  Servidor recusou SetEnv (AcceptEnv); variáveis exportadas no shell")
+	}
+
+	if prelude != "" {
+		code, err := shell.Run(prelude)
+		if err == nil && code != 0 {
+			err = fmt.Errorf("código de saída %d", code)
+		}
+		if err != nil {
+			shell.Close()
+			return fmt.Errorf("erro ao preparar shell remoto: %w", err)
+		}
+	}
+
+	for i, cmd := range commands {
+		fmt.Fprintf(d.stdout(), "  [%d/%d] Executando: %s\n", i+1, len(commands), cmd)
+
+		code, err := shell.Run(cmd)
+		if err != nil {
+			shell.Close()
+			return fmt.Errorf("erro ao executar comando '%s': %w", cmd, err)
+		}
+		if code != 0 {
+			shell.Close()
+			return fmt.Errorf("erro ao executar comando '%s': código de saída %d", cmd, code)
+		}
+	}
+
+	return shell.Close()
+}
@@ -1,10 +1,13 @@
 package deploy
 
 import (
+	"context"
 	"fmt"
 	"io"
+	"net"
 	"os"
-	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
 	"golang.org/x/crypto/ssh"
@@ -12,56 +15,257 @@ import (
 
 // SSHDeployer implementa deploy via SSH
 type SSHDeployer struct {
-	Host     string
-	Port     int
-	User     string
-	SSHKey   string
-	Password string
+	Name        string // nome do host na saída de deploys multi-host
+	Host        string
+	Port        int
+	User        string
+	SSHKey      string
+	Password    string
+	SessionMode string // "persistent" (padrão) ou "isolated"
+	Shell       string // shell remoto usado no modo persistent (padrão: /bin/sh)
+	Environment map[string]string
+	Verbose     bool
+	Provision   *Provision // arquivos enviados antes dos comandos (opcional)
+	Releases    *Releases  // layout de releases com symlink current (opcional)
+	Sync        *Sync      // diretório local espelhado no servidor (opcional)
+	Build       *Build     // build local cujo artefato é enviado ao servidor (opcional)
+	Pipeline    Pipeline   // estágios do deploy (opcional; commands equivale ao estágio build)
+	ProjectPath string     // diretório dos estágios com target local
+
+	// HostKey fixa a chave esperada do servidor ("SHA256:..." ou formato authorized_keys)
+	HostKey string
+	// KnownHosts lista os arquivos known_hosts consultados (padrão: ~/.ssh/known_hosts)
+	KnownHosts []string
+	// ConfirmHostKey pergunta se uma chave desconhecida deve ser aceita (padrão: terminal)
+	ConfirmHostKey func(question string) (bool, error)
+
+	// IdentityFiles são chaves privadas tentadas em ordem depois de SSHKey
+	IdentityFiles []string
+	// SSHConfig é o ssh_config usado para resolver Host como alias (padrão:
+	// ~/.ssh/config; SSHConfigNone desativa)
+	SSHConfig string
+	// Passphrase obtém a senha de uma chave cifrada (padrão:
+	// 00CLI_SSH_PASSPHRASE ou terminal)
+	Passphrase func(keyFile string) ([]byte, error)
+	// Jump são os bastions atravessados em ordem até o servidor (padrão:
+	// ProxyJump do ssh_config)
+	Jump []JumpHost
+	// KeyboardInteractive responde aos desafios keyboard-interactive (padrão:
+	// Password para o pedido de senha, os demais no terminal)
+	KeyboardInteractive ssh.KeyboardInteractiveChallenge
+	// KeepAlive é o intervalo entre keepalives da conexão (padrão:
+	// DefaultKeepAlive; negativo desativa)
+	KeepAlive time.Duration
+
+	// Stdout e Stderr recebem a saída do deploy (padrão: os.Stdout/os.Stderr)
+	Stdout io.Writer
+	Stderr io.Writer
+
+	// conn é a conexão compartilhada entre Execute, UploadFile e as
+	// operações de releases, até Close
+	conn *sshConn
+	// artifact é o resultado do Build, gerado uma vez e compartilhado entre
+	// os hosts de um deploy multi-host
+	artifact *buildArtifact
+}
+
+// connection retorna a conexão do deployer, aberta no primeiro uso
+func (d *SSHDeployer) connection() *sshConn {
+	if d.conn == nil {
+		keepAlive := d.KeepAlive
+		if keepAlive == 0 {
+			keepAlive = DefaultKeepAlive
+		}
+		d.conn = &sshConn{dial: d.dial, keepAlive: keepAlive, warnf: d.warnf}
+	}
+	return d.conn
+}
+
+// Close encerra a conexão SSH mantida entre as operações do deployer
+func (d *SSHDeployer) Close() error {
+	if d.conn == nil {
+		return nil
+	}
+	return d.conn.Close()
+}
+
+func (d *SSHDeployer) stdout() io.Writer {
+	if d.Stdout != nil {
+		return d.Stdout
+	}
+	return os.Stdout
+}
+
+func (d *SSHDeployer) stderr() io.Writer {
+	if d.Stderr != nil {
+		return d.Stderr
+	}
+	return os.Stderr
 }
 
-// Execute executa comandos via SSH
+// Execute executa o pipeline de deploy via SSH; commands são os comandos do
+// estágio build
 func (d *SSHDeployer) Execute(commands []string) error {
-	config := &ssh.ClientConfig{
-		User:            d.User,
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(), // Em produção, use validação adequada
-		Timeout:         10 * time.Second,
+	if err := validateEnv(d.Environment); err != nil {
+		return err
 	}
 
-	// Autenticação por chave SSH
-	if d.SSHKey != "" {
-		key, err := os.ReadFile(d.SSHKey)
+	run, err := newPipelineRun(d.Pipeline, commands, d.stdout())
+	if err != nil {
+		return err
+	}
+
+	var release *releaseRun
+	if d.Releases != nil {
+		if release, err = d.newReleaseRun(); err != nil {
+			return err
+		}
+	}
+	if d.Build != nil && d.artifact == nil {
+		defer d.discardArtifact()
+	}
+
+	client := d.connection()
+	run.DefaultTarget = TargetRemote
+	run.Actions[StageUpload] = func() error { return d.upload(client, release) }
+	run.Runners[TargetRemote] = func(ctx context.Context, name string, stage Stage) error {
+		return d.runRemoteStage(ctx, client, d.stagePrelude(name, stage, release), stage.Commands, stage.Scripts)
+	}
+	run.Runners[TargetLocal] = func(ctx context.Context, name string, stage Stage) error {
+		commands, err := stage.localCommands()
 		if err != nil {
-			return fmt.Errorf("erro ao ler chave SSH: %w", err)
+			return err
 		}
+		local := localStage{
+			Dir:    localStageDir(d.ProjectPath, stage.Dir),
+			Env:    d.Environment,
+			Stdout: d.stdout(),
+			Stderr: d.stderr(),
+		}
+		return local.run(ctx, commands)
+	}
+	if release != nil {
+		run.Actions[StageSwitch] = func() error { return d.activateRelease(client, release) }
+		run.Actions[StagePostDeploy] = func() error {
+			if err := d.cleanupReleases(client); err != nil {
+				// Falha na limpeza não invalida o deploy
+				fmt.Fprintf(d.stdout(), "⚠️  Erro ao remover releases antigas: %v\n", err)
+			}
+			return nil
+		}
+		run.OnFailure = func(err error) error { return d.discardRelease(client, release, err) }
+	}
 
-		signer, err := ssh.ParsePrivateKey(key)
+	return run.Run()
+}
+
+// upload é a ação do estágio upload: build local (antes de conectar, para
+// falhar sem tocar no servidor), provision, artefato, sync e criação da release
+func (d *SSHDeployer) upload(client *sshConn, release *releaseRun) error {
+	if d.Build != nil && d.artifact == nil {
+		artifact, err := d.prepareArtifact()
 		if err != nil {
-			return fmt.Errorf("erro ao parsear chave SSH: %w", err)
+			return err
 		}
+		d.artifact = artifact
+	}
 
-		config.Auth = []ssh.AuthMethod{
-			ssh.PublicKeys(signer),
+	if _, err := client.Client(); err != nil {
+		return err
+	}
+
+	if err := d.syncProvision(client); err != nil {
+		return err
+	}
+
+	if d.hasArtifact() && !d.inRelease(d.Build.Target) {
+		if d.Build.Target == "" {
+			return fmt.Errorf("build.target não configurado")
 		}
-	} else if d.Password != "" {
-		// Autenticação por senha
-		config.Auth = []ssh.AuthMethod{
-			ssh.Password(d.Password),
+		if err := d.uploadArtifact(client, d.Build.Target); err != nil {
+			return err
 		}
-	} else {
-		return fmt.Errorf("nenhuma forma de autenticação configurada (ssh_key ou password)")
 	}
 
-	// Conectar ao servidor
-	addr := fmt.Sprintf("%s:%d", d.Host, d.Port)
-	client, err := ssh.Dial("tcp", addr, config)
-	if err != nil {
-		return fmt.Errorf("erro ao conectar via SSH: %w", err)
+	if d.Sync != nil && !d.syncIntoRelease() {
+		if d.Sync.Target == "" {
+			return fmt.Errorf("sync.target não configurado")
+		}
+		if _, err := d.syncFiles(client, d.Sync.Target); err != nil {
+			return err
+		}
+	}
+
+	if release != nil {
+		return d.createRelease(client, release)
+	}
+	return nil
+}
+
+// stagePrelude monta o prelude dos comandos remotos de um estágio. Com
+// releases, os estágios até migrate rodam na nova release e os seguintes em
+// current; pre_deploy e on_failure rodam na home do usuário.
+func (d *SSHDeployer) stagePrelude(name string, stage Stage, release *releaseRun) string {
+	base := ""
+	if release != nil {
+		switch name {
+		case StageUpload, StageBuild, StageMigrate:
+			base = release.dir
+		case StageSwitch, StagePostDeploy, StageOnSuccess:
+			base = d.Releases.currentLink()
+		}
+	}
+
+	var parts []string
+	if dir := remoteStageDir(base, stage.Dir); dir != "" {
+		parts = append(parts, "cd "+shellQuote(dir))
+	}
+	if release != nil {
+		parts = append(parts, d.releaseExports(release))
 	}
-	defer client.Close()
+	return strings.Join(parts, " && ")
+}
 
-	// Executar comandos
+// runRemoteStage executa os comandos e scripts de um estágio no servidor. As
+// sessões não podem ser interrompidas individualmente: ao expirar o timeout a
+// conexão é encerrada, e os passos seguintes abrem outra.
+func (d *SSHDeployer) runRemoteStage(ctx context.Context, client *sshConn, prelude string, commands []string, scripts []Script) error {
+	done := make(chan error, 1)
+	go func() {
+		var err error
+		if len(commands) > 0 {
+			err = d.runCommands(client, prelude, commands)
+		}
+		if err == nil && len(scripts) > 0 {
+			err = d.runScripts(client, prelude, scripts)
+		}
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		client.Close()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// runCommands executa os comandos no modo de sessão configurado. O prelude,
+// se informado, é executado antes de cada comando (ou uma vez, no modo
+// persistent) sem ser exibido como passo.
+func (d *SSHDeployer) runCommands(client sessionOpener, prelude string, commands []string) error {
+	if d.SessionMode != SessionIsolated {
+		return d.runPersistent(client, prelude, commands)
+	}
+	return d.runIsolated(client, prelude, commands)
+}
+
+// runIsolated executa cada comando em uma sessão separada
+func (d *SSHDeployer) runIsolated(client sessionOpener, prelude string, commands []string) error {
 	for i, cmd := range commands {
-		fmt.Printf("  [%d/%d] Executando: %s\n", i+1, len(commands), cmd)
+		fmt.Fprintf(d.stdout(), "  [%d/%d] Executando: %s\n", i+1, len(commands), cmd)
 
 		session, err := client.NewSession()
 		if err != nil {
@@ -69,10 +273,18 @@ func (d *SSHDeployer) Execute(commands []string) error {
 		}
 
 		// Capturar stdout e stderr
-		session.Stdout = os.Stdout
-		session.Stderr = os.Stderr
+		session.Stdout = d.stdout()
+		session.Stderr = d.stderr()
+
+		remoteCmd := cmd
+		if prelude != "" {
+			remoteCmd = prelude + " && " + remoteCmd
+		}
+		if !setSessionEnv(session, d.Environment) {
+			remoteCmd = exportPrefix(d.Environment) + remoteCmd
+		}
 
-		if err := session.Run(cmd); err != nil {
+		if err := session.Run(remoteCmd); err != nil {
 			session.Close()
 			return fmt.Errorf("erro ao executar comando '%s': %w", cmd, err)
 		}
@@ -83,76 +295,107 @@ func (d *SSHDeployer) Execute(commands []string) error {
 	return nil
 }
 
-// UploadFile faz upload de um arquivo via SCP
+// UploadFile envia um arquivo ou diretório ao servidor por SFTP (ou SCP,
+// se o servidor não oferecer SFTP), conferindo o checksum remoto
 func (d *SSHDeployer) UploadFile(localPath, remotePath string) error {
-	config := &ssh.ClientConfig{
-		User:            d.User,
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
-		Timeout:         10 * time.Second,
+	transfer, err := newFileTransfer(d.connection())
+	if err != nil {
+		return err
 	}
+	defer transfer.Close()
 
-	if d.SSHKey != "" {
-		key, err := os.ReadFile(d.SSHKey)
-		if err != nil {
-			return fmt.Errorf("erro ao ler chave SSH: %w", err)
-		}
-
-		signer, err := ssh.ParsePrivateKey(key)
-		if err != nil {
-			return fmt.Errorf("erro ao parsear chave SSH: %w", err)
-		}
+	transfer.Verify = true
+	return transfer.Upload(localPath, remotePath)
+}
 
-		config.Auth = []ssh.AuthMethod{
-			ssh.PublicKeys(signer),
-		}
-	} else if d.Password != "" {
-		config.Auth = []ssh.AuthMethod{
-			ssh.Password(d.Password),
-		}
+// DownloadFile baixa um arquivo ou diretório do servidor para localPath
+func (d *SSHDeployer) DownloadFile(remotePath, localPath string) error {
+	transfer, err := newFileTransfer(d.connection())
+	if err != nil {
+		return err
 	}
+	defer transfer.Close()
 
-	addr := fmt.Sprintf("%s:%d", d.Host, d.Port)
-	client, err := ssh.Dial("tcp", addr, config)
+	return transfer.Download(remotePath, localPath)
+}
+
+// clientConfig monta a configuração do cliente SSH com a autenticação
+// configurada. A função retornada libera o agente SSH após o handshake.
+func (d *SSHDeployer) clientConfig(target *sshTarget, addr string) (*ssh.ClientConfig, func(), error) {
+	auth, cleanup, err := d.authMethods(target)
 	if err != nil {
-		return fmt.Errorf("erro ao conectar via SSH: %w", err)
+		return nil, nil, err
 	}
-	defer client.Close()
 
-	// Abrir arquivo local
-	srcFile, err := os.Open(localPath)
-	if err != nil {
-		return fmt.Errorf("erro ao abrir arquivo local: %w", err)
+	verifier := d.hostKeyVerifier()
+	config := &ssh.ClientConfig{
+		User:              target.User,
+		Auth:              auth,
+		HostKeyCallback:   verifier.Callback(),
+		HostKeyAlgorithms: verifier.Algorithms(addr),
+		Timeout:           10 * time.Second,
 	}
-	defer srcFile.Close()
+	return config, cleanup, nil
+}
 
-	// Criar sessão SCP
-	session, err := client.NewSession()
+// dial conecta ao servidor configurado, resolvendo o host no ssh_config e
+// atravessando os bastions configurados
+func (d *SSHDeployer) dial() (*ssh.Client, error) {
+	target, err := d.resolveTarget()
 	if err != nil {
-		return fmt.Errorf("erro ao criar sessão: %w", err)
+		return nil, err
+	}
+	jumps, err := d.jumpHosts(target)
+	if err != nil {
+		return nil, err
 	}
-	defer session.Close()
-
-	// Executar SCP
-	go func() {
-		w, _ := session.StdinPipe()
-		defer w.Close()
-		fmt.Fprintf(w, "C%04o %d %s\n", 0644, getFileSize(srcFile), filepath.Base(remotePath))
-		io.Copy(w, srcFile)
-		fmt.Fprint(w, "\x00")
-	}()
 
-	cmd := fmt.Sprintf("scp -t %s", remotePath)
-	if err := session.Run(cmd); err != nil {
-		return fmt.Errorf("erro ao fazer upload: %w", err)
+	// Cada bastion é alcançado pelo túnel do anterior
+	var via *ssh.Client
+	for _, jump := range jumps {
+		hop := d.jumpDeployer(jump)
+		hopTarget, err := hop.resolveTarget()
+		if err == nil {
+			if d.Verbose {
+				fmt.Fprintf(d.stdout(), "🔀 Via bastion %s@%s\n", hopTarget.User, net.JoinHostPort(hopTarget.Host, strconv.Itoa(hopTarget.Port)))
+			}
+			via, err = hop.connect(hopTarget, via)
+		} else if via != nil {
+			via.Close()
+		}
+		if err != nil {
+			return nil, fmt.Errorf("erro ao conectar ao bastion %s: %w", jump.Host, err)
+		}
 	}
 
-	return nil
+	return d.connect(target, via)
 }
 
-func getFileSize(file *os.File) int64 {
-	info, err := file.Stat()
+// connect autentica no destino, diretamente ou pelo túnel aberto em via
+// (que passa a pertencer à conexão retornada)
+func (d *SSHDeployer) connect(target *sshTarget, via *ssh.Client) (*ssh.Client, error) {
+	addr := net.JoinHostPort(target.Host, strconv.Itoa(target.Port))
+	if d.Verbose && target.Host != target.Alias {
+		fmt.Fprintf(d.stdout(), "🔗 %s → %s@%s\n", target.Alias, target.User, addr)
+	}
+
+	config, cleanup, err := d.clientConfig(target, addr)
+	if err != nil {
+		if via != nil {
+			via.Close()
+		}
+		return nil, err
+	}
+	defer cleanup()
+
+	if via != nil {
+		return dialVia(via, addr, config)
+	}
+
+	client, err := ssh.Dial("tcp", addr, config)
 	if err != nil {
-		return 0
+		return nil, fmt.Errorf("erro ao conectar via SSH: %w", err)
 	}
-	return info.Size()
+
+	return client, nil
 }
@@ -0,0 +1,935 @@
+package deploy
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// testSSHServer é um servidor SSH em processo que executa os comandos
+// recebidos com o /bin/sh local, usado como substituto do servidor remoto.
+type testSSHServer struct {
+	Addr    string
+	Host    string
+	Port    int
+	HostKey ssh.Signer
+
+	// RejectEnv faz o servidor recusar requisições "env", como um sshd
+	// sem AcceptEnv
+	RejectEnv bool
+
+	// NoSFTP desativa o subsistema sftp, forçando o uso de SCP
+	NoSFTP bool
+
+	// Dir é o diretório de trabalho dos comandos, como a home do usuário em
+	// um servidor (vazio: o do processo de teste)
+	Dir string
+
+	mu         sync.Mutex
+	commands   []string
+	forwards   []string
+	conns      []net.Conn
+	keepAlives int
+}
+
+// newTestSSHServer inicia o servidor; configure pode trocar a autenticação
+// aceita (por padrão, qualquer senha). HOME e SSH_AUTH_SOCK são isolados para
+// que chaves e agente do usuário não participem dos testes.
+func newTestSSHServer(t *testing.T, configure ...func(*ssh.ServerConfig)) *testSSHServer {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("SSH_AUTH_SOCK", "")
+
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("erro ao gerar chave do servidor: %v", err)
+	}
+	signer, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatalf("erro ao criar signer: %v", err)
+	}
+
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(c ssh.ConnMetadata, pass []byte) (*ssh.Permissions, error) {
+			return nil, nil
+		},
+	}
+	for _, fn := range configure {
+		fn(config)
+	}
+	config.AddHostKey(signer)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("erro ao abrir listener: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	addr := listener.Addr().(*net.TCPAddr)
+	srv := &testSSHServer{
+		Addr:    addr.String(),
+		Host:    addr.IP.String(),
+		Port:    addr.Port,
+		HostKey: signer,
+	}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go srv.handleConn(conn, config)
+		}
+	}()
+
+	return srv
+}
+
+// Deployer retorna um SSHDeployer apontando para o servidor, com a chave
+// do host fixada
+func (s *testSSHServer) Deployer() *SSHDeployer {
+	return &SSHDeployer{
+		Host:     s.Host,
+		Port:     s.Port,
+		User:     "deploy",
+		Password: "secret",
+		HostKey:  ssh.FingerprintSHA256(s.HostKey.PublicKey()),
+	}
+}
+
+// Target retorna o HostTarget do servidor para deploys multi-host
+func (s *testSSHServer) Target(name string, roles ...string) HostTarget {
+	return HostTarget{
+		Name:    name,
+		Host:    s.Host,
+		Port:    s.Port,
+		HostKey: ssh.FingerprintSHA256(s.HostKey.PublicKey()),
+		Roles:   roles,
+	}
+}
+
+// Commands retorna os comandos "exec" recebidos pelo servidor
+func (s *testSSHServer) Commands() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.commands...)
+}
+
+func (s *testSSHServer) handleConn(conn net.Conn, config *ssh.ServerConfig) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	s.mu.Lock()
+	s.conns = append(s.conns, conn)
+	s.mu.Unlock()
+	go s.handleGlobalRequests(reqs)
+
+	for newChan := range chans {
+		switch newChan.ChannelType() {
+		case "session":
+			ch, requests, err := newChan.Accept()
+			if err != nil {
+				continue
+			}
+			go s.handleSession(ch, requests)
+		case "direct-tcpip":
+			go s.handleForward(newChan)
+		default:
+			newChan.Reject(ssh.UnknownChannelType, "tipo de canal não suportado")
+		}
+	}
+}
+
+// Connections retorna quantas conexões SSH foram autenticadas
+func (s *testSSHServer) Connections() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.conns)
+}
+
+// KeepAlives retorna quantos keepalive@openssh.com foram recebidos
+func (s *testSSHServer) KeepAlives() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.keepAlives
+}
+
+// DropConnections derruba as conexões abertas, como uma queda de rede
+func (s *testSSHServer) DropConnections() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, conn := range s.conns {
+		conn.Close()
+	}
+}
+
+func (s *testSSHServer) handleGlobalRequests(requests <-chan *ssh.Request) {
+	for req := range requests {
+		if req.Type == "keepalive@openssh.com" {
+			s.mu.Lock()
+			s.keepAlives++
+			s.mu.Unlock()
+		}
+		if req.WantReply {
+			req.Reply(false, nil)
+		}
+	}
+}
+
+// Forwards retorna os destinos tunelados pelo servidor (direct-tcpip), como
+// um bastion
+func (s *testSSHServer) Forwards() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.forwards...)
+}
+
+func (s *testSSHServer) handleForward(newChan ssh.NewChannel) {
+	var payload struct {
+		Host       string
+		Port       uint32
+		OriginHost string
+		OriginPort uint32
+	}
+	if err := ssh.Unmarshal(newChan.ExtraData(), &payload); err != nil {
+		newChan.Reject(ssh.ConnectionFailed, "payload inválido")
+		return
+	}
+
+	addr := net.JoinHostPort(payload.Host, strconv.Itoa(int(payload.Port)))
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		newChan.Reject(ssh.ConnectionFailed, err.Error())
+		return
+	}
+	ch, requests, err := newChan.Accept()
+	if err != nil {
+		conn.Close()
+		return
+	}
+	go ssh.DiscardRequests(requests)
+
+	s.mu.Lock()
+	s.forwards = append(s.forwards, addr)
+	s.mu.Unlock()
+
+	go func() {
+		io.Copy(ch, conn)
+		ch.Close()
+	}()
+	io.Copy(conn, ch)
+	conn.Close()
+}
+
+func (s *testSSHServer) handleSession(ch ssh.Channel, requests <-chan *ssh.Request) {
+	defer ch.Close()
+
+	var env []string
+	for req := range requests {
+		switch req.Type {
+		case "env":
+			if s.RejectEnv {
+				req.Reply(false, nil)
+				continue
+			}
+			var kv struct{ Name, Value string }
+			if err := ssh.Unmarshal(req.Payload, &kv); err == nil {
+				env = append(env, kv.Name+"="+kv.Value)
+			}
+			req.Reply(true, nil)
+		case "exec":
+			var payload struct{ Command string }
+			if err := ssh.Unmarshal(req.Payload, &payload); err != nil {
+				req.Reply(false, nil)
+				return
+			}
+			req.Reply(true, nil)
+
+			s.mu.Lock()
+			s.commands = append(s.commands, payload.Command)
+			s.mu.Unlock()
+
+			cmd := exec.Command("/bin/sh", "-c", payload.Command)
+			cmd.Dir = s.Dir
+			cmd.Env = append(cmd.Environ(), env...)
+			cmd.Stdout = ch
+			cmd.Stderr = ch.Stderr()
+
+			// Como o sshd, não esperar o fim do stdin para encerrar a sessão
+			stdin, err := cmd.StdinPipe()
+			if err != nil {
+				return
+			}
+			go func() {
+				io.Copy(stdin, ch)
+				stdin.Close()
+			}()
+
+			status := uint32(0)
+			if err := cmd.Run(); err != nil {
+				status = 1
+				if exitErr, ok := err.(*exec.ExitError); ok {
+					status = uint32(exitErr.ExitCode())
+				}
+			}
+
+			var buf [4]byte
+			binary.BigEndian.PutUint32(buf[:], status)
+			ch.SendRequest("exit-status", false, buf[:])
+			return
+		case "subsystem":
+			var payload struct{ Name string }
+			if err := ssh.Unmarshal(req.Payload, &payload); err != nil || payload.Name != "sftp" || s.NoSFTP {
+				req.Reply(false, nil)
+				continue
+			}
+			req.Reply(true, nil)
+
+			server, err := sftp.NewServer(ch)
+			if err != nil {
+				return
+			}
+			server.Serve()
+			server.Close()
+			return
+		default:
+			req.Reply(false, nil)
+		}
+	}
+}
+
+func TestSSHDeployerPersistentSession(t *testing.T) {
+	srv := newTestSSHServer(t)
+	dir := t.TempDir()
+
+	deployer := srv.Deployer()
+
+	out := &bytes.Buffer{}
+	client, err := deployer.dial()
+	if err != nil {
+		t.Fatalf("erro ao conectar: %v", err)
+	}
+	defer client.Close()
+
+	shell, err := startRemoteShell(client, "", nil, out, io.Discard)
+	if err != nil {
+		t.Fatalf("erro ao iniciar shell: %v", err)
+	}
+
+	steps := []string{
+		"cd " + dir,
+		"export APP_NAME=meu-projeto",
+		"pwd",
+		"echo $APP_NAME",
+		"false",
+	}
+	codes := make([]int, len(steps))
+	for i, step := range steps {
+		code, err := shell.Run(step)
+		if err != nil {
+			t.Fatalf("erro no passo %d: %v", i+1, err)
+		}
+		codes[i] = code
+	}
+	if err := shell.Close(); err != nil {
+		t.Fatalf("erro ao encerrar shell: %v", err)
+	}
+
+	for i, code := range codes[:4] {
+		if code != 0 {
+			t.Errorf("passo %d: esperado código 0, obtido %d", i+1, code)
+		}
+	}
+	if codes[4] != 1 {
+		t.Errorf("esperado código 1 no último passo, obtido %d", codes[4])
+	}
+
+	output := out.String()
+	if !strings.Contains(output, dir+"\n") {
+		t.Errorf("esperado diretório %s na saída, obtido %q", dir, output)
+	}
+	if !strings.Contains(output, "meu-projeto\n") {
+		t.Errorf("esperado variável exportada na saída, obtido %q", output)
+	}
+	if strings.Contains(output, "__00CLI_STEP_") {
+		t.Errorf("marcador de passo vazou para a saída: %q", output)
+	}
+}
+
+func TestSSHDeployerStopsOnFailingStep(t *testing.T) {
+	srv := newTestSSHServer(t)
+	dir := t.TempDir()
+
+	deployer := srv.Deployer()
+
+	err := deployer.Execute([]string{
+		"cd " + dir,
+		"exit 3",
+		"touch nao-deveria-existir",
+	})
+	if err == nil {
+		t.Fatal("esperado erro quando um passo falha")
+	}
+
+	err = deployer.Execute([]string{
+		"cd " + dir,
+		"sh -c 'exit 7'",
+		"touch nao-deveria-existir",
+	})
+	if err == nil || !strings.Contains(err.Error(), strconv.Itoa(7)) {
+		t.Fatalf("esperado erro com código 7, obtido %v", err)
+	}
+	if _, statErr := exec.Command("test", "-e", dir+"/nao-deveria-existir").Output(); statErr == nil {
+		t.Error("comando após passo com falha não deveria ter sido executado")
+	}
+}
+
+func TestSSHDeployerEnvironment(t *testing.T) {
+	for _, mode := range []string{SessionPersistent, SessionIsolated} {
+		for _, rejectEnv := range []bool{false, true} {
+			name := mode
+			if rejectEnv {
+				name += " sem AcceptEnv"
+			}
+			t.Run(name, func(t *testing.T) {
+				srv := newTestSSHServer(t)
+				srv.RejectEnv = rejectEnv
+				out := filepath.Join(t.TempDir(), "env.txt")
+
+				deployer := srv.Deployer()
+				deployer.SessionMode = mode
+				deployer.Environment = map[string]string{
+					"NODE_ENV": "production",
+					"GREETING": "it's ok",
+				}
+
+				err := deployer.Execute([]string{
+					`printf '%s|%s' "$NODE_ENV" "$GREETING" > ` + out,
+				})
+				if err != nil {
+					t.Fatalf("erro no deploy: %v", err)
+				}
+
+				data, err := os.ReadFile(out)
+				if err != nil {
+					t.Fatalf("erro ao ler saída: %v", err)
+				}
+				if got, want := string(data), "production|it's ok"; got != want {
+					t.Errorf("esperado %q, obtido %q", want, got)
+				}
+			})
+		}
+	}
+}
+
+func TestSSHDeployerSyncProvision(t *testing.T) {
+	srv := newTestSSHServer(t)
+	local := t.TempDir()
+	target := filepath.Join(t.TempDir(), "provision")
+
+	files := map[string]struct {
+		content string
+		mode    os.FileMode
+	}{
+		"nginx.conf":         {"server {}\n", 0644},
+		"scripts/restart.sh": {"#!/bin/sh\necho ok\n", 0755},
+	}
+	for name, f := range files {
+		file := filepath.Join(local, name)
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(f.content), f.mode); err != nil {
+			t.Fatal(err)
+		}
+		os.Chmod(file, f.mode)
+	}
+
+	deployer := srv.Deployer()
+	deployer.Provision = &Provision{LocalPath: local, Target: target}
+	out := &bytes.Buffer{}
+	deployer.Stdout = out
+
+	countUploads := func() int {
+		return strings.Count(out.String(), "⬆️")
+	}
+
+	if err := deployer.Execute(nil); err != nil {
+		t.Fatalf("erro no primeiro deploy: %v", err)
+	}
+	if n := countUploads(); n != 2 {
+		t.Fatalf("esperados 2 uploads, obtidos %d", n)
+	}
+
+	for name, f := range files {
+		info, err := os.Stat(filepath.Join(target, name))
+		if err != nil {
+			t.Fatalf("arquivo %s não enviado: %v", name, err)
+		}
+		if info.Mode().Perm() != f.mode {
+			t.Errorf("%s: esperado modo %o, obtido %o", name, f.mode, info.Mode().Perm())
+		}
+		data, _ := os.ReadFile(filepath.Join(target, name))
+		if string(data) != f.content {
+			t.Errorf("%s: conteúdo divergente: %q", name, data)
+		}
+	}
+
+	// Sem alterações, nada deve ser reenviado
+	if err := deployer.Execute(nil); err != nil {
+		t.Fatalf("erro no segundo deploy: %v", err)
+	}
+	if n := countUploads(); n != 2 {
+		t.Errorf("arquivos inalterados foram reenviados (%d uploads)", n)
+	}
+
+	// Apenas o arquivo alterado deve ser reenviado
+	if err := os.WriteFile(filepath.Join(local, "nginx.conf"), []byte("server { listen 80; }\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	deployer.Provision.Files = []string{"nginx.conf"}
+	if err := deployer.Execute(nil); err != nil {
+		t.Fatalf("erro no terceiro deploy: %v", err)
+	}
+	if n := countUploads(); n != 3 {
+		t.Errorf("esperado 1 novo upload, total %d", n)
+	}
+}
+
+func TestSSHDeployerReleases(t *testing.T) {
+	srv := newTestSSHServer(t)
+	base := filepath.Join(t.TempDir(), "app")
+
+	deployer := srv.Deployer()
+	deployer.Releases = &Releases{
+		Path:    base,
+		Keep:    2,
+		Shared:  []string{".env", "storage/"},
+		Restart: []string{"echo $(basename $(pwd -P)) >> ../../restarts.log"},
+	}
+
+	commands := []string{
+		"echo $RELEASE_ID > release.txt",
+		"test -L .env && test -d storage",
+	}
+	// Escrever em uma release antiga (ou copiar datas com cp -p) não muda a
+	// ordem das releases, que segue o nome
+	touch := func(name string) {
+		t.Helper()
+		future := time.Now().Add(time.Hour)
+		dir := filepath.Join(base, "releases", name)
+		if err := os.WriteFile(filepath.Join(dir, "log.txt"), []byte("escrita\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		os.Chtimes(dir, future, future)
+	}
+
+	for _, name := range []string{"r1", "r2", "r3"} {
+		if name == "r3" {
+			touch("r1")
+		}
+		deployer.Releases.Name = name
+		if err := deployer.Execute(commands); err != nil {
+			t.Fatalf("erro no deploy %s: %v", name, err)
+		}
+	}
+
+	readCurrent := func() string {
+		data, err := os.ReadFile(filepath.Join(base, "current", "release.txt"))
+		if err != nil {
+			t.Fatalf("erro ao ler current: %v", err)
+		}
+		return strings.TrimSpace(string(data))
+	}
+
+	if got := readCurrent(); got != "r3" {
+		t.Errorf("esperado current r3, obtido %s", got)
+	}
+	if _, err := os.Stat(filepath.Join(base, "releases", "r1")); !os.IsNotExist(err) {
+		t.Error("release r1 deveria ter sido removida (keep=2)")
+	}
+
+	// Um deploy com falha não deve alterar current
+	deployer.Releases.Name = "r4"
+	if err := deployer.Execute([]string{"false"}); err == nil {
+		t.Fatal("esperado erro no deploy r4")
+	}
+	if got := readCurrent(); got != "r3" {
+		t.Errorf("current alterado após falha: %s", got)
+	}
+	if _, err := os.Stat(filepath.Join(base, "releases", "r4")); !os.IsNotExist(err) {
+		t.Error("release r4 com falha deveria ter sido removida")
+	}
+
+	touch("r2")
+	target, err := deployer.Rollback("")
+	if err != nil {
+		t.Fatalf("erro no rollback: %v", err)
+	}
+	if target != "r2" || readCurrent() != "r2" {
+		t.Errorf("esperado rollback para r2, obtido %s (current %s)", target, readCurrent())
+	}
+
+	data, err := os.ReadFile(filepath.Join(base, "restarts.log"))
+	if err != nil {
+		t.Fatalf("comandos de reinício não executados: %v", err)
+	}
+	if got, want := string(data), "r1\nr2\nr3\nr2\n"; got != want {
+		t.Errorf("reinícios esperados %q, obtidos %q", want, got)
+	}
+
+	if _, err := deployer.Rollback("r9"); err == nil {
+		t.Error("esperado erro ao fazer rollback para release inexistente")
+	}
+}
+
+func TestDockerDeployerRemote(t *testing.T) {
+	srv := newTestSSHServer(t)
+	project := t.TempDir()
+	remoteDir := filepath.Join(t.TempDir(), "app")
+
+	// docker-compose falso que registra os argumentos recebidos
+	bin := t.TempDir()
+	logFile := filepath.Join(t.TempDir(), "compose.log")
+	script := "#!/bin/sh\necho \"$(pwd) $*\" >> " + logFile + "\n"
+	if err := os.WriteFile(filepath.Join(bin, "docker-compose"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.MkdirAll(filepath.Join(project, "provision"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(project, "provision", "docker-compose.yml"), []byte("services: {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	deployer := &DockerDeployer{
+		ProjectPath: project,
+		ProjectName: "app",
+		Profiles:    []string{"web"},
+		Environment: map[string]string{"PATH": bin + ":" + os.Getenv("PATH")},
+		Remote:      srv.Deployer(),
+		RemoteDir:   remoteDir,
+	}
+
+	if err := deployer.Execute([]string{"docker-compose up -d"}); err != nil {
+		t.Fatalf("erro no deploy: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(remoteDir, "docker-compose.yml")); err != nil {
+		t.Errorf("compose file não enviado: %v", err)
+	}
+
+	data, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatalf("docker-compose não executado: %v", err)
+	}
+	want := remoteDir + " -f docker-compose.yml -p app --profile web up -d\n"
+	if string(data) != want {
+		t.Errorf("esperado %q, obtido %q", want, data)
+	}
+}
+
+func TestSSHDeployerTrustOnFirstUse(t *testing.T) {
+	srv := newTestSSHServer(t)
+	knownHosts := filepath.Join(t.TempDir(), "known_hosts")
+
+	asked := 0
+	deployer := srv.Deployer()
+	deployer.HostKey = ""
+	deployer.KnownHosts = []string{knownHosts}
+	deployer.ConfirmHostKey = func(question string) (bool, error) {
+		asked++
+		return true, nil
+	}
+
+	if err := deployer.Execute([]string{"true"}); err != nil {
+		t.Fatalf("erro na primeira conexão: %v", err)
+	}
+	if asked != 1 {
+		t.Fatalf("esperada 1 confirmação, obtidas %d", asked)
+	}
+
+	data, err := os.ReadFile(knownHosts)
+	if err != nil {
+		t.Fatalf("known_hosts não foi gravado: %v", err)
+	}
+	if !strings.Contains(string(data), knownhosts.Normalize(srv.Addr)) {
+		t.Errorf("known_hosts não contém o host: %q", data)
+	}
+
+	// Segunda conexão não deve perguntar novamente
+	deployer.Close()
+	if err := deployer.Execute([]string{"true"}); err != nil {
+		t.Fatalf("erro na segunda conexão: %v", err)
+	}
+	if asked != 1 {
+		t.Errorf("host conhecido não deveria pedir confirmação (%d perguntas)", asked)
+	}
+}
+
+func TestSSHDeployerRejectsUnknownHost(t *testing.T) {
+	srv := newTestSSHServer(t)
+
+	deployer := srv.Deployer()
+	deployer.HostKey = ""
+	deployer.KnownHosts = []string{filepath.Join(t.TempDir(), "known_hosts")}
+
+	deployer.ConfirmHostKey = func(question string) (bool, error) {
+		return false, nil
+	}
+	if err := deployer.Execute([]string{"true"}); err == nil {
+		t.Error("esperado erro quando o usuário rejeita a chave")
+	}
+
+	deployer.ConfirmHostKey = func(question string) (bool, error) {
+		return false, errNotInteractive
+	}
+	err := deployer.Execute([]string{"true"})
+	if err == nil || !strings.Contains(err.Error(), ssh.FingerprintSHA256(srv.HostKey.PublicKey())) {
+		t.Errorf("esperado erro com a fingerprint do host, obtido %v", err)
+	}
+	if len(srv.Commands()) != 0 {
+		t.Errorf("nenhum comando deveria ter sido executado, obtido %v", srv.Commands())
+	}
+}
+
+func TestSSHDeployerHostKeyMismatch(t *testing.T) {
+	srv := newTestSSHServer(t)
+
+	_, otherPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("erro ao gerar chave: %v", err)
+	}
+	otherKey, err := ssh.NewSignerFromKey(otherPriv)
+	if err != nil {
+		t.Fatalf("erro ao criar signer: %v", err)
+	}
+
+	knownHosts := filepath.Join(t.TempDir(), "known_hosts")
+	line := knownhosts.Line([]string{knownhosts.Normalize(srv.Addr)}, otherKey.PublicKey())
+	if err := os.WriteFile(knownHosts, []byte(line+"\n"), 0600); err != nil {
+		t.Fatalf("erro ao escrever known_hosts: %v", err)
+	}
+
+	deployer := srv.Deployer()
+	deployer.HostKey = ""
+	deployer.KnownHosts = []string{knownHosts}
+	deployer.ConfirmHostKey = func(question string) (bool, error) {
+		t.Error("chave divergente não deveria pedir confirmação")
+		return true, nil
+	}
+
+	err = deployer.Execute([]string{"true"})
+	if err == nil {
+		t.Fatal("esperado erro com chave divergente")
+	}
+	for _, fp := range []string{ssh.FingerprintSHA256(otherKey.PublicKey()), ssh.FingerprintSHA256(srv.HostKey.PublicKey())} {
+		if !strings.Contains(err.Error(), fp) {
+			t.Errorf("erro deveria conter a fingerprint %s: %v", fp, err)
+		}
+	}
+
+	// Chave fixada divergente também deve falhar
+	deployer.HostKey = string(ssh.MarshalAuthorizedKey(otherKey.PublicKey()))
+	if err := deployer.Execute([]string{"true"}); err == nil {
+		t.Error("esperado erro com server.host_key divergente")
+	}
+
+	deployer.HostKey = string(ssh.MarshalAuthorizedKey(srv.HostKey.PublicKey()))
+	if err := deployer.Execute([]string{"true"}); err != nil {
+		t.Errorf("erro com server.host_key correto: %v", err)
+	}
+}
+
+func TestMultiHostDeployerParallel(t *testing.T) {
+	web, worker := newTestSSHServer(t), newTestSSHServer(t)
+
+	out := &bytes.Buffer{}
+	multi := &MultiHostDeployer{
+		Base:   web.Deployer(),
+		Hosts:  []HostTarget{web.Target("web", "web"), worker.Target("worker", "worker")},
+		Stdout: out,
+		Stderr: io.Discard,
+	}
+
+	if err := multi.Execute([]string{"echo ola"}); err != nil {
+		t.Fatalf("erro no deploy multi-host: %v", err)
+	}
+
+	output := out.String()
+	for _, want := range []string{"[web   ] ola\n", "[worker] ola\n", "✅ web\n", "✅ worker\n"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("esperado %q na saída, obtido:\n%s", want, output)
+		}
+	}
+
+	// Filtro por papel executa apenas nos hosts correspondentes
+	before := len(web.Commands())
+	multi.Roles = []string{"worker"}
+	if err := multi.Execute([]string{"true"}); err != nil {
+		t.Fatalf("erro no deploy com papéis: %v", err)
+	}
+	if len(web.Commands()) != before {
+		t.Error("host sem o papel pedido não deveria ter sido usado")
+	}
+
+	multi.Roles = []string{"db"}
+	if err := multi.Execute([]string{"true"}); err == nil {
+		t.Error("esperado erro quando nenhum host tem o papel pedido")
+	}
+}
+
+func TestMultiHostDeployerRollingStopsOnFailure(t *testing.T) {
+	first, second := newTestSSHServer(t), newTestSSHServer(t)
+
+	// Chave fixada errada faz o primeiro lote falhar
+	broken := first.Target("first")
+	broken.HostKey = ssh.FingerprintSHA256(second.HostKey.PublicKey())
+
+	out := &bytes.Buffer{}
+	multi := &MultiHostDeployer{
+		Base:      first.Deployer(),
+		Hosts:     []HostTarget{broken, second.Target("second")},
+		Rolling:   true,
+		BatchSize: 1,
+		Stdout:    out,
+		Stderr:    io.Discard,
+	}
+
+	err := multi.Execute([]string{"true"})
+	if err == nil {
+		t.Fatal("esperado erro quando um lote falha")
+	}
+	if !strings.Contains(err.Error(), "1 de 2") {
+		t.Errorf("erro deveria informar a quantidade de falhas: %v", err)
+	}
+	if len(second.Commands()) != 0 {
+		t.Errorf("lote seguinte não deveria ter sido executado, obtido %v", second.Commands())
+	}
+
+	output := out.String()
+	for _, want := range []string{"❌ first", "⏭️  second: não executado"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("esperado %q no resumo, obtido:\n%s", want, output)
+		}
+	}
+}
+
+func TestMultiHostDeployerRollback(t *testing.T) {
+	web, worker := newTestSSHServer(t), newTestSSHServer(t)
+	web.Dir, worker.Dir = t.TempDir(), t.TempDir()
+
+	base := web.Deployer()
+	base.Releases = &Releases{Path: "app"}
+	out := &bytes.Buffer{}
+	multi := &MultiHostDeployer{
+		Base:   base,
+		Hosts:  []HostTarget{web.Target("web"), worker.Target("worker")},
+		Stdout: out,
+		Stderr: io.Discard,
+	}
+
+	for _, name := range []string{"r1", "r2"} {
+		base.Releases.Name = name
+		if err := multi.Execute([]string{"true"}); err != nil {
+			t.Fatalf("erro no deploy %s: %v", name, err)
+		}
+	}
+	current := func(srv *testSSHServer) string {
+		target, err := os.Readlink(filepath.Join(srv.Dir, "app", "current"))
+		if err != nil {
+			t.Fatalf("erro ao ler current: %v", err)
+		}
+		return filepath.Base(target)
+	}
+
+	// r1 ausente em um host: nenhum servidor é alterado
+	if err := os.RemoveAll(filepath.Join(worker.Dir, "app", "releases", "r1")); err != nil {
+		t.Fatal(err)
+	}
+	_, err := multi.Rollback("")
+	if err == nil || !strings.Contains(err.Error(), "worker") {
+		t.Fatalf("esperado erro informando o host sem a release, obtido %v", err)
+	}
+	if current(web) != "r2" || current(worker) != "r2" {
+		t.Errorf("nenhum host deveria trocar de release: web=%s worker=%s", current(web), current(worker))
+	}
+
+	// Com o destino em todos os hosts, uma falha na troca não interrompe os demais
+	if err := os.MkdirAll(filepath.Join(worker.Dir, "app", "releases", "r1"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	multi.Base.Releases.Restart = []string{"test $(basename $(pwd -P)) = r1 && test ! -e ../../fail"}
+	if err := os.WriteFile(filepath.Join(web.Dir, "app", "fail"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	out.Reset()
+	targets, err := multi.Rollback("")
+	if err == nil || !strings.Contains(err.Error(), "rollback falhou em 1 de 2") {
+		t.Fatalf("esperado erro no reinício de um host, obtido %v", err)
+	}
+	if !reflect.DeepEqual(targets, []string{"r1", "r1"}) || current(web) != "r1" || current(worker) != "r1" {
+		t.Errorf("todos os hosts deveriam ir para r1: %v web=%s worker=%s", targets, current(web), current(worker))
+	}
+	for _, want := range []string{"❌ web", "✅ worker"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("esperado %q no resumo, obtido:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestMarkerWriter(t *testing.T) {
+	results := make(chan stepResult, 4)
+	out := &bytes.Buffer{}
+	w := newMarkerWriter(out, "__M:", results)
+
+	// Escrever em pedaços pequenos para exercitar marcadores divididos
+	data := "linha 1\nparcial\n\n__M:1:0\nlinha 2\n\n__M:2:5\n"
+	for i := 0; i < len(data); i += 3 {
+		end := i + 3
+		if end > len(data) {
+			end = len(data)
+		}
+		w.Write([]byte(data[i:end]))
+	}
+	w.Flush()
+
+	if got, want := out.String(), "linha 1\nparcial\nlinha 2\n"; got != want {
+		t.Errorf("saída esperada %q, obtida %q", want, got)
+	}
+
+	close(results)
+	var got []stepResult
+	for res := range results {
+		got = append(got, res)
+	}
+	want := []stepResult{{Step: 1, ExitCode: 0}, {Step: 2, ExitCode: 5}}
+	if len(got) != len(want) {
+		t.Fatalf("esperado %d resultados, obtido %d", len(want), len(got))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("resultado %d: esperado %+v, obtido %+v", i, want[i], got[i])
+		}
+	}
+}
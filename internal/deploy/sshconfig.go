@@ -0,0 +1,221 @@
+package deploy
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// sshHostConfig são as opções do ssh_config (~/.ssh/config) aplicáveis a um
+// host. Como no OpenSSH, vale o primeiro valor encontrado de cada opção,
+// exceto IdentityFile, que acumula.
+type sshHostConfig struct {
+	HostName       string
+	User           string
+	Port           int
+	IdentityFiles  []string
+	IdentitiesOnly bool
+	IdentityAgent  string
+	ProxyJump      string
+}
+
+// DefaultSSHConfigFile retorna o caminho do ssh_config do usuário (~/.ssh/config)
+func DefaultSSHConfigFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".ssh", "config")
+}
+
+// loadSSHConfig lê as opções de alias no arquivo; arquivo ausente não é erro
+func loadSSHConfig(file, alias string) (*sshHostConfig, error) {
+	config := &sshHostConfig{}
+	if file == "" {
+		return config, nil
+	}
+
+	seen := make(map[string]bool)
+	if err := parseSSHConfig(file, filepath.Dir(file), alias, config, seen, 0); err != nil {
+		return nil, err
+	}
+
+	// Expandir ~ e os tokens %h, %r e %% nos caminhos
+	host := config.HostName
+	if host == "" {
+		host = alias
+	}
+	for i, identity := range config.IdentityFiles {
+		config.IdentityFiles[i] = expandSSHPath(identity, host, config.User)
+	}
+	if config.IdentityAgent != "" && config.IdentityAgent != "none" && config.IdentityAgent != "SSH_AUTH_SOCK" {
+		config.IdentityAgent = expandSSHPath(config.IdentityAgent, host, config.User)
+	}
+	return config, nil
+}
+
+// parseSSHConfig lê file; dir é o diretório do ssh_config principal, base
+// dos Include relativos em qualquer nível
+func parseSSHConfig(file, dir, alias string, config *sshHostConfig, seen map[string]bool, depth int) error {
+	if depth > 8 {
+		return fmt.Errorf("ssh_config: Include aninhado demais em %s", file)
+	}
+
+	f, err := os.Open(file)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("erro ao ler %s: %w", file, err)
+	}
+	defer f.Close()
+
+	// Opções antes do primeiro Host valem para todos os hosts
+	matching := true
+	scanner := bufio.NewScanner(f)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		key, args := splitSSHConfigLine(scanner.Text())
+		if key == "" {
+			continue
+		}
+
+		switch key {
+		case "host":
+			matching = matchSSHHost(args, alias)
+			continue
+		case "match":
+			// Match não é suportado: o bloco é ignorado
+			matching = false
+			continue
+		}
+		if !matching || len(args) == 0 {
+			continue
+		}
+
+		switch key {
+		case "include":
+			for _, pattern := range args {
+				if err := includeSSHConfig(dir, pattern, alias, config, seen, depth); err != nil {
+					return err
+				}
+			}
+			continue
+		case "identityfile":
+			config.IdentityFiles = append(config.IdentityFiles, args[0])
+			continue
+		}
+
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		switch key {
+		case "hostname":
+			config.HostName = args[0]
+		case "user":
+			config.User = args[0]
+		case "port":
+			port, err := strconv.Atoi(args[0])
+			if err != nil {
+				return fmt.Errorf("%s:%d: porta inválida: %s", file, lineNo, args[0])
+			}
+			config.Port = port
+		case "identitiesonly":
+			config.IdentitiesOnly = strings.EqualFold(args[0], "yes")
+		case "identityagent":
+			config.IdentityAgent = args[0]
+		case "proxyjump":
+			config.ProxyJump = args[0]
+		}
+	}
+	return scanner.Err()
+}
+
+// includeSSHConfig processa Include; caminhos relativos são relativos ao
+// diretório do ssh_config principal (~/.ssh no padrão), como no OpenSSH,
+// mesmo em um Include aninhado
+func includeSSHConfig(dir, pattern, alias string, config *sshHostConfig, seen map[string]bool, depth int) error {
+	pattern = expandSSHPath(pattern, "", "")
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(dir, pattern)
+	}
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return fmt.Errorf("ssh_config: Include inválido %q: %w", pattern, err)
+	}
+	for _, match := range matches {
+		if err := parseSSHConfig(match, dir, alias, config, seen, depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitSSHConfigLine separa "Chave valor", "Chave=valor" e argumentos entre aspas
+func splitSSHConfigLine(line string) (string, []string) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return "", nil
+	}
+
+	idx := strings.IndexAny(line, " \t=")
+	if idx < 0 {
+		return strings.ToLower(line), nil
+	}
+	key := line[:idx]
+	rest := strings.TrimSpace(line[idx:])
+	rest = strings.TrimPrefix(rest, "=")
+
+	var args []string
+	var current strings.Builder
+	inQuotes := false
+	for _, r := range strings.TrimSpace(rest) {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+		case (r == ' ' || r == '\t') && !inQuotes:
+			if current.Len() > 0 {
+				args = append(args, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteRune(r)
+		}
+	}
+	if current.Len() > 0 {
+		args = append(args, current.String())
+	}
+	return strings.ToLower(key), args
+}
+
+// matchSSHHost aplica os padrões de Host (*, ? e negação com !)
+func matchSSHHost(patterns []string, alias string) bool {
+	matched := false
+	for _, pattern := range patterns {
+		negated := strings.HasPrefix(pattern, "!")
+		pattern = strings.TrimPrefix(pattern, "!")
+		ok, err := path.Match(strings.ToLower(pattern), strings.ToLower(alias))
+		if err != nil || !ok {
+			continue
+		}
+		if negated {
+			return false
+		}
+		matched = true
+	}
+	return matched
+}
+
+// expandSSHPath expande ~ e os tokens %d, %h, %r e %% de um caminho
+func expandSSHPath(p, host, user string) string {
+	home, _ := os.UserHomeDir()
+	if p == "~" || strings.HasPrefix(p, "~/") {
+		p = filepath.Join(home, p[1:])
+	}
+	return strings.NewReplacer("%%", "%", "%d", home, "%h", host, "%r", user).Replace(p)
+}
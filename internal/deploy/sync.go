@@ -0,0 +1,478 @@
+package deploy
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/tstest3213/00cli/internal/bytesize"
+)
+
+// Sync espelha um diretório local (ex: dist/) em um diretório remoto pela
+// conexão SSH do deploy, enviando apenas os arquivos alterados, sem depender
+// de rsync em nenhum dos lados
+type Sync struct {
+	LocalPath  string   // diretório local espelhado
+	Target     string   // diretório remoto; com Releases, relativo é dentro da release
+	Delete     bool     // remove do destino o que não existe localmente
+	Exclude    []string // padrões ignorados, além dos do IgnoreFile
+	IgnoreFile string   // arquivo com padrões ignorados (ex: <projeto>/.00cliignore)
+}
+
+// syncLocal é um arquivo ou diretório do lado local
+type syncLocal struct {
+	Path    string
+	Dir     bool
+	Size    int64
+	Mode    fs.FileMode
+	ModTime time.Time
+}
+
+// syncRemote é um arquivo ou diretório já presente no destino. Via SFTP são
+// conhecidos tamanho, permissão e data; no modo SCP, apenas o checksum.
+type syncRemote struct {
+	Dir      bool
+	Size     int64
+	Mode     fs.FileMode
+	ModTime  time.Time
+	Checksum string
+}
+
+// syncStats resume uma sincronização
+type syncStats struct {
+	Uploaded  int
+	Unchanged int
+	Deleted   int
+	Bytes     int64
+}
+
+// matcher combina os padrões do IgnoreFile com os de Exclude
+func (s *Sync) matcher() (*ignoreMatcher, error) {
+	var patterns []string
+	if s.IgnoreFile != "" {
+		fromFile, err := loadIgnoreFile(s.IgnoreFile)
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, fromFile...)
+	}
+	patterns = append(patterns, s.Exclude...)
+	return newIgnoreMatcher(patterns)
+}
+
+// collect lista os arquivos e diretórios locais que não são ignorados. Links
+// simbólicos e arquivos especiais não são enviados.
+func (s *Sync) collect(ignore *ignoreMatcher) (map[string]syncLocal, error) {
+	root := filepath.Clean(s.LocalPath)
+	entries := make(map[string]syncLocal)
+
+	err := filepath.WalkDir(root, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if file == root {
+			return nil
+		}
+		rel, err := filepath.Rel(root, file)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if ignore.Match(rel, entry.IsDir()) {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !entry.IsDir() && !entry.Type().IsRegular() {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		entries[rel] = syncLocal{
+			Path:    file,
+			Dir:     entry.IsDir(),
+			Size:    info.Size(),
+			Mode:    info.Mode().Perm(),
+			ModTime: info.ModTime(),
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar diretório de sync: %w", err)
+	}
+	return entries, nil
+}
+
+// syncIntoRelease informa se o sync é feito dentro da pasta de cada release
+func (d *SSHDeployer) syncIntoRelease() bool {
+	return d.Sync != nil && d.inRelease(d.Sync.Target)
+}
+
+// seedReleaseScript copia o destino do sync da release atual para a nova,
+// preservando datas, para que apenas as diferenças sejam enviadas
+func (d *SSHDeployer) seedReleaseScript(releaseDir string) string {
+	previous := path.Join(d.Releases.currentLink(), d.Sync.Target)
+	next := path.Join(releaseDir, d.Sync.Target)
+	return fmt.Sprintf(`if [ -d %[1]s ]; then mkdir -p %[2]s && cp -pR %[1]s/. %[2]s/; fi`,
+		shellQuote(previous), shellQuote(next))
+}
+
+// syncFiles espelha Sync.LocalPath em target. Arquivos com mesmo tamanho e
+// data são considerados iguais; com datas diferentes, os checksums decidem.
+func (d *SSHDeployer) syncFiles(client sessionOpener, target string) (*syncStats, error) {
+	s := d.Sync
+	if info, err := os.Stat(s.LocalPath); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("diretório de sync não encontrado: %s", s.LocalPath)
+	}
+
+	ignore, err := s.matcher()
+	if err != nil {
+		return nil, err
+	}
+	local, err := s.collect(ignore)
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Fprintf(d.stdout(), "🔁 Sincronizando %s em %s...\n", filepath.Base(s.LocalPath), target)
+
+	transfer, err := newFileTransfer(client)
+	if err != nil {
+		return nil, err
+	}
+	defer transfer.Close()
+
+	remote, err := transfer.remoteTree(target)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &syncStats{}
+	var mkdirs, uploads, compare, conflicts, unchanged []string
+	for _, rel := range sortedKeys(local) {
+		l := local[rel]
+		r, exists := remote[rel]
+		if exists && r.Dir != l.Dir {
+			// Um arquivo no lugar de um diretório (ou o contrário) é substituído
+			conflicts = append(conflicts, rel)
+			exists = false
+		}
+
+		switch {
+		case l.Dir:
+			if !exists {
+				mkdirs = append(mkdirs, rel)
+			} else {
+				unchanged = append(unchanged, rel)
+			}
+		case !exists:
+			uploads = append(uploads, rel)
+		case transfer.sftp != nil && r.Size != l.Size:
+			uploads = append(uploads, rel)
+		case transfer.sftp != nil && r.ModTime.Unix() == l.ModTime.Unix():
+			unchanged = append(unchanged, rel)
+		default:
+			compare = append(compare, rel)
+		}
+	}
+
+	// O conteúdo de um diretório substituído por arquivo some junto com ele
+	for _, rel := range conflicts {
+		for key := range remote {
+			if strings.HasPrefix(key, rel+"/") {
+				delete(remote, key)
+			}
+		}
+	}
+
+	// Mesmo tamanho e datas diferentes (ou modo SCP): comparar checksums
+	if len(compare) > 0 {
+		sums, err := d.remoteSums(transfer, target, compare, remote)
+		if err != nil {
+			return nil, err
+		}
+		for _, rel := range compare {
+			sum, err := fileChecksum(local[rel].Path)
+			if err != nil {
+				return nil, fmt.Errorf("erro ao calcular checksum de %s: %w", rel, err)
+			}
+			if sums[rel] != "" && sums[rel] == sum {
+				unchanged = append(unchanged, rel)
+			} else {
+				uploads = append(uploads, rel)
+			}
+		}
+	}
+
+	if len(conflicts) > 0 {
+		if err := removeRemote(client, target, conflicts); err != nil {
+			return nil, err
+		}
+	}
+	if err := transfer.mkdirAll(target); err != nil {
+		return nil, err
+	}
+	for _, rel := range mkdirs {
+		if err := transfer.mkdir(path.Join(target, rel), local[rel].Mode); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, rel := range uploads {
+		l := local[rel]
+		fmt.Fprintf(d.stdout(), "  ⬆️  %s\n", rel)
+		if err := transfer.UploadFile(l.Path, path.Join(target, rel), l.Mode); err != nil {
+			return nil, fmt.Errorf("erro ao enviar %s: %w", rel, err)
+		}
+		stats.Uploaded++
+		stats.Bytes += l.Size
+	}
+	if err := d.verifySync(client, target, uploads, local); err != nil {
+		return nil, err
+	}
+
+	if err := transfer.syncMetadata(target, unchanged, local, remote); err != nil {
+		return nil, fmt.Errorf("erro ao ajustar permissões remotas: %w", err)
+	}
+	stats.Unchanged = countFiles(unchanged, local)
+
+	extraneous := extraneousPaths(local, remote, ignore)
+	if len(extraneous) > 0 {
+		if s.Delete {
+			for _, rel := range extraneous {
+				fmt.Fprintf(d.stdout(), "  🗑️  %s\n", rel)
+			}
+			if err := removeRemote(client, target, extraneous); err != nil {
+				return nil, err
+			}
+			stats.Deleted = len(extraneous)
+		} else if d.Verbose {
+			fmt.Fprintf(d.stdout(), "  ℹ️  %d item(ns) no destino não existem localmente (sync.delete desativado)\n", len(extraneous))
+		}
+	}
+
+	fmt.Fprintf(d.stdout(), "  ✅ %d enviado(s) (%s), %d sem alterações, %d removido(s)\n",
+		stats.Uploaded, bytesize.Format(stats.Bytes), stats.Unchanged, stats.Deleted)
+	return stats, nil
+}
+
+// countFiles conta os arquivos (não diretórios) da lista
+func countFiles(rels []string, local map[string]syncLocal) int {
+	n := 0
+	for _, rel := range rels {
+		if !local[rel].Dir {
+			n++
+		}
+	}
+	return n
+}
+
+// extraneousPaths lista o que existe no destino e não localmente, sem repetir
+// o conteúdo de diretórios já listados e preservando os caminhos ignorados
+func extraneousPaths(local map[string]syncLocal, remote map[string]syncRemote, ignore *ignoreMatcher) []string {
+	var paths []string
+	for _, rel := range sortedKeys(remote) {
+		if _, ok := local[rel]; ok {
+			continue
+		}
+		if n := len(paths); n > 0 && strings.HasPrefix(rel, paths[n-1]+"/") {
+			continue
+		}
+		if ignore.Match(rel, remote[rel].Dir) {
+			continue
+		}
+		paths = append(paths, rel)
+	}
+	return paths
+}
+
+// remoteSums obtém os checksums remotos dos arquivos; no modo SCP eles já
+// vieram na listagem
+func (d *SSHDeployer) remoteSums(transfer *fileTransfer, target string, rels []string, remote map[string]syncRemote) (map[string]string, error) {
+	sums := make(map[string]string, len(rels))
+	if transfer.sftp == nil {
+		for _, rel := range rels {
+			sums[rel] = remote[rel].Checksum
+		}
+		return sums, nil
+	}
+
+	files := make([]provisionFile, len(rels))
+	for i, rel := range rels {
+		files[i] = provisionFile{RelPath: rel}
+	}
+	return remoteChecksums(transfer.client, target, files)
+}
+
+// verifySync confere em lote os checksums dos arquivos enviados; servidores
+// sem sha256sum/shasum não são conferidos
+func (d *SSHDeployer) verifySync(client sessionOpener, target string, uploads []string, local map[string]syncLocal) error {
+	if len(uploads) == 0 {
+		return nil
+	}
+	files := make([]provisionFile, len(uploads))
+	for i, rel := range uploads {
+		files[i] = provisionFile{RelPath: rel}
+	}
+	remote, err := remoteChecksums(client, target, files)
+	if err != nil {
+		return err
+	}
+	for _, rel := range uploads {
+		sum, ok := remote[rel]
+		if !ok {
+			continue
+		}
+		want, err := fileChecksum(local[rel].Path)
+		if err != nil {
+			return fmt.Errorf("erro ao calcular checksum de %s: %w", rel, err)
+		}
+		if sum != want {
+			return fmt.Errorf("checksum de %s divergente após o upload", rel)
+		}
+	}
+	return nil
+}
+
+// removeRemote remove caminhos relativos a target no servidor
+func removeRemote(client sessionOpener, target string, rels []string) error {
+	script := fmt.Sprintf("cd %s && rm -rf -- %s", shellQuote(target), quoteAll(rels))
+	if err := runRemote(client, script); err != nil {
+		return fmt.Errorf("erro ao remover arquivos remotos: %w", err)
+	}
+	return nil
+}
+
+// remoteTree lista os arquivos e diretórios em root no servidor. Links
+// simbólicos (ex: caminhos compartilhados de releases) não são listados e,
+// portanto, nunca são removidos.
+func (t *fileTransfer) remoteTree(root string) (map[string]syncRemote, error) {
+	if t.sftp == nil {
+		return t.remoteTreeShell(root)
+	}
+
+	root = path.Clean(root)
+	entries := make(map[string]syncRemote)
+	if _, err := t.sftp.Lstat(root); errors.Is(err, os.ErrNotExist) {
+		return entries, nil
+	}
+
+	prefix := strings.TrimSuffix(root, "/") + "/"
+	walker := t.sftp.Walk(root)
+	for walker.Step() {
+		if err := walker.Err(); err != nil {
+			return nil, fmt.Errorf("erro ao listar %s no servidor: %w", walker.Path(), err)
+		}
+		if walker.Path() == root {
+			continue
+		}
+		rel := walker.Path()
+		if root != "." {
+			rel = strings.TrimPrefix(rel, prefix)
+		}
+
+		info := walker.Stat()
+		if !info.IsDir() && !info.Mode().IsRegular() {
+			continue
+		}
+		entries[rel] = syncRemote{
+			Dir:     info.IsDir(),
+			Size:    info.Size(),
+			Mode:    info.Mode().Perm(),
+			ModTime: info.ModTime(),
+		}
+	}
+	return entries, nil
+}
+
+// remoteTreeShell lista o destino com find e sha256sum (ou shasum) quando o
+// servidor não oferece SFTP
+func (t *fileTransfer) remoteTreeShell(root string) (map[string]syncRemote, error) {
+	script := fmt.Sprintf(`cd %s 2>/dev/null || exit 0
+find . -type d | sed 's/^/d /'
+if command -v sha256sum >/dev/null 2>&1; then find . -type f -exec sha256sum {} +
+elif command -v shasum >/dev/null 2>&1; then find . -type f -exec shasum -a 256 {} +
+else find . -type f | sed 's/^/-  /'; fi`, shellQuote(root))
+
+	output, err := runRemoteOutput(t.client, script)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar %s no servidor: %w", root, err)
+	}
+
+	entries := make(map[string]syncRemote)
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if dir, ok := strings.CutPrefix(line, "d ./"); ok {
+			entries[dir] = syncRemote{Dir: true}
+			continue
+		}
+		sum, name, ok := strings.Cut(line, "  ./")
+		if !ok {
+			continue
+		}
+		if sum == "-" {
+			sum = ""
+		}
+		entries[name] = syncRemote{Checksum: sum}
+	}
+	return entries, nil
+}
+
+// mkdirAll cria o diretório remoto, se necessário, sem alterar permissões
+func (t *fileTransfer) mkdirAll(remotePath string) error {
+	var err error
+	if t.sftp != nil {
+		err = t.sftp.MkdirAll(remotePath)
+	} else {
+		err = runRemote(t.client, "mkdir -p "+shellQuote(remotePath))
+	}
+	if err != nil {
+		return fmt.Errorf("erro ao criar diretório remoto %s: %w", remotePath, err)
+	}
+	return nil
+}
+
+// syncMetadata aplica permissão e data locais aos itens que não foram
+// reenviados. No modo SCP apenas as permissões dos arquivos são aplicadas.
+func (t *fileTransfer) syncMetadata(target string, rels []string, local map[string]syncLocal, remote map[string]syncRemote) error {
+	if t.sftp == nil {
+		var files []provisionFile
+		for _, rel := range rels {
+			if l := local[rel]; !l.Dir {
+				files = append(files, provisionFile{RelPath: rel, Mode: l.Mode})
+			}
+		}
+		if len(files) == 0 {
+			return nil
+		}
+		return runRemote(t.client, chmodScript(target, files))
+	}
+
+	for _, rel := range rels {
+		l, r := local[rel], remote[rel]
+		remotePath := path.Join(target, rel)
+		if r.Mode != l.Mode {
+			if err := t.sftp.Chmod(remotePath, l.Mode); err != nil {
+				return err
+			}
+		}
+		if !l.Dir && r.ModTime.Unix() != l.ModTime.Unix() {
+			if err := t.sftp.Chtimes(remotePath, l.ModTime, l.ModTime); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+package deploy
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIgnoreMatcher(t *testing.T) {
+	ignore, err := newIgnoreMatcher([]string{
+		"*.map",
+		"node_modules/",
+		"/config/local.json",
+		"assets/**/*.psd",
+		"logs",
+		"!logs/keep.log",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		rel   string
+		isDir bool
+		want  bool
+	}{
+		{"app.js.map", false, true},
+		{"js/app.js.map", false, true},
+		{"js/app.js", false, false},
+		{"node_modules", true, true},
+		{"node_modules/lib/index.js", false, true},
+		{"node_modules", false, false},
+		{"config/local.json", false, true},
+		{"sub/config/local.json", false, false},
+		{"assets/logo.psd", false, true},
+		{"assets/img/icons/logo.psd", false, true},
+		{"assets/logo.png", false, false},
+		{"logs", true, true},
+		// O conteúdo de um diretório ignorado não volta a ser incluído
+		{"logs/keep.log", false, true},
+	}
+	for _, tt := range tests {
+		if got := ignore.Match(tt.rel, tt.isDir); got != tt.want {
+			t.Errorf("Match(%q, %v) = %v, esperado %v", tt.rel, tt.isDir, got, tt.want)
+		}
+	}
+
+	if _, err := newIgnoreMatcher([]string{"[a-"}); err == nil {
+		t.Error("esperado erro para padrão inválido")
+	}
+}
+
+func TestLoadIgnoreFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), IgnoreFile)
+	if err := os.WriteFile(file, []byte("# mapas\n*.map\n\n  .DS_Store  \n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	patterns, err := loadIgnoreFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(patterns, ",") != "*.map,.DS_Store" {
+		t.Errorf("padrões inesperados: %q", patterns)
+	}
+
+	if patterns, err := loadIgnoreFile(filepath.Join(t.TempDir(), IgnoreFile)); err != nil || patterns != nil {
+		t.Errorf("arquivo inexistente deveria não ter padrões: %q, %v", patterns, err)
+	}
+}
+
+func TestSSHDeployerSync(t *testing.T) {
+	for _, mode := range []string{"sftp", "scp"} {
+		t.Run(mode, func(t *testing.T) {
+			srv := newTestSSHServer(t)
+			srv.NoSFTP = mode == "scp"
+
+			root := t.TempDir()
+			dist := filepath.Join(root, "dist")
+			mtime := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+			for name, content := range map[string]string{
+				"index.html":    "<html></html>",
+				"js/app.js":     "console.log(1)",
+				"js/app.js.map": "{}",
+				"bin/server":    "#!/bin/sh\n",
+			} {
+				file := filepath.Join(dist, name)
+				os.MkdirAll(filepath.Dir(file), 0755)
+				if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+				os.Chtimes(file, mtime, mtime)
+			}
+			os.Chmod(filepath.Join(dist, "bin", "server"), 0755)
+			if err := os.WriteFile(filepath.Join(root, IgnoreFile), []byte("*.map\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			target := filepath.Join(t.TempDir(), "public")
+			deployer := srv.Deployer()
+			deployer.Sync = &Sync{
+				LocalPath:  dist,
+				Target:     target,
+				Delete:     true,
+				Exclude:    []string{"uploads/"},
+				IgnoreFile: filepath.Join(root, IgnoreFile),
+			}
+			deployer.Stdout = &bytes.Buffer{}
+			defer deployer.Close()
+
+			sync := func() *syncStats {
+				t.Helper()
+				stats, err := deployer.syncFiles(deployer.connection(), target)
+				if err != nil {
+					t.Fatalf("erro no sync: %v", err)
+				}
+				return stats
+			}
+
+			stats := sync()
+			if stats.Uploaded != 3 || stats.Bytes != int64(len("<html></html>console.log(1)#!/bin/sh\n")) {
+				t.Errorf("primeiro sync: %+v", stats)
+			}
+			if _, err := os.Stat(filepath.Join(target, "js", "app.js.map")); !os.IsNotExist(err) {
+				t.Error("arquivo ignorado pelo .00cliignore foi enviado")
+			}
+			if info, err := os.Stat(filepath.Join(target, "bin", "server")); err != nil || info.Mode().Perm() != 0755 {
+				t.Errorf("permissão não preservada: %v, %v", info, err)
+			}
+
+			if stats := sync(); stats.Uploaded != 0 || stats.Unchanged != 3 {
+				t.Errorf("sync sem alterações reenviou arquivos: %+v", stats)
+			}
+
+			// Mesmo conteúdo com outra data não é reenviado; conteúdo novo é
+			later := mtime.Add(time.Hour)
+			os.Chtimes(filepath.Join(dist, "index.html"), later, later)
+			if err := os.WriteFile(filepath.Join(dist, "js", "app.js"), []byte("console.log(2)"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if stats := sync(); stats.Uploaded != 1 || stats.Unchanged != 2 {
+				t.Errorf("esperado 1 upload após alterar app.js: %+v", stats)
+			}
+			if data, _ := os.ReadFile(filepath.Join(target, "js", "app.js")); string(data) != "console.log(2)" {
+				t.Errorf("arquivo alterado não enviado: %q", data)
+			}
+
+			// Arquivos extras são removidos, exceto os ignorados
+			os.MkdirAll(filepath.Join(target, "old", "css"), 0755)
+			os.WriteFile(filepath.Join(target, "old", "css", "site.css"), nil, 0644)
+			os.MkdirAll(filepath.Join(target, "uploads"), 0755)
+			os.WriteFile(filepath.Join(target, "uploads", "avatar.png"), nil, 0644)
+			os.Remove(filepath.Join(dist, "bin", "server"))
+
+			stats = sync()
+			if stats.Deleted != 2 {
+				t.Errorf("esperadas 2 remoções (bin/server e old/): %+v", stats)
+			}
+			for _, gone := range []string{"old", "bin/server"} {
+				if _, err := os.Stat(filepath.Join(target, gone)); !os.IsNotExist(err) {
+					t.Errorf("%s deveria ter sido removido", gone)
+				}
+			}
+			if _, err := os.Stat(filepath.Join(target, "uploads", "avatar.png")); err != nil {
+				t.Errorf("arquivo ignorado foi removido do destino: %v", err)
+			}
+		})
+	}
+}
+
+func TestSSHDeployerSyncReleases(t *testing.T) {
+	srv := newTestSSHServer(t)
+	base := filepath.Join(t.TempDir(), "app")
+
+	dist := t.TempDir()
+	for _, name := range []string{"index.html", "app.js"} {
+		if err := os.WriteFile(filepath.Join(dist, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out := &bytes.Buffer{}
+	deployer := srv.Deployer()
+	deployer.Stdout = out
+	deployer.Releases = &Releases{Path: base, Shared: []string{"public/uploads/"}}
+	deployer.Sync = &Sync{LocalPath: dist, Target: "public", Delete: true}
+	defer deployer.Close()
+
+	deployer.Releases.Name = "r1"
+	if err := deployer.Execute([]string{"test -f public/index.html"}); err != nil {
+		t.Fatalf("erro no deploy r1: %v", err)
+	}
+	if n := strings.Count(out.String(), "⬆️"); n != 2 {
+		t.Errorf("esperados 2 uploads na primeira release, obtidos %d", n)
+	}
+
+	// A nova release parte da atual: nada é reenviado e o link
+	// compartilhado não é removido pelo sync
+	out.Reset()
+	deployer.Releases.Name = "r2"
+	if err := deployer.Execute([]string{"test -f public/app.js && test -L public/uploads"}); err != nil {
+		t.Fatalf("erro no deploy r2: %v", err)
+	}
+	if n := strings.Count(out.String(), "⬆️"); n != 0 {
+		t.Errorf("arquivos reenviados na segunda release: %d\n%s", n, out)
+	}
+	if data, err := os.ReadFile(filepath.Join(base, "current", "public", "index.html")); err != nil || string(data) != "index.html" {
+		t.Errorf("release r2 sem os arquivos do sync: %q, %v", data, err)
+	}
+
+	deployer.Sync.Target = "../fora"
+	deployer.Releases.Name = "r3"
+	if err := deployer.Execute(nil); err == nil {
+		t.Error("esperado erro para sync.target fora da release")
+	}
+}
@@ -0,0 +1,271 @@
+package deploy
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+// fileTransfer envia e baixa arquivos pelo subsistema SFTP do servidor ou,
+// se ele não estiver disponível, pelo protocolo SCP. Arquivos são gravados
+// com um nome temporário e renomeados no fim, preservando permissões e
+// data de modificação.
+type fileTransfer struct {
+	client  sessionOpener
+	sftp    *sftp.Client // nil no modo SCP
+	session *ssh.Session
+
+	// Verify confere o sha256 remoto depois de cada upload
+	Verify bool
+}
+
+// newFileTransfer abre uma sessão SFTP; servidores sem o subsistema usam SCP
+func newFileTransfer(client sessionOpener) (*fileTransfer, error) {
+	t := &fileTransfer{client: client}
+
+	session, err := client.NewSession()
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criar sessão: %w", err)
+	}
+	stdin, err := session.StdinPipe()
+	if err != nil {
+		session.Close()
+		return nil, fmt.Errorf("erro ao abrir stdin da sessão: %w", err)
+	}
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		session.Close()
+		return nil, fmt.Errorf("erro ao abrir stdout da sessão: %w", err)
+	}
+	if err := session.RequestSubsystem("sftp"); err != nil {
+		session.Close()
+		return t, nil
+	}
+
+	sftpClient, err := sftp.NewClientPipe(stdout, stdin)
+	if err != nil {
+		session.Close()
+		return t, nil
+	}
+	t.sftp = sftpClient
+	t.session = session
+	return t, nil
+}
+
+// Close encerra a sessão SFTP, se houver
+func (t *fileTransfer) Close() error {
+	if t.sftp == nil {
+		return nil
+	}
+	t.sftp.Close()
+	return t.session.Close()
+}
+
+// Upload envia um arquivo ou, recursivamente, um diretório
+func (t *fileTransfer) Upload(localPath, remotePath string) error {
+	info, err := os.Stat(localPath)
+	if err != nil {
+		return fmt.Errorf("erro ao abrir arquivo local: %w", err)
+	}
+	if !info.IsDir() {
+		return t.UploadFile(localPath, remotePath, info.Mode().Perm())
+	}
+
+	return filepath.WalkDir(localPath, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(localPath, file)
+		if err != nil {
+			return err
+		}
+		remote := path.Join(remotePath, filepath.ToSlash(rel))
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case entry.IsDir():
+			return t.mkdir(remote, info.Mode().Perm())
+		case info.Mode().IsRegular():
+			return t.UploadFile(file, remote, info.Mode().Perm())
+		default:
+			// Links simbólicos e arquivos especiais não são enviados
+			return nil
+		}
+	})
+}
+
+// UploadFile envia um arquivo com a permissão mode. O diretório remoto é
+// criado se necessário.
+func (t *fileTransfer) UploadFile(localPath, remotePath string, mode os.FileMode) error {
+	src, err := os.Open(localPath)
+	if err != nil {
+		return fmt.Errorf("erro ao abrir arquivo local: %w", err)
+	}
+	defer src.Close()
+
+	info, err := src.Stat()
+	if err != nil {
+		return fmt.Errorf("erro ao abrir arquivo local: %w", err)
+	}
+
+	hash := sha256.New()
+	reader := io.TeeReader(src, hash)
+	if t.sftp != nil {
+		err = t.sftpUpload(reader, info, remotePath, mode)
+	} else {
+		err = t.scpUpload(reader, info, remotePath, mode)
+	}
+	if err != nil {
+		return fmt.Errorf("erro ao fazer upload de %s: %w", filepath.Base(localPath), err)
+	}
+
+	if t.Verify {
+		return t.verify(remotePath, hex.EncodeToString(hash.Sum(nil)))
+	}
+	return nil
+}
+
+func (t *fileTransfer) sftpUpload(src io.Reader, info os.FileInfo, remotePath string, mode os.FileMode) error {
+	if err := t.sftp.MkdirAll(path.Dir(remotePath)); err != nil {
+		return fmt.Errorf("erro ao criar diretório remoto: %w", err)
+	}
+
+	tmp := tempName(remotePath)
+	dst, err := t.sftp.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_EXCL)
+	if err != nil {
+		return err
+	}
+	written, err := io.Copy(dst, src)
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = t.sftp.Chmod(tmp, mode)
+	}
+	if err == nil {
+		err = t.sftp.Chtimes(tmp, info.ModTime(), info.ModTime())
+	}
+	if err == nil {
+		err = t.checkRemoteSize(tmp, written)
+	}
+	if err == nil {
+		err = t.rename(tmp, remotePath)
+	}
+	if err != nil {
+		t.sftp.Remove(tmp)
+	}
+	return err
+}
+
+// checkRemoteSize compara o tamanho gravado no servidor com o enviado
+func (t *fileTransfer) checkRemoteSize(remotePath string, want int64) error {
+	info, err := t.sftp.Stat(remotePath)
+	if err != nil {
+		return err
+	}
+	if info.Size() != want {
+		return fmt.Errorf("tamanho remoto divergente: %d bytes, esperados %d", info.Size(), want)
+	}
+	return nil
+}
+
+// rename substitui o destino de forma atômica quando o servidor suporta
+// posix-rename; caso contrário, remove o destino antes de renomear
+func (t *fileTransfer) rename(from, to string) error {
+	if _, ok := t.sftp.HasExtension("posix-rename@openssh.com"); ok {
+		return t.sftp.PosixRename(from, to)
+	}
+	if err := t.sftp.Rename(from, to); err == nil {
+		return nil
+	}
+	t.sftp.Remove(to)
+	return t.sftp.Rename(from, to)
+}
+
+func (t *fileTransfer) mkdir(remotePath string, mode os.FileMode) error {
+	if t.sftp == nil {
+		return runRemote(t.client, fmt.Sprintf("mkdir -p %s && chmod %04o %s", shellQuote(remotePath), mode, shellQuote(remotePath)))
+	}
+	if err := t.sftp.MkdirAll(remotePath); err != nil {
+		return fmt.Errorf("erro ao criar diretório remoto %s: %w", remotePath, err)
+	}
+	return t.sftp.Chmod(remotePath, mode)
+}
+
+// verify confere o sha256 do arquivo remoto; servidores sem sha256sum ou
+// shasum não são verificados
+func (t *fileTransfer) verify(remotePath, want string) error {
+	script := fmt.Sprintf(`sha256sum %[1]s 2>/dev/null || shasum -a 256 %[1]s 2>/dev/null; true`, shellQuote(remotePath))
+	output, err := runRemoteOutput(t.client, script)
+	if err != nil {
+		return fmt.Errorf("erro ao verificar %s: %w", remotePath, err)
+	}
+	got, _, _ := strings.Cut(strings.TrimSpace(output), " ")
+	if got != "" && got != want {
+		return fmt.Errorf("checksum de %s divergente após o upload", remotePath)
+	}
+	return nil
+}
+
+// Download baixa um arquivo ou, recursivamente, um diretório do servidor
+func (t *fileTransfer) Download(remotePath, localPath string) error {
+	if t.sftp == nil {
+		return t.scpDownload(remotePath, localPath)
+	}
+
+	info, err := t.sftp.Stat(remotePath)
+	if err != nil {
+		return fmt.Errorf("erro ao acessar %s no servidor: %w", remotePath, err)
+	}
+	if !info.IsDir() {
+		return t.sftpDownloadFile(remotePath, localPath, info)
+	}
+
+	if err := os.MkdirAll(localPath, info.Mode().Perm()|0700); err != nil {
+		return err
+	}
+	entries, err := t.sftp.ReadDir(remotePath)
+	if err != nil {
+		return fmt.Errorf("erro ao listar %s no servidor: %w", remotePath, err)
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() && !entry.Mode().IsRegular() {
+			continue
+		}
+		if err := t.Download(path.Join(remotePath, entry.Name()), filepath.Join(localPath, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return os.Chmod(localPath, info.Mode().Perm())
+}
+
+func (t *fileTransfer) sftpDownloadFile(remotePath, localPath string, info os.FileInfo) error {
+	src, err := t.sftp.Open(remotePath)
+	if err != nil {
+		return fmt.Errorf("erro ao abrir %s no servidor: %w", remotePath, err)
+	}
+	defer src.Close()
+
+	return writeLocalFile(localPath, src, info.Size(), info.Mode().Perm(), info.ModTime())
+}
+
+// tempName gera o nome temporário usado durante a transferência, no mesmo
+// diretório do destino para que o rename seja atômico
+func tempName(target string) string {
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	return path.Join(path.Dir(target), "."+path.Base(target)+".00cli-"+hex.EncodeToString(suffix))
+}
@@ -0,0 +1,172 @@
+package deploy
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeTree cria arquivos com conteúdo, permissão e data conhecidos
+func writeTree(t *testing.T, dir string, mtime time.Time) map[string]os.FileMode {
+	t.Helper()
+	files := map[string]os.FileMode{
+		"app.conf":          0644,
+		"bin/start.sh":      0755,
+		"secrets/token.txt": 0600,
+	}
+	for name, mode := range files {
+		file := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte("conteúdo de "+name), mode); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chmod(file, mode); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(file, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return files
+}
+
+// checkTree confere conteúdo, permissão e data dos arquivos em dir
+func checkTree(t *testing.T, dir string, files map[string]os.FileMode, mtime time.Time) {
+	t.Helper()
+	for name, mode := range files {
+		file := filepath.Join(dir, name)
+		info, err := os.Stat(file)
+		if err != nil {
+			t.Errorf("arquivo %s não transferido: %v", name, err)
+			continue
+		}
+		if info.Mode().Perm() != mode {
+			t.Errorf("%s: permissão esperada %o, obtida %o", name, mode, info.Mode().Perm())
+		}
+		if !info.ModTime().Equal(mtime) {
+			t.Errorf("%s: data esperada %v, obtida %v", name, mtime, info.ModTime())
+		}
+		data, _ := os.ReadFile(file)
+		if string(data) != "conteúdo de "+name {
+			t.Errorf("%s: conteúdo inesperado %q", name, data)
+		}
+	}
+}
+
+func TestFileTransfer(t *testing.T) {
+	for _, mode := range []string{"sftp", "scp"} {
+		t.Run(mode, func(t *testing.T) {
+			srv := newTestSSHServer(t)
+			srv.NoSFTP = mode == "scp"
+
+			deployer := srv.Deployer()
+			defer deployer.Close()
+
+			transfer, err := newFileTransfer(deployer.connection())
+			if err != nil {
+				t.Fatalf("erro ao abrir transferência: %v", err)
+			}
+			defer transfer.Close()
+			transfer.Verify = true
+			if usingSFTP := transfer.sftp != nil; usingSFTP != (mode == "sftp") {
+				t.Fatalf("modo de transferência incorreto: sftp=%v", usingSFTP)
+			}
+
+			mtime := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+			local := t.TempDir()
+			files := writeTree(t, local, mtime)
+
+			remote := filepath.Join(t.TempDir(), "releases", "r1")
+			if err := transfer.Upload(local, remote); err != nil {
+				t.Fatalf("erro no upload do diretório: %v", err)
+			}
+			checkTree(t, remote, files, mtime)
+
+			// Nenhum arquivo temporário deve sobrar no destino
+			filepath.WalkDir(remote, func(file string, _ os.DirEntry, _ error) error {
+				if strings.Contains(filepath.Base(file), ".00cli-") {
+					t.Errorf("arquivo temporário não removido: %s", file)
+				}
+				return nil
+			})
+
+			// Baixar o diretório de volta e um arquivo isolado
+			back := filepath.Join(t.TempDir(), "copia")
+			if err := transfer.Download(remote, back); err != nil {
+				t.Fatalf("erro no download do diretório: %v", err)
+			}
+			checkTree(t, back, files, mtime)
+
+			single := filepath.Join(t.TempDir(), "start.sh")
+			if err := transfer.Download(filepath.Join(remote, "bin", "start.sh"), single); err != nil {
+				t.Fatalf("erro no download do arquivo: %v", err)
+			}
+			if info, err := os.Stat(single); err != nil || info.Mode().Perm() != 0755 {
+				t.Errorf("arquivo baixado com permissão incorreta: %v, %v", info, err)
+			}
+
+			if err := transfer.Download(filepath.Join(remote, "inexistente"), filepath.Join(t.TempDir(), "x")); err == nil {
+				t.Error("esperado erro ao baixar arquivo inexistente")
+			}
+
+			// Sobrescrever um arquivo existente
+			conf := filepath.Join(local, "app.conf")
+			if err := os.WriteFile(conf, []byte("novo"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := transfer.UploadFile(conf, filepath.Join(remote, "app.conf"), 0640); err != nil {
+				t.Fatalf("erro ao sobrescrever arquivo: %v", err)
+			}
+			info, err := os.Stat(filepath.Join(remote, "app.conf"))
+			if err != nil || info.Mode().Perm() != 0640 {
+				t.Errorf("arquivo sobrescrito com permissão incorreta: %v, %v", info, err)
+			}
+			if data, _ := os.ReadFile(filepath.Join(remote, "app.conf")); string(data) != "novo" {
+				t.Errorf("arquivo não sobrescrito: %q", data)
+			}
+		})
+	}
+}
+
+func TestSSHDeployerDownloadFile(t *testing.T) {
+	srv := newTestSSHServer(t)
+	remote := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(remote, []byte("log\n"), 0640); err != nil {
+		t.Fatal(err)
+	}
+
+	deployer := srv.Deployer()
+	defer deployer.Close()
+
+	local := filepath.Join(t.TempDir(), "logs", "app.log")
+	if err := deployer.DownloadFile(remote, local); err != nil {
+		t.Fatalf("erro no download: %v", err)
+	}
+	if data, err := os.ReadFile(local); err != nil || string(data) != "log\n" {
+		t.Errorf("arquivo baixado incorreto: %q, %v", data, err)
+	}
+}
+
+func TestParseSCPHeader(t *testing.T) {
+	mode, size, name, err := parseSCPHeader("0755 12 start.sh")
+	if err != nil || mode != 0755 || size != 12 || name != "start.sh" {
+		t.Errorf("cabeçalho interpretado incorretamente: %o %d %q %v", mode, size, name, err)
+	}
+
+	for _, line := range []string{
+		"0644 1 ../passwd",
+		"0644 1 ..",
+		"0644 1 dir/arquivo",
+		"0644 -1 arquivo",
+		"rwx 1 arquivo",
+		"0644 1",
+	} {
+		if _, _, _, err := parseSCPHeader(line); err == nil {
+			t.Errorf("esperado erro para %q", line)
+		}
+	}
+}
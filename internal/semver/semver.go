@@ -0,0 +1,143 @@
+// Package semver interpreta e compara versões semânticas, incluindo as
+// geradas pelo "git describe"; usado pelo cliente e pelo servidor de updates
+package semver
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Version é uma versão semântica (https://semver.org), com suporte aos
+// sufixos do "git describe" (v0.3.0-5-gabc1234-dirty)
+type Version struct {
+	Major, Minor, Patch int
+	Pre                 []string // identificadores de pré-release (ex: beta.1)
+	Build               string   // metadados de build (ignorados na comparação)
+	Ahead               int      // commits após a tag (git describe)
+	Dirty               bool     // árvore com alterações não commitadas
+}
+
+// describeSuffix reconhece "-<n>-g<hash>" e/ou "-dirty" no fim da versão
+var describeSuffix = regexp.MustCompile(`(?:-(\d+)-g[0-9a-f]+)?(-dirty)?$`)
+
+// Parse interpreta versões como "v1.2.3", "1.2.3-beta.1+build.5" ou
+// "v0.3.0-5-gabc1234-dirty"
+func Parse(s string) (Version, error) {
+	var v Version
+	raw := strings.TrimPrefix(strings.TrimSpace(s), "v")
+
+	if m := describeSuffix.FindStringSubmatchIndex(raw); m != nil && m[0] < len(raw) {
+		if m[2] >= 0 {
+			v.Ahead, _ = strconv.Atoi(raw[m[2]:m[3]])
+		}
+		v.Dirty = m[4] >= 0
+		raw = raw[:m[0]]
+	}
+
+	if i := strings.IndexByte(raw, '+'); i >= 0 {
+		v.Build = raw[i+1:]
+		raw = raw[:i]
+	}
+	if i := strings.IndexByte(raw, '-'); i >= 0 {
+		v.Pre = strings.Split(raw[i+1:], ".")
+		raw = raw[:i]
+		for _, id := range v.Pre {
+			if id == "" {
+				return Version{}, fmt.Errorf("versão inválida: %q", s)
+			}
+		}
+	}
+
+	parts := strings.Split(raw, ".")
+	if len(parts) > 3 || parts[0] == "" {
+		return Version{}, fmt.Errorf("versão inválida: %q", s)
+	}
+	nums := []*int{&v.Major, &v.Minor, &v.Patch}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return Version{}, fmt.Errorf("versão inválida: %q", s)
+		}
+		*nums[i] = n
+	}
+
+	return v, nil
+}
+
+// IsPrerelease informa se a versão é uma pré-release (ex: v1.0.0-beta.1)
+func (v Version) IsPrerelease() bool {
+	return len(v.Pre) > 0
+}
+
+// Compare retorna -1, 0 ou 1 se v for menor, igual ou maior que o. Builds do
+// git describe ficam após a tag de origem e antes da próxima versão.
+func (v Version) Compare(o Version) int {
+	for _, pair := range [][2]int{{v.Major, o.Major}, {v.Minor, o.Minor}, {v.Patch, o.Patch}} {
+		if c := compareInts(pair[0], pair[1]); c != 0 {
+			return c
+		}
+	}
+	if c := comparePrerelease(v.Pre, o.Pre); c != 0 {
+		return c
+	}
+	return compareInts(v.Ahead, o.Ahead)
+}
+
+// CompareStrings compara duas versões em texto
+func CompareStrings(a, b string) (int, error) {
+	va, err := Parse(a)
+	if err != nil {
+		return 0, err
+	}
+	vb, err := Parse(b)
+	if err != nil {
+		return 0, err
+	}
+	return va.Compare(vb), nil
+}
+
+// comparePrerelease segue a regra 11 do semver: sem pré-release é maior;
+// identificadores numéricos são comparados como números e são menores que
+// os alfanuméricos
+func comparePrerelease(a, b []string) int {
+	switch {
+	case len(a) == 0 && len(b) == 0:
+		return 0
+	case len(a) == 0:
+		return 1
+	case len(b) == 0:
+		return -1
+	}
+
+	for i := 0; i < len(a) && i < len(b); i++ {
+		na, errA := strconv.Atoi(a[i])
+		nb, errB := strconv.Atoi(b[i])
+		switch {
+		case errA == nil && errB == nil:
+			if c := compareInts(na, nb); c != 0 {
+				return c
+			}
+		case errA == nil:
+			return -1
+		case errB == nil:
+			return 1
+		default:
+			if c := strings.Compare(a[i], b[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	return compareInts(len(a), len(b))
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
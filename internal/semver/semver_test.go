@@ -0,0 +1,84 @@
+package semver
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	v, err := Parse("v0.3.0-5-gabc1234-dirty")
+	if err != nil {
+		t.Fatalf("erro ao interpretar versão do git describe: %v", err)
+	}
+	if v.Major != 0 || v.Minor != 3 || v.Patch != 0 || v.Ahead != 5 || !v.Dirty || v.IsPrerelease() {
+		t.Errorf("versão do git describe interpretada incorretamente: %+v", v)
+	}
+
+	v, err = Parse("1.2.3-beta.1+build.5")
+	if err != nil {
+		t.Fatalf("erro ao interpretar versão: %v", err)
+	}
+	if v.Patch != 3 || len(v.Pre) != 2 || v.Pre[0] != "beta" || v.Build != "build.5" {
+		t.Errorf("pré-release/build interpretados incorretamente: %+v", v)
+	}
+
+	for _, invalid := range []string{"", "abc1234", "v1.x.0", "v1.2.3.4", "v1.0.0-"} {
+		if _, err := Parse(invalid); err == nil {
+			t.Errorf("esperado erro para %q", invalid)
+		}
+	}
+}
+
+func TestCompareStrings(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"v1.0.0", "v1.0.0", 0},
+		{"v1.0.1", "v1.0.0", 1},
+		{"v1.10.0", "v1.9.0", 1},
+		{"v2.0.0", "v1.99.99", 1},
+		{"v1.0.0", "v1.0.0-rc.1", 1},
+		{"v1.0.0-beta.2", "v1.0.0-beta.11", -1},
+		{"v1.0.0-alpha", "v1.0.0-alpha.1", -1},
+		{"v1.0.0-alpha.1", "v1.0.0-alpha.beta", -1},
+		{"v1.0.0-beta", "v1.0.0-alpha", 1},
+		{"v1.0.0+build.1", "v1.0.0+build.2", 0},
+		{"v0.3.0-5-gabc1234-dirty", "v0.3.0", 1},
+		{"v0.3.0-5-gabc1234", "v0.3.1", -1},
+		{"v0.3.0-dirty", "v0.3.0", 0},
+		{"0.2.0", "v0.2.0", 0},
+	}
+
+	for _, tt := range tests {
+		got, err := CompareStrings(tt.a, tt.b)
+		if err != nil {
+			t.Errorf("CompareStrings(%q, %q): %v", tt.a, tt.b, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("CompareStrings(%q, %q) = %d, esperado %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+
+	if _, err := CompareStrings("v1.0.0", "latest"); err == nil {
+		t.Error("esperado erro para versão inválida")
+	}
+}
+
+func TestIsPrerelease(t *testing.T) {
+	// O canal beta aceita pré-releases; builds do git describe não são
+	tests := map[string]bool{
+		"v1.0.0":              false,
+		"v1.1.0-beta.1":       true,
+		"v1.1.0-rc.1+build.2": true,
+		"v0.3.0-5-gabc1234":   false,
+		"v0.3.0-dirty":        false,
+	}
+	for tag, want := range tests {
+		v, err := Parse(tag)
+		if err != nil {
+			t.Fatalf("erro ao interpretar %q: %v", tag, err)
+		}
+		if got := v.IsPrerelease(); got != want {
+			t.Errorf("IsPrerelease(%q) = %v, esperado %v", tag, got, want)
+		}
+	}
+}
@@ -0,0 +1,14 @@
+// Package tty detecta terminais interativos; usado pelos comandos e pelo
+// deploy para decidir se podem perguntar ou exibir progresso.
+package tty
+
+import "os"
+
+// IsTerminal verifica se o arquivo é um terminal (dispositivo de caractere)
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,331 @@
+package updateserver
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tstest3213/00cli/internal/semver"
+)
+
+const (
+	releaseFile   = "release.json"
+	checksumsFile = "checksums.txt"
+)
+
+// ReleasesDir retorna o diretório das releases versionadas
+// (<Dir>/releases/<versão>/)
+func (s *Server) ReleasesDir() string {
+	return filepath.Join(s.Dir, "releases")
+}
+
+// handleReleases atende /releases (GET lista, POST publica),
+// /releases/<versão> e /releases/<versão>/download/<arquivo>
+func (s *Server) handleReleases(w http.ResponseWriter, r *http.Request) {
+	rest := r.URL.Path[strings.Index(r.URL.Path, "/releases")+len("/releases"):]
+	rest = strings.Trim(rest, "/")
+
+	if rest == "" {
+		if r.Method == http.MethodPost {
+			s.handlePublish(w, r)
+			return
+		}
+		if !allowMethod(w, r, http.MethodGet) {
+			return
+		}
+		releases, err := s.listReleases(s.baseURL(r))
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, releases)
+		return
+	}
+
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	tag, file, isDownload := strings.Cut(rest, "/download/")
+	if !validReleaseName(tag) {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("release '%s' não encontrada", tag))
+		return
+	}
+	release, err := s.loadRelease(tag, s.baseURL(r))
+	if err != nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("release '%s' não encontrada", tag))
+		return
+	}
+
+	if !isDownload {
+		writeJSON(w, http.StatusOK, release)
+		return
+	}
+
+	if !validAssetName(file) {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("arquivo '%s' não encontrado", file))
+		return
+	}
+	path := filepath.Join(s.ReleasesDir(), tag, file)
+	if info, err := os.Stat(path); err != nil || !info.Mode().IsRegular() {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("arquivo '%s' não encontrado", file))
+		return
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", file))
+	w.Header().Set("Content-Type", "application/octet-stream")
+	http.ServeFile(w, r, path)
+}
+
+// handlePublish cria uma release versionada a partir de um upload multipart:
+// "version" (obrigatório), "body", "prerelease", "replace", "checksums"
+// (conteúdo de checksums.txt, conferido no servidor) e um ou mais "file"
+func (s *Server) handlePublish(w http.ResponseWriter, r *http.Request) {
+	if !s.authorize(w, r) {
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxUploadSize)
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("requisição de publicação inválida: %v", err))
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	version := r.FormValue("version")
+	if !validReleaseName(version) {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("versão inválida: %q", version))
+		return
+	}
+	files := r.MultipartForm.File["file"]
+	if len(files) == 0 {
+		writeError(w, http.StatusBadRequest, "nenhum arquivo enviado no campo 'file'")
+		return
+	}
+
+	target := filepath.Join(s.ReleasesDir(), version)
+	if _, err := os.Stat(target); err == nil && r.FormValue("replace") != "true" {
+		writeError(w, http.StatusConflict, fmt.Sprintf("release %s já existe", version))
+		return
+	}
+
+	expected := parseChecksums(r.FormValue("checksums"))
+
+	if err := os.MkdirAll(s.ReleasesDir(), 0755); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	staging, err := os.MkdirTemp(s.ReleasesDir(), ".publish-*")
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer os.RemoveAll(staging)
+
+	release := Release{
+		TagName:     version,
+		Name:        "Release " + version,
+		PublishedAt: time.Now().UTC(),
+		Body:        r.FormValue("body"),
+		Prerelease:  r.FormValue("prerelease") == "true",
+	}
+
+	for _, header := range files {
+		name := filepath.Base(header.Filename)
+		if !validBinaryName(name) {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("nome de binário inválido: %s (deve começar com %s)", header.Filename, binaryPrefix))
+			return
+		}
+
+		src, err := header.Open()
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		size, sum, err := writeWithChecksum(filepath.Join(staging, name), src)
+		src.Close()
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, fmt.Sprintf("erro ao salvar %s: %v", name, err))
+			return
+		}
+
+		if want, ok := expected[name]; ok && want != sum {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("checksum divergente para %s: esperado %s, recebido %s", name, want, sum))
+			return
+		}
+
+		release.Assets = append(release.Assets, Asset{Name: name, Size: size, SHA256: sum})
+	}
+	sort.Slice(release.Assets, func(i, j int) bool { return release.Assets[i].Name < release.Assets[j].Name })
+
+	var checksums strings.Builder
+	for _, asset := range release.Assets {
+		fmt.Fprintf(&checksums, "%s  %s\n", asset.SHA256, asset.Name)
+	}
+	if err := os.WriteFile(filepath.Join(staging, checksumsFile), []byte(checksums.String()), 0644); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	release.Assets = append(release.Assets, Asset{Name: checksumsFile, Size: int64(checksums.Len())})
+
+	data, err := json.MarshalIndent(release, "", "  ")
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if err := os.WriteFile(filepath.Join(staging, releaseFile), data, 0644); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Trocar a release de uma vez: quem consulta nunca vê uma release parcial
+	if err := os.RemoveAll(target); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if err := os.Rename(staging, target); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	published, _ := s.loadRelease(version, s.baseURL(r))
+	writeJSON(w, http.StatusCreated, published)
+}
+
+// listReleases retorna as releases versionadas, da maior versão para a menor
+func (s *Server) listReleases(baseURL string) ([]Release, error) {
+	entries, err := os.ReadDir(s.ReleasesDir())
+	if os.IsNotExist(err) {
+		return []Release{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar releases: %w", err)
+	}
+
+	releases := []Release{}
+	for _, entry := range entries {
+		if !entry.IsDir() || !validReleaseName(entry.Name()) {
+			continue
+		}
+		release, err := s.loadRelease(entry.Name(), baseURL)
+		if err != nil {
+			continue
+		}
+		releases = append(releases, release)
+	}
+
+	sortReleases(releases)
+	return releases, nil
+}
+
+// sortReleases ordena da maior versão para a menor, com a mesma comparação
+// do cliente, para que republicar uma tag antiga não a torne a mais recente.
+// A data de publicação só desempata e ordena tags fora do semver, no fim.
+func sortReleases(releases []Release) {
+	versions := make(map[string]semver.Version, len(releases))
+	for _, release := range releases {
+		if v, err := semver.Parse(release.TagName); err == nil {
+			versions[release.TagName] = v
+		}
+	}
+	sort.SliceStable(releases, func(i, j int) bool {
+		vi, okI := versions[releases[i].TagName]
+		vj, okJ := versions[releases[j].TagName]
+		if okI != okJ {
+			return okI
+		}
+		if okI {
+			if c := vi.Compare(vj); c != 0 {
+				return c > 0
+			}
+		}
+		return releases[i].PublishedAt.After(releases[j].PublishedAt)
+	})
+}
+
+// latestRelease retorna a release versionada mais recente do canal; ok é
+// falso quando não há releases versionadas
+func (s *Server) latestRelease(baseURL string, includePrerelease bool) (Release, bool, error) {
+	releases, err := s.listReleases(baseURL)
+	if err != nil {
+		return Release{}, false, err
+	}
+	for _, release := range releases {
+		if includePrerelease || !release.Prerelease {
+			return release, true, nil
+		}
+	}
+	return Release{}, false, nil
+}
+
+// loadRelease lê release.json e monta as URLs de download
+func (s *Server) loadRelease(tag, baseURL string) (Release, error) {
+	var release Release
+	data, err := os.ReadFile(filepath.Join(s.ReleasesDir(), tag, releaseFile))
+	if err != nil {
+		return release, err
+	}
+	if err := json.Unmarshal(data, &release); err != nil {
+		return release, err
+	}
+
+	if release.HTMLURL == "" {
+		release.HTMLURL = baseURL + "/releases/" + tag
+	}
+	for i := range release.Assets {
+		release.Assets[i].BrowserDownloadURL = fmt.Sprintf("%s/releases/%s/download/%s", baseURL, tag, release.Assets[i].Name)
+	}
+	return release, nil
+}
+
+// writeWithChecksum grava o conteúdo e retorna tamanho e sha256
+func writeWithChecksum(path string, src io.Reader) (int64, string, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0755)
+	if err != nil {
+		return 0, "", err
+	}
+
+	h := sha256.New()
+	size, err := io.Copy(io.MultiWriter(f, h), src)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return 0, "", err
+	}
+	return size, hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// parseChecksums lê o formato do sha256sum ("<hex>  <nome>")
+func parseChecksums(data string) map[string]string {
+	sums := make(map[string]string)
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 {
+			sums[strings.TrimPrefix(fields[1], "*")] = strings.ToLower(fields[0])
+		}
+	}
+	return sums
+}
+
+// validReleaseName aceita nomes de versão simples (ex: v1.2.3-beta.1)
+func validReleaseName(name string) bool {
+	if name == "" || name == "." || strings.HasPrefix(name, ".") || strings.ContainsAny(name, "/\\") {
+		return false
+	}
+	return !strings.Contains(name, "..")
+}
+
+// validAssetName aceita binários e o checksums.txt de uma release
+func validAssetName(name string) bool {
+	return validBinaryName(name) || name == checksumsFile
+}
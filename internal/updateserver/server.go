@@ -0,0 +1,442 @@
+// Package updateserver implementa o servidor de atualizações do 00cli, com o
+// mesmo contrato HTTP de server-update/api.py.
+package updateserver
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	binaryPrefix  = "00cli"
+	metadataFile  = "metadata.json"
+	maxUploadSize = 512 << 20
+)
+
+// Release é a resposta de /latest, no formato de release do GitHub
+type Release struct {
+	TagName     string    `json:"tag_name"`
+	Name        string    `json:"name"`
+	PublishedAt time.Time `json:"published_at"`
+	HTMLURL     string    `json:"html_url"`
+	Body        string    `json:"body"`
+	Prerelease  bool      `json:"prerelease,omitempty"`
+	Assets      []Asset   `json:"assets"`
+}
+
+// Asset é um binário disponível para download
+type Asset struct {
+	Name               string `json:"name"`
+	BrowserDownloadURL string `json:"browser_download_url"`
+	Size               int64  `json:"size"`
+	SHA256             string `json:"sha256,omitempty"`
+}
+
+// Server serve as releases versionadas de <Dir>/releases/<versão>/ e, sem
+// elas, os binários de <Dir>/binaries com os metadados de <Dir>/metadata.json
+type Server struct {
+	Dir     string // diretório base
+	Token   string // token Bearer exigido no upload; vazio desabilita o upload
+	BaseURL string // URL pública usada nos links de download (padrão: derivada da requisição)
+	Version string // versão anunciada quando metadata.json não define tag_name
+
+	mu     sync.Mutex
+	hashes map[string]cachedHash
+}
+
+// cachedHash evita recalcular o sha256 de binários que não mudaram
+type cachedHash struct {
+	Size    int64
+	ModTime time.Time
+	Sum     string
+}
+
+// BinariesDir retorna o diretório dos binários
+func (s *Server) BinariesDir() string {
+	return filepath.Join(s.Dir, "binaries")
+}
+
+// Handler retorna as rotas do servidor. As rotas também respondem sob o
+// prefixo /updates (ex: http://host/updates/latest).
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	for _, prefix := range []string{"", "/updates"} {
+		mux.HandleFunc(prefix+"/latest", s.handleLatest)
+		mux.HandleFunc(prefix+"/download/", s.handleDownload)
+		mux.HandleFunc(prefix+"/health", s.handleHealth)
+		mux.HandleFunc(prefix+"/upload", s.handleUpload)
+		mux.HandleFunc(prefix+"/releases", s.handleReleases)
+		mux.HandleFunc(prefix+"/releases/", s.handleReleases)
+	}
+	mux.HandleFunc("/updates", s.handleLatest)
+	mux.HandleFunc("/", s.handleIndex)
+	return mux
+}
+
+func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		writeError(w, http.StatusNotFound, "endpoint não encontrado")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"service": "00cli Update Server",
+		"version": s.latestMetadata().TagName,
+		"endpoints": map[string]string{
+			"/latest":            "Informações da última versão",
+			"/download/<binary>": "Download de binário específico",
+			"/health":            "Health check",
+			"/upload":            "POST - Envia novos binários (requer token)",
+			"/releases":          "Lista as releases; POST publica uma release versionada (requer token)",
+			"/releases/<versão>": "Informações de uma release específica",
+		},
+	})
+}
+
+func (s *Server) handleLatest(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	baseURL := s.baseURL(r)
+
+	// Releases versionadas têm precedência sobre o layout de binaries/
+	versioned, ok, err := s.latestRelease(baseURL, r.URL.Query().Get("channel") == "beta")
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if ok {
+		writeJSON(w, http.StatusOK, versioned)
+		return
+	}
+
+	release := s.latestMetadata()
+	if release.HTMLURL == "" {
+		release.HTMLURL = baseURL
+	}
+
+	assets, err := s.scanBinaries(baseURL)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	release.Assets = assets
+
+	writeJSON(w, http.StatusOK, release)
+}
+
+func (s *Server) handleDownload(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	name := r.URL.Path[strings.LastIndex(r.URL.Path, "/download/")+len("/download/"):]
+	if !validBinaryName(name) {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Binário '%s' não encontrado", name))
+		return
+	}
+
+	file := filepath.Join(s.BinariesDir(), name)
+	info, err := os.Stat(file)
+	if err != nil || !info.Mode().IsRegular() {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Binário '%s' não encontrado", name))
+		return
+	}
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	w.Header().Set("Content-Type", "application/octet-stream")
+	http.ServeFile(w, r, file)
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	names, err := s.binaryNames()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"status":   "ok",
+		"version":  s.latestMetadata().TagName,
+		"binaries": len(names),
+	})
+}
+
+// handleUpload recebe binários via multipart (campos "file" e, opcionalmente,
+// "version" e "body") e atualiza metadata.json
+func (s *Server) handleUpload(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodPost) || !s.authorize(w, r) {
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxUploadSize)
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("requisição de upload inválida: %v", err))
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	files := r.MultipartForm.File["file"]
+	if len(files) == 0 {
+		writeError(w, http.StatusBadRequest, "nenhum arquivo enviado no campo 'file'")
+		return
+	}
+
+	version := r.FormValue("version")
+	if version != "" && strings.ContainsAny(version, "/\\") {
+		writeError(w, http.StatusBadRequest, "versão inválida")
+		return
+	}
+
+	if err := os.MkdirAll(s.BinariesDir(), 0755); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var uploaded []string
+	for _, header := range files {
+		name := filepath.Base(header.Filename)
+		if !validBinaryName(name) {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("nome de binário inválido: %s (deve começar com %s)", header.Filename, binaryPrefix))
+			return
+		}
+
+		src, err := header.Open()
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		err = s.saveBinary(name, src)
+		src.Close()
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, fmt.Sprintf("erro ao salvar %s: %v", name, err))
+			return
+		}
+		uploaded = append(uploaded, name)
+	}
+
+	release := s.latestMetadata()
+	if version != "" {
+		release.TagName = version
+		release.Name = "Release " + version
+	}
+	if body := r.FormValue("body"); body != "" {
+		release.Body = body
+	}
+	release.PublishedAt = time.Now().UTC()
+	if err := s.saveMetadata(release); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"status":   "success",
+		"uploaded": uploaded,
+		"version":  release.TagName,
+	})
+}
+
+// authorize confere o token Bearer das rotas de escrita
+func (s *Server) authorize(w http.ResponseWriter, r *http.Request) bool {
+	if s.Token == "" {
+		writeError(w, http.StatusForbidden, "upload desabilitado: configure um token no servidor")
+		return false
+	}
+	provided := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(provided), []byte(s.Token)) != 1 {
+		writeError(w, http.StatusUnauthorized, "Token inválido")
+		return false
+	}
+	return true
+}
+
+// saveBinary grava o arquivo em um temporário no mesmo diretório e o
+// renomeia, para que downloads em andamento nunca vejam um arquivo parcial
+func (s *Server) saveBinary(name string, src io.Reader) error {
+	tmp, err := os.CreateTemp(s.BinariesDir(), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, src); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0755); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(s.BinariesDir(), name))
+}
+
+// latestMetadata carrega metadata.json, completando os campos ausentes
+func (s *Server) latestMetadata() Release {
+	var release Release
+	if data, err := os.ReadFile(filepath.Join(s.Dir, metadataFile)); err == nil {
+		json.Unmarshal(data, &release)
+	}
+
+	if release.TagName == "" {
+		release.TagName = s.Version
+	}
+	if release.TagName == "" {
+		release.TagName = "v0.0.0"
+	}
+	if release.Name == "" {
+		release.Name = "Release " + release.TagName
+	}
+	release.Assets = nil
+	return release
+}
+
+func (s *Server) saveMetadata(release Release) error {
+	release.Assets = nil
+	data, err := json.MarshalIndent(release, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(s.Dir, metadataFile), data, 0644)
+}
+
+// scanBinaries lista os binários disponíveis com tamanho e sha256
+func (s *Server) scanBinaries(baseURL string) ([]Asset, error) {
+	names, err := s.binaryNames()
+	if err != nil {
+		return nil, err
+	}
+
+	assets := make([]Asset, 0, len(names))
+	for _, name := range names {
+		file := filepath.Join(s.BinariesDir(), name)
+		info, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+		sum, err := s.checksum(file, info)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao calcular sha256 de %s: %w", name, err)
+		}
+		assets = append(assets, Asset{
+			Name:               name,
+			BrowserDownloadURL: baseURL + "/download/" + name,
+			Size:               info.Size(),
+			SHA256:             sum,
+		})
+	}
+	return assets, nil
+}
+
+// binaryNames lista os arquivos de binaries/ que começam com "00cli"
+func (s *Server) binaryNames() ([]string, error) {
+	entries, err := os.ReadDir(s.BinariesDir())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar binários: %w", err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if entry.Type().IsRegular() && validBinaryName(entry.Name()) {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// checksum retorna o sha256 do arquivo, reaproveitando o valor calculado
+// enquanto tamanho e data de modificação não mudarem
+func (s *Server) checksum(file string, info os.FileInfo) (string, error) {
+	s.mu.Lock()
+	cached, ok := s.hashes[file]
+	s.mu.Unlock()
+	if ok && cached.Size == info.Size() && cached.ModTime.Equal(info.ModTime()) {
+		return cached.Sum, nil
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	sum := hex.EncodeToString(h.Sum(nil))
+
+	s.mu.Lock()
+	if s.hashes == nil {
+		s.hashes = make(map[string]cachedHash)
+	}
+	s.hashes[file] = cachedHash{Size: info.Size(), ModTime: info.ModTime(), Sum: sum}
+	s.mu.Unlock()
+
+	return sum, nil
+}
+
+// baseURL retorna a URL usada nos links de download
+func (s *Server) baseURL(r *http.Request) string {
+	if s.BaseURL != "" {
+		return strings.TrimSuffix(s.BaseURL, "/")
+	}
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+
+	base := scheme + "://" + r.Host
+	if strings.HasPrefix(r.URL.Path, "/updates") {
+		base += "/updates"
+	}
+	return base
+}
+
+// validBinaryName aceita apenas nomes simples que começam com "00cli"
+func validBinaryName(name string) bool {
+	return strings.HasPrefix(name, binaryPrefix) && !strings.ContainsAny(name, "/\\") && !strings.Contains(name, "..")
+}
+
+func allowMethod(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method == method || (method == http.MethodGet && r.Method == http.MethodHead) {
+		return true
+	}
+	w.Header().Set("Allow", method)
+	writeError(w, http.StatusMethodNotAllowed, "método não permitido")
+	return false
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
@@ -0,0 +1,282 @@
+package updateserver
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newTestServer(t *testing.T, token string) (*Server, *httptest.Server) {
+	t.Helper()
+	srv := &Server{Dir: t.TempDir(), Token: token, Version: "v0.1.0"}
+	if err := os.MkdirAll(srv.BinariesDir(), 0755); err != nil {
+		t.Fatalf("erro ao criar diretório: %v", err)
+	}
+	ts := httptest.NewServer(srv.Handler())
+	t.Cleanup(ts.Close)
+	return srv, ts
+}
+
+func getJSON(t *testing.T, url string, v interface{}) int {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("erro em GET %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+	if v != nil {
+		if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+			t.Fatalf("erro ao decodificar %s: %v", url, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func upload(t *testing.T, url, token, name, version string, content []byte) *http.Response {
+	t.Helper()
+	body := &bytes.Buffer{}
+	form := multipart.NewWriter(body)
+	part, err := form.CreateFormFile("file", name)
+	if err != nil {
+		t.Fatalf("erro ao montar upload: %v", err)
+	}
+	part.Write(content)
+	form.WriteField("version", version)
+	form.Close()
+
+	req, err := http.NewRequest(http.MethodPost, url+"/upload", body)
+	if err != nil {
+		t.Fatalf("erro ao criar requisição: %v", err)
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("erro no upload: %v", err)
+	}
+	resp.Body.Close()
+	return resp
+}
+
+func TestLatestAndDownload(t *testing.T) {
+	srv, ts := newTestServer(t, "")
+	content := []byte("binário linux")
+	if err := os.WriteFile(filepath.Join(srv.BinariesDir(), "00cli-linux-amd64"), content, 0755); err != nil {
+		t.Fatalf("erro ao escrever binário: %v", err)
+	}
+	os.WriteFile(filepath.Join(srv.BinariesDir(), "outro-arquivo"), []byte("x"), 0644)
+
+	var release Release
+	if status := getJSON(t, ts.URL+"/latest", &release); status != http.StatusOK {
+		t.Fatalf("esperado 200 em /latest, obtido %d", status)
+	}
+	if release.TagName != "v0.1.0" || len(release.Assets) != 1 {
+		t.Fatalf("release inesperada: %+v", release)
+	}
+
+	sum := sha256.Sum256(content)
+	asset := release.Assets[0]
+	if asset.SHA256 != hex.EncodeToString(sum[:]) || asset.Size != int64(len(content)) {
+		t.Errorf("metadados do asset incorretos: %+v", asset)
+	}
+	if asset.BrowserDownloadURL != ts.URL+"/download/00cli-linux-amd64" {
+		t.Errorf("URL de download incorreta: %s", asset.BrowserDownloadURL)
+	}
+
+	resp, err := http.Get(asset.BrowserDownloadURL)
+	if err != nil {
+		t.Fatalf("erro no download: %v", err)
+	}
+	data, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !bytes.Equal(data, content) {
+		t.Errorf("conteúdo baixado diferente do binário")
+	}
+
+	for _, path := range []string{"/download/outro-arquivo", "/download/00cli-inexistente", "/download/..%2Fmetadata.json"} {
+		if status := getJSON(t, ts.URL+path, nil); status != http.StatusNotFound {
+			t.Errorf("esperado 404 em %s, obtido %d", path, status)
+		}
+	}
+
+	var health map[string]interface{}
+	getJSON(t, ts.URL+"/updates/health", &health)
+	if health["status"] != "ok" || health["binaries"] != float64(1) {
+		t.Errorf("health inesperado: %v", health)
+	}
+}
+
+func TestUpload(t *testing.T) {
+	srv, ts := newTestServer(t, "segredo")
+
+	if resp := upload(t, ts.URL, "", "00cli-linux-amd64", "v0.2.0", []byte("novo")); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("esperado 401 sem token, obtido %d", resp.StatusCode)
+	}
+	if resp := upload(t, ts.URL, "segredo", "../00cli-linux-amd64", "v0.2.0", []byte("novo")); resp.StatusCode != http.StatusOK {
+		t.Errorf("nome com diretório deveria ser reduzido ao nome base, obtido %d", resp.StatusCode)
+	}
+	if resp := upload(t, ts.URL, "segredo", "malicioso", "v0.2.0", []byte("novo")); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("esperado 400 para nome inválido, obtido %d", resp.StatusCode)
+	}
+
+	if _, err := os.Stat(filepath.Join(srv.BinariesDir(), "00cli-linux-amd64")); err != nil {
+		t.Errorf("binário enviado não encontrado: %v", err)
+	}
+
+	var release Release
+	getJSON(t, ts.URL+"/latest", &release)
+	if release.TagName != "v0.2.0" || len(release.Assets) != 1 {
+		t.Errorf("release após upload inesperada: %+v", release)
+	}
+
+	// Sem token configurado o upload fica desabilitado
+	_, open := newTestServer(t, "")
+	if resp := upload(t, open.URL, "", "00cli-linux-amd64", "", []byte("novo")); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("esperado 403 sem token no servidor, obtido %d", resp.StatusCode)
+	}
+}
+
+func publish(t *testing.T, url, token string, fields map[string]string, files map[string][]byte) int {
+	t.Helper()
+	body := &bytes.Buffer{}
+	form := multipart.NewWriter(body)
+	for name, content := range files {
+		part, err := form.CreateFormFile("file", name)
+		if err != nil {
+			t.Fatalf("erro ao montar publicação: %v", err)
+		}
+		part.Write(content)
+	}
+	for key, value := range fields {
+		form.WriteField(key, value)
+	}
+	form.Close()
+
+	req, err := http.NewRequest(http.MethodPost, url+"/releases", body)
+	if err != nil {
+		t.Fatalf("erro ao criar requisição: %v", err)
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("erro na publicação: %v", err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestPublishRelease(t *testing.T) {
+	_, ts := newTestServer(t, "segredo")
+	binary := []byte("binário v1")
+	sum := sha256.Sum256(binary)
+	checksums := hex.EncodeToString(sum[:]) + "  00cli-linux-amd64\n"
+
+	stable := map[string]string{"version": "v1.0.0", "body": "notas", "checksums": checksums}
+	files := map[string][]byte{"00cli-linux-amd64": binary}
+
+	if status := publish(t, ts.URL, "errado", stable, files); status != http.StatusUnauthorized {
+		t.Errorf("esperado 401 com token inválido, obtido %d", status)
+	}
+	bad := map[string]string{"version": "v1.0.0", "checksums": strings.Repeat("0", 64) + "  00cli-linux-amd64\n"}
+	if status := publish(t, ts.URL, "segredo", bad, files); status != http.StatusBadRequest {
+		t.Errorf("esperado 400 com checksum divergente, obtido %d", status)
+	}
+	if status := publish(t, ts.URL, "segredo", map[string]string{"version": "../v1"}, files); status != http.StatusBadRequest {
+		t.Errorf("esperado 400 com versão inválida, obtido %d", status)
+	}
+	if status := publish(t, ts.URL, "segredo", stable, files); status != http.StatusCreated {
+		t.Fatalf("esperado 201 na publicação, obtido %d", status)
+	}
+	if status := publish(t, ts.URL, "segredo", stable, files); status != http.StatusConflict {
+		t.Errorf("esperado 409 ao republicar a mesma versão, obtido %d", status)
+	}
+	stable["replace"] = "true"
+	if status := publish(t, ts.URL, "segredo", stable, files); status != http.StatusCreated {
+		t.Errorf("esperado 201 com replace, obtido %d", status)
+	}
+
+	beta := map[string]string{"version": "v1.1.0-beta.1", "prerelease": "true"}
+	if status := publish(t, ts.URL, "segredo", beta, map[string][]byte{"00cli-linux-amd64": []byte("beta")}); status != http.StatusCreated {
+		t.Fatalf("esperado 201 na pré-release, obtido %d", status)
+	}
+
+	var release Release
+	getJSON(t, ts.URL+"/latest", &release)
+	if release.TagName != "v1.0.0" || release.Body != "notas" || len(release.Assets) != 2 {
+		t.Fatalf("canal stable deveria receber v1.0.0 com binário e checksums: %+v", release)
+	}
+	asset := release.Assets[0]
+	if asset.SHA256 != hex.EncodeToString(sum[:]) || asset.BrowserDownloadURL != ts.URL+"/releases/v1.0.0/download/00cli-linux-amd64" {
+		t.Errorf("asset versionado incorreto: %+v", asset)
+	}
+
+	getJSON(t, ts.URL+"/updates/latest?channel=beta", &release)
+	if release.TagName != "v1.1.0-beta.1" || !release.Prerelease {
+		t.Errorf("canal beta deveria receber a pré-release: %+v", release)
+	}
+
+	resp, err := http.Get(ts.URL + "/releases/v1.0.0/download/checksums.txt")
+	if err != nil {
+		t.Fatalf("erro ao baixar checksums.txt: %v", err)
+	}
+	data, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(data) != checksums {
+		t.Errorf("checksums.txt inesperado: %q", data)
+	}
+
+	var releases []Release
+	getJSON(t, ts.URL+"/releases", &releases)
+	if len(releases) != 2 || releases[0].TagName != "v1.1.0-beta.1" {
+		t.Errorf("lista de releases inesperada: %+v", releases)
+	}
+	if status := getJSON(t, ts.URL+"/releases/v9.9.9", nil); status != http.StatusNotFound {
+		t.Errorf("esperado 404 para release inexistente, obtido %d", status)
+	}
+}
+
+func TestLatestReleaseOutOfOrder(t *testing.T) {
+	_, ts := newTestServer(t, "segredo")
+	files := map[string][]byte{"00cli-linux-amd64": []byte("binário")}
+
+	// Hotfix de uma versão anterior e republicação de uma tag antiga não
+	// podem virar a versão mais recente
+	for _, fields := range []map[string]string{
+		{"version": "v1.0.0"},
+		{"version": "v1.3.0"},
+		{"version": "v1.2.4"},
+		{"version": "v1.0.0", "replace": "true"},
+	} {
+		if status := publish(t, ts.URL, "segredo", fields, files); status != http.StatusCreated {
+			t.Fatalf("esperado 201 ao publicar %s, obtido %d", fields["version"], status)
+		}
+	}
+
+	var release Release
+	getJSON(t, ts.URL+"/latest", &release)
+	if release.TagName != "v1.3.0" {
+		t.Errorf("esperado v1.3.0 como mais recente, obtido %s", release.TagName)
+	}
+
+	var releases []Release
+	getJSON(t, ts.URL+"/releases", &releases)
+	var tags []string
+	for _, r := range releases {
+		tags = append(tags, r.TagName)
+	}
+	if got := strings.Join(tags, ","); got != "v1.3.0,v1.2.4,v1.0.0" {
+		t.Errorf("releases fora da ordem de versão: %s", got)
+	}
+}
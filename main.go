@@ -7,15 +7,16 @@ import (
 	"github.com/tstest3213/00cli/cmd"
 )
 
+// Sobrescritos no build com -ldflags; vazios usam os metadados do módulo
+// (go install)
 var (
-	version = "v0.1.0" // Será sobrescrito no build com -ldflags
+	version = ""
+	commit  = ""
+	date    = ""
 )
 
 func main() {
-	// Verificar atualizações em background (apenas se não for comando update)
-	if len(os.Args) > 1 && os.Args[1] != "update" {
-		go cmd.CheckForUpdates(version)
-	}
+	cmd.SetBuildInfo(version, commit, date)
 
 	if err := cmd.Execute(); err != nil {
 		fmt.Fprintf(os.Stderr, "Erro: %v\n", err)